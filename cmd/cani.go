@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/rest"
+)
+
+// CanIOptions contains the options for the can-i command.
+type CanIOptions struct {
+	genericclioptions.IOStreams
+	configFlags *genericclioptions.ConfigFlags
+	restConfig  *rest.Config
+	namespace   string
+	verbs       []string
+}
+
+// NewCanIOptions initializes CanIOptions with the provided IO streams.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// options := NewCanIOptions(genericclioptions.NewConfigFlags(true), streams)
+func NewCanIOptions(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *CanIOptions {
+	return &CanIOptions{
+		configFlags: configFlags,
+		IOStreams:   streams,
+	}
+}
+
+// NewCmdCanI creates a new cobra command for checking the permissions needed by kubectl-envsecret.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// cmd := NewCmdCanI(genericclioptions.NewConfigFlags(true), streams)
+// cmd.Execute()
+func NewCmdCanI(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewCanIOptions(configFlags, streams)
+
+	// canICmd represents the can-i command
+	canICmd := &cobra.Command{
+		Use:   "can-i [verb...] [flags]",
+		Short: "Check whether you may manage secrets in a namespace.",
+		Long: `The can-i command checks, using SelfSubjectAccessReviews, whether the current user may use the given verbs on secrets in the target namespace.
+
+  When no verb is given, every verb used by kubectl-envsecret is checked (get, list, create, update and delete). The command exits with a non-zero status when any verb is denied.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(cmd, args); err != nil {
+				return err
+			}
+			// From here on errors report denied verbs, not a wrong invocation.
+			cmd.SilenceUsage = true
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			if err := o.Run(ctx); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	return canICmd
+}
+
+// Complete completes all necessary settings.
+func (o *CanIOptions) Complete(cmd *cobra.Command, args []string) error {
+	o.verbs = args
+	if len(o.verbs) == 0 {
+		o.verbs = k8sapi.SecretVerbs
+	}
+
+	var err error
+
+	o.restConfig, err = o.configFlags.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+
+	ns, err := cmd.Flags().GetString("namespace")
+	if err != nil {
+		return err
+	}
+
+	if len(ns) == 0 {
+		o.namespace = "default"
+	} else {
+		o.namespace = ns
+	}
+
+	return nil
+}
+
+// Run checks the verbs and prints the results
+func (o *CanIOptions) Run(ctx context.Context) error {
+	client, err := k8sapi.NewK8sClientFromConfig(k8sapi.NewK8sConfig(o.restConfig, o.namespace))
+	if err != nil {
+		return err
+	}
+
+	checks, err := client.CheckAccess(ctx, o.verbs...)
+	if err != nil {
+		return err
+	}
+
+	denied := 0
+	w := printers.GetNewTabWriter(o.Out)
+	fmt.Fprintln(w, "VERB\tRESOURCE\tNAMESPACE\tALLOWED\tREASON")
+	for _, check := range checks {
+		allowed := "yes"
+		if !check.Allowed {
+			allowed = "no"
+			denied++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", check.Verb, check.Resource, check.Namespace, allowed, check.Reason)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if denied > 0 {
+		return fmt.Errorf("%d of %d verb(s) denied on secrets in namespace %s", denied, len(checks), o.namespace)
+	}
+
+	return nil
+}
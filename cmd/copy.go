@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/diff"
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/ogticrd/kubectl-envsecret/internal/output"
+	"github.com/ogticrd/kubectl-envsecret/internal/utils"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+)
+
+// CopyOptions contains the options for the copy command.
+type CopyOptions struct {
+	genericclioptions.IOStreams
+	configFlags    *genericclioptions.ConfigFlags
+	restConfig     *rest.Config
+	destRestConfig *rest.Config
+	namespace      string
+	secretName     string
+	toNamespace    string
+	toContext      string
+	rename         string
+	output         string
+	keys           []string
+	historyLimit   int
+	retries        int
+	dryRun         bool
+	showDiff       bool
+	overwrite      bool
+}
+
+// NewCopyOptions initializes CopyOptions with the provided IO streams.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// options := NewCopyOptions(genericclioptions.NewConfigFlags(true), streams)
+func NewCopyOptions(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *CopyOptions {
+	return &CopyOptions{
+		configFlags:  configFlags,
+		IOStreams:    streams,
+		historyLimit: k8sapi.DefaultHistoryLimit,
+	}
+}
+
+// NewCmdCopy creates a new cobra command for copying a secret between namespaces and clusters.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// cmd := NewCmdCopy(genericclioptions.NewConfigFlags(true), streams)
+// cmd.Execute()
+func NewCmdCopy(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewCopyOptions(configFlags, streams)
+
+	// copyCmd represents the copy command
+	copyCmd := &cobra.Command{
+		Use:   "copy [secret name] --to-namespace NAMESPACE [flags]",
+		Short: "Copy a secret to another namespace or cluster.",
+		Long: `The copy command reads a secret and writes it to another namespace, optionally in another cluster context.
+
+  Server-managed metadata such as uid, resourceVersion and managedFields is stripped before writing. Use --keys to copy only some of the keys and --dry-run or --diff to review the changes before they are written.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			if err := o.Run(ctx); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	copyCmd.Flags().StringVar(&o.toNamespace, "to-namespace", o.toNamespace, "Namespace to copy the secret to. Defaults to the source namespace.")
+	copyCmd.Flags().StringVar(&o.toContext, "to-context", o.toContext, "Kubeconfig context to copy the secret to. Defaults to the source context.")
+	copyCmd.Flags().StringVar(&o.rename, "rename", o.rename, "Name of the secret copy. Defaults to the source name.")
+	copyCmd.Flags().StringSliceVar(&o.keys, "keys", o.keys, "Copy only the specified keys.")
+	copyCmd.Flags().BoolVar(&o.dryRun, "dry-run", o.dryRun, "Print the changes that would be made without writing the secret.")
+	copyCmd.Flags().BoolVar(&o.showDiff, "diff", o.showDiff, "Print the changed keys to the error stream before writing the secret.")
+	copyCmd.Flags().BoolVar(&o.overwrite, "overwrite", o.overwrite, "Update the target secret if it already exists.")
+	copyCmd.Flags().StringVarP(&o.output, "output", "o", o.output, "Output format. One of: json, yaml. Prints human readable text when empty.")
+	copyCmd.Flags().IntVar(&o.historyLimit, "history-limit", o.historyLimit, "Number of previous versions of the target secret to keep. Use 0 to keep all of them.")
+
+	return copyCmd
+}
+
+// Complete completes all necessary settings.
+func (o *CopyOptions) Complete(cmd *cobra.Command, args []string) error {
+	o.secretName = args[0]
+
+	var err error
+
+	o.restConfig, err = o.configFlags.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+
+	ns, err := cmd.Flags().GetString("namespace")
+	if err != nil {
+		return err
+	}
+
+	if len(ns) == 0 {
+		o.namespace = "default"
+	} else {
+		o.namespace = ns
+	}
+
+	o.retries, err = cmd.Flags().GetInt("retries")
+	if err != nil {
+		return err
+	}
+
+	if len(o.toNamespace) == 0 {
+		o.toNamespace = o.namespace
+	}
+	if len(o.rename) == 0 {
+		o.rename = o.secretName
+	}
+	o.keys = utils.RemoveDuplicatedStringE(o.keys)
+
+	o.destRestConfig = o.restConfig
+	if len(o.toContext) > 0 {
+		destFlags := genericclioptions.NewConfigFlags(true)
+		destFlags.KubeConfig = o.configFlags.KubeConfig
+		destFlags.Timeout = o.configFlags.Timeout
+		destFlags.Context = &o.toContext
+
+		o.destRestConfig, err = destFlags.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Validate validates all set flags and args
+func (o *CopyOptions) Validate() error {
+	if err := output.ValidateFormat(o.output); err != nil {
+		return err
+	}
+	if len(o.toContext) == 0 && o.toNamespace == o.namespace && o.rename == o.secretName {
+		return fmt.Errorf("source and target are the same secret, set --to-namespace, --to-context or --rename")
+	}
+	if o.historyLimit < 0 {
+		return fmt.Errorf("--history-limit must be greater than or equal to 0")
+	}
+	return nil
+}
+
+// Run does the secret copy
+func (o *CopyOptions) Run(ctx context.Context) error {
+	source, err := k8sapi.NewK8sClientFromConfig(k8sapi.NewK8sConfig(o.restConfig, o.namespace))
+	if err != nil {
+		return err
+	}
+
+	dest, err := k8sapi.NewK8sClientFromConfig(k8sapi.NewK8sConfig(o.destRestConfig, o.toNamespace))
+	if err != nil {
+		return err
+	}
+
+	dest.WithRetryAttempts(o.retries)
+
+	if err := source.Preflight(ctx, "get"); err != nil {
+		return err
+	}
+	if err := dest.Preflight(ctx, o.requiredVerbs()...); err != nil {
+		return err
+	}
+
+	sourceSecret, err := source.GetSecret(ctx, o.secretName)
+	if err != nil {
+		return err
+	}
+
+	secret, err := k8sapi.PrepareCopy(sourceSecret, o.rename, o.keys)
+	if err != nil {
+		return err
+	}
+
+	existing, err := dest.GetSecret(ctx, o.rename)
+	if kerr.IsNotFound(err) {
+		existing, err = nil, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if existing != nil && !o.overwrite {
+		return fmt.Errorf("secret %s already exists in namespace %s, use --overwrite to replace it", o.rename, o.toNamespace)
+	}
+
+	var existingData map[string][]byte
+	action := output.ActionCreated
+	if existing != nil {
+		existingData = existing.Data
+		action = output.ActionUpdated
+	}
+	changes := diff.Compare(existingData, secret.Data)
+
+	if o.dryRun {
+		secret.Namespace = o.toNamespace
+		result := output.NewResult(secret, action)
+		result.Changes = changes.String()
+		result.DryRun = true
+		return output.Print(o.Out, o.output, result)
+	}
+
+	if o.showDiff {
+		fmt.Fprintf(o.ErrOut, "Changes for secret %s in namespace %s: %s\n", o.rename, o.toNamespace, changes)
+	}
+
+	if existing != nil && changes.Empty() {
+		return output.Print(o.Out, o.output, output.NewResult(existing, output.ActionUnchanged))
+	}
+
+	var written *v1.Secret
+	if existing != nil {
+		secrets := make(map[string]string, len(secret.Data))
+		for key, value := range secret.Data {
+			secrets[key] = string(value)
+		}
+		written, err = dest.UpdateSecret(ctx, o.rename, secrets)
+	} else {
+		written, err = dest.CreateSecretFromObject(ctx, secret)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := dest.RecordRevision(ctx, written, o.historyLimit); err != nil {
+		return err
+	}
+
+	result := output.NewResult(written, action)
+	result.Changes = changes.String()
+	return output.Print(o.Out, o.output, result)
+}
+
+// requiredVerbs returns the verbs on secrets needed in the target namespace.
+func (o *CopyOptions) requiredVerbs() []string {
+	verbs := []string{"get"}
+	if o.dryRun {
+		return verbs
+	}
+	verbs = append(verbs, "create", "list")
+	if o.overwrite {
+		verbs = append(verbs, "update")
+	}
+	if o.historyLimit > 0 {
+		verbs = append(verbs, "delete")
+	}
+	return verbs
+}
@@ -1,46 +1,83 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/ogticrd/kubectl-envsecret/internal/output"
 	"github.com/ogticrd/kubectl-envsecret/internal/parser"
-	"github.com/ogticrd/kubectl-envsecret/internal/utils"
+	"github.com/ogticrd/kubectl-envsecret/internal/schema"
+	"github.com/ogticrd/kubectl-envsecret/internal/watcher"
+	"github.com/ogticrd/kubectl-envsecret/pkg/envsecret"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
 
 // CreateOptions contains the options for the create command.
 type CreateOptions struct {
 	genericclioptions.IOStreams
-	configFlags  *genericclioptions.ConfigFlags
-	restConfig   *rest.Config
-	namespace    string
-	secretName   string
-	envFilePaths []string
+	configFlags   *genericclioptions.ConfigFlags
+	restConfig    *rest.Config
+	clientset     kubernetes.Interface
+	values        *envsecret.Values
+	output        string
+	vaultPaths    []string
+	opts          envsecret.Options
+	vaultVersion  int
+	watchDebounce time.Duration
+	watch         bool
 }
 
 // NewCreateOptions initializes CreateOptions with the provided IO streams.
 //
 // Example usage:
 // streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
-// options := NewCreateOptions(streams)
-func NewCreateOptions(streams genericclioptions.IOStreams) *CreateOptions {
+// options := NewCreateOptions(genericclioptions.NewConfigFlags(true), streams)
+func NewCreateOptions(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *CreateOptions {
 	return &CreateOptions{
-		configFlags:  genericclioptions.NewConfigFlags(true),
-		IOStreams:    streams,
-		envFilePaths: []string{".env"},
+		configFlags: configFlags,
+		IOStreams:   streams,
+		opts: envsecret.Options{
+			Warnings:     streams.ErrOut,
+			Files:        []string{".env"},
+			HistoryLimit: envsecret.DefaultHistoryLimit,
+		},
+		watchDebounce: watcher.DefaultDebounce,
 	}
 }
 
+// WithClientset sets the client used to talk to the cluster instead of one
+// created from the kubeconfig, e.g. a fake clientset in tests.
+//
+// Example usage:
+// options := NewCreateOptions(configFlags, streams).WithClientset(fake.NewSimpleClientset())
+func (o *CreateOptions) WithClientset(clientset kubernetes.Interface) *CreateOptions {
+	o.clientset = clientset
+	return o
+}
+
 // NewCmdCreate creates a new cobra command for creating Kubernetes secrets from .env files.
 //
 // Example usage:
 // streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
-// cmd := NewCmdCreate(streams)
+// cmd := NewCmdCreate(genericclioptions.NewConfigFlags(true), streams)
 // cmd.Execute()
-func NewCmdCreate(streams genericclioptions.IOStreams) *cobra.Command {
-	o := NewCreateOptions(streams)
+func NewCmdCreate(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *cobra.Command {
+	return NewCmdCreateWithOptions(NewCreateOptions(configFlags, streams))
+}
 
+// NewCmdCreateWithOptions creates the create command running with the given options.
+//
+// Example usage:
+// o := NewCreateOptions(genericclioptions.NewConfigFlags(true), streams).WithClientset(clientset)
+// cmd := NewCmdCreateWithOptions(o)
+func NewCmdCreateWithOptions(o *CreateOptions) *cobra.Command {
 	// createCmd represents the create command
 	createCmd := &cobra.Command{
 		Use:   "create [secret name] [flags]",
@@ -56,34 +93,65 @@ func NewCmdCreate(streams genericclioptions.IOStreams) *cobra.Command {
 			if err := o.Validate(); err != nil {
 				return err
 			}
-			if err := o.Run(); err != nil {
+			cmd.SilenceUsage = true
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			if err := o.Run(ctx); err != nil {
 				return err
 			}
 			return nil
 		},
 	}
 
-	createCmd.Flags().StringSliceVar(&o.envFilePaths, "from-env-file", o.envFilePaths, "Specify the path to a file to read key=val pairs to create a secret.")
+	createCmd.Flags().StringSliceVar(&o.opts.Files, "from-env-file", o.opts.Files, fmt.Sprintf("Specify the path to a file to read key=val pairs to create a secret. Also accepts the URIs %s://PATH, %s://PREFIX, %s://PATH, %s://PATH and %s://PATH.", parser.SchemeFile, parser.SchemeEnv, parser.SchemeSops, parser.SchemeOnePassword, parser.SchemeBitwarden))
 	createCmd.MarkFlagFilename("from-env-file")
+	createCmd.Flags().StringSliceVar(&o.vaultPaths, "from-vault", o.vaultPaths, "Path of a HashiCorp Vault KV v2 secret to read values from, starting with its mount, e.g. secret/api. Read after the env files, taking precedence over them. The server and token are taken from VAULT_ADDR and VAULT_TOKEN.")
+	createCmd.Flags().IntVar(&o.vaultVersion, "vault-version", o.vaultVersion, "Version of the --from-vault secret to read. Reads the latest version when 0.")
+	createCmd.Flags().BoolVar(&o.opts.Template, "template", o.opts.Template, "Render the env files as Go templates before parsing them. Templates can use .Env, .Values and the file, b64enc, b64dec and quote functions.")
+	createCmd.Flags().StringSliceVar(&o.opts.ValuesFiles, "values", o.opts.ValuesFiles, "Specify the path to a YAML file with values available to templates as .Values. Requires --template.")
+	createCmd.MarkFlagFilename("values", "yaml", "yml", "json")
+	createCmd.Flags().BoolVar(&o.opts.GenerateMissing, "generate-missing", o.opts.GenerateMissing, fmt.Sprintf("Fill keys whose values are %sLENGTH[:CHARSET] or %srsa-BITS directives with random values. Keys already in the secret keep their values.", parser.DirectivePrefix, parser.DirectivePrefix))
+	createCmd.Flags().StringVar(&o.opts.Overlay, "overlay", o.opts.Overlay, "Also load the .local, .OVERLAY and .OVERLAY.local variants of every env file that exist, e.g. .env.production, following the dotenv-flow convention.")
+	createCmd.Flags().StringVar(&o.opts.Schema, "schema", o.opts.Schema, fmt.Sprintf("Path to a schema file, usually %s, declaring the required keys, their types and default values.", schema.DefaultFile))
+	createCmd.MarkFlagFilename("schema", "schema", "yaml", "yml", "json")
+	createCmd.Flags().StringSliceVar(&o.opts.CompareFiles, "compare-env-file", o.opts.CompareFiles, "Warn about passwords, tokens and keys whose values are identical in this file, e.g. the env file of another environment.")
+	createCmd.MarkFlagFilename("compare-env-file")
+	createCmd.Flags().BoolVar(&o.opts.FailOnWarnings, "fail-on-warnings", o.opts.FailOnWarnings, "Refuse to write the secret when placeholder values, values shared with --compare-env-file or env files tracked by git are found.")
+	createCmd.Flags().StringVar(&o.opts.Owner, "owner", o.opts.Owner, "Workload owning the secret, as KIND/NAME, e.g. deployment/api. The secret is deleted along with it. Must be in the same namespace.")
+	createCmd.Flags().BoolVar(&o.opts.OwnerController, "owner-controller", o.opts.OwnerController, "Mark the --owner as the managing controller of the secret.")
+	createCmd.Flags().BoolVar(&o.opts.Overwrite, "overwrite", o.opts.Overwrite, "Update the secret if it already exists, recording the previous version in its history.")
+	createCmd.Flags().BoolVar(&o.watch, "watch", o.watch, "Keep running and update the secret every time the env files change. Implies --overwrite.")
+	createCmd.Flags().DurationVar(&o.watchDebounce, "watch-debounce", o.watchDebounce, "Time to wait for a burst of file changes to settle before updating the secret.")
+	createCmd.Flags().StringVarP(&o.output, "output", "o", o.output, "Output format. One of: json, yaml. Prints human readable text when empty.")
+	createCmd.Flags().IntVar(&o.opts.HistoryLimit, "history-limit", o.opts.HistoryLimit, "Number of previous versions of the secret to keep. Use 0 to keep all of them.")
 
 	return createCmd
 }
 
 // Complete completes all necessary settigns.
 func (o *CreateOptions) Complete(cmd *cobra.Command, args []string) error {
-	o.secretName = args[0]
+	o.opts.Name = args[0]
 
 	var err error
 
-	envFilePaths, err := cmd.Flags().GetStringSlice("from-env-file")
-	if err != nil {
-		return err
+	// Secrets read only from Vault do not need the default .env file.
+	if len(o.vaultPaths) > 0 && !cmd.Flags().Changed("from-env-file") {
+		o.opts.Files = nil
+	}
+	for _, path := range o.vaultPaths {
+		source, err := envsecret.NewVaultSource(path, o.vaultVersion)
+		if err != nil {
+			return err
+		}
+		o.opts.Sources = append(o.opts.Sources, source)
 	}
-	o.envFilePaths = utils.RemoveDuplicatedStringE(envFilePaths)
 
-	o.restConfig, err = o.configFlags.ToRESTConfig()
-	if err != nil {
-		return err
+	// An injected clientset does not need the kubeconfig.
+	if o.clientset == nil {
+		o.restConfig, err = o.configFlags.ToRESTConfig()
+		if err != nil {
+			return err
+		}
 	}
 
 	ns, err := cmd.Flags().GetString("namespace")
@@ -92,9 +160,19 @@ func (o *CreateOptions) Complete(cmd *cobra.Command, args []string) error {
 	}
 
 	if len(ns) == 0 {
-		o.namespace = "default"
+		o.opts.Namespace = "default"
 	} else {
-		o.namespace = ns
+		o.opts.Namespace = ns
+	}
+
+	o.opts.Retries, err = cmd.Flags().GetInt("retries")
+	if err != nil {
+		return err
+	}
+
+	// Watching updates the secret it created on the first write.
+	if o.watch {
+		o.opts.Overwrite = true
 	}
 
 	return nil
@@ -102,27 +180,139 @@ func (o *CreateOptions) Complete(cmd *cobra.Command, args []string) error {
 
 // Validate validates all set flags and args
 func (o *CreateOptions) Validate() error {
-	// Validate that paths exists
-	return utils.ValidatePaths(o.envFilePaths)
+	if err := output.ValidateFormat(o.output); err != nil {
+		return err
+	}
+	if o.watchDebounce <= 0 {
+		return fmt.Errorf("--watch-debounce must be greater than 0")
+	}
+	if o.vaultVersion < 0 {
+		return fmt.Errorf("--vault-version must be greater than or equal to 0")
+	}
+	if o.vaultVersion > 0 && len(o.vaultPaths) != 1 {
+		return fmt.Errorf("--vault-version requires exactly one --from-vault")
+	}
+	if err := o.opts.Validate(); err != nil {
+		return flagError(err)
+	}
+	return nil
 }
 
 // Run does the secret creation
-func (o *CreateOptions) Run() error {
+func (o *CreateOptions) Run(ctx context.Context) error {
 	var err error
+	clientset := o.clientset
+	if clientset == nil {
+		clientset, err = kubernetes.NewForConfig(o.restConfig)
+		if err != nil {
+			return err
+		}
+	}
 
-	client, err := k8sapi.NewK8sClientFromConfig(k8sapi.NewK8sConfig(o.restConfig, o.namespace))
-	if err != nil {
+	// Permissions are checked before reading sources, which may decrypt
+	// files or call Vault for nothing.
+	client := k8sapi.NewK8sClient(clientset, o.opts.Namespace)
+	if err := client.Preflight(ctx, o.requiredVerbs()...); err != nil {
 		return err
 	}
 
-	parsedFile, err := parser.Load(o.envFilePaths...)
+	o.values, err = envsecret.Load(ctx, o.opts)
+	if err != nil {
+		return flagError(err)
+	}
+	if o.watch && len(o.values.Files) == 0 {
+		return fmt.Errorf("--watch requires at least one source read from a file")
+	}
+
+	result, err := envsecret.Apply(ctx, clientset, o.opts, o.values)
 	if err != nil {
 		return err
 	}
+	if err := o.print(result); err != nil {
+		return err
+	}
+
+	if o.watch {
+		return o.runWatch(ctx, clientset)
+	}
+
+	return nil
+}
+
+// print writes the result of applying the secret in the selected output format.
+func (o *CreateOptions) print(result *envsecret.Result) error {
+	printed := output.NewResult(result.Secret, result.Action)
+	printed.Changes = result.Changes
+	return output.Print(o.Out, o.output, printed)
+}
+
+// flagErrors words the errors of envsecret.Options.Validate after the flags
+// setting the options.
+var flagErrors = map[error]string{
+	envsecret.ErrValuesWithoutTemplate:  "--values requires --template",
+	envsecret.ErrTemplateSources:        "--template only supports .env files, not other sources",
+	envsecret.ErrControllerWithoutOwner: "--owner-controller requires --owner",
+	envsecret.ErrNegativeHistoryLimit:   "--history-limit must be greater than or equal to 0",
+}
 
-	if err := client.CreateSecret(o.secretName, parsedFile); err != nil {
+// flagError words errors of the envsecret package about its options after
+// the flags setting them.
+func flagError(err error) error {
+	for target, message := range flagErrors {
+		if errors.Is(err, target) {
+			return errors.New(message)
+		}
+	}
+	var directivesErr *envsecret.DirectivesError
+	if errors.As(err, &directivesErr) {
+		return fmt.Errorf("keys %s use %s directives, use --generate-missing to fill them", strings.Join(directivesErr.Keys, ", "), parser.DirectivePrefix)
+	}
+	return err
+}
+
+// requiredVerbs returns the verbs on secrets needed to run the command.
+func (o *CreateOptions) requiredVerbs() []string {
+	// Recording a revision lists and reads previous revisions.
+	verbs := []string{"create", "get", "list"}
+	if o.opts.Overwrite {
+		verbs = append(verbs, "update")
+	}
+	if o.opts.HistoryLimit > 0 {
+		verbs = append(verbs, "delete")
+	}
+	return verbs
+}
+
+// runWatch updates the secret every time the env files change until the
+// process is interrupted.
+func (o *CreateOptions) runWatch(ctx context.Context, clientset kubernetes.Interface) error {
+	// Values files change the rendered templates as much as the env files.
+	paths := append(append([]string{}, o.values.Files...), o.opts.ValuesFiles...)
+	fmt.Fprintf(o.ErrOut, "Watching %v for changes. Press Ctrl-C to stop.\n", paths)
+
+	reload := func() error {
+		values, err := envsecret.Load(ctx, o.opts)
+		if err != nil {
+			return flagError(err)
+		}
+
+		result, err := envsecret.Apply(ctx, clientset, o.opts, values)
+		if err != nil {
+			return err
+		}
+		if result.Action == envsecret.ActionUnchanged {
+			return nil
+		}
+		return o.print(result)
+	}
+
+	err := watcher.Watch(ctx, paths, o.watchDebounce, reload, func(err error) {
+		fmt.Fprintf(o.ErrOut, "%s Error updating secret %s: %v\n", time.Now().Format(time.TimeOnly), o.opts.Name, err)
+	})
+	if err != nil {
 		return err
 	}
 
+	fmt.Fprintln(o.ErrOut, "Stopped watching.")
 	return nil
 }
@@ -0,0 +1,238 @@
+package cmd_test
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/cmd"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var update = flag.Bool("update", false, "Rewrite the golden files in testdata with the current output.")
+
+var (
+	secretsResource     = schema.GroupResource{Resource: "secrets"}
+	deploymentsResource = schema.GroupResource{Group: "apps", Resource: "deployments"}
+)
+
+// denyVerbs makes the fake clientset answer access reviews, denying only the given verbs.
+func denyVerbs(client *fake.Clientset, verbs ...string) {
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = true
+		for _, verb := range verbs {
+			if review.Spec.ResourceAttributes.Verb == verb {
+				review.Status.Allowed = false
+			}
+		}
+		return true, review, nil
+	})
+}
+
+// failTimes makes the first n calls of verb on secrets fail with err.
+func failTimes(client *fake.Clientset, verb string, n int, err error) {
+	calls := 0
+	client.PrependReactor(verb, "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		calls++
+		if calls <= n {
+			return true, nil, err
+		}
+		return false, nil, nil
+	})
+}
+
+// existingSecret returns a secret named api in the default namespace.
+func existingSecret(data map[string]string) *v1.Secret {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+		Data:       make(map[string][]byte, len(data)),
+	}
+	for key, value := range data {
+		secret.Data[key] = []byte(value)
+	}
+	return secret
+}
+
+// runCreate runs the create command of the plugin against the clientset from
+// a temporary directory holding envFile, copied from testdata, as .env.
+func runCreate(t *testing.T, clientset *fake.Clientset, envFile string, args ...string) (string, string, error) {
+	content, err := os.ReadFile(filepath.Join("testdata", "create", envFile))
+	require.NoError(t, err)
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), content, 0600))
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	return runWith(t, func(streams genericiooptions.IOStreams) *cobra.Command {
+		o := cmd.NewCreateOptions(genericclioptions.NewConfigFlags(true), streams).WithClientset(clientset)
+		return cmd.NewCmdCreateWithOptions(o)
+	}, append([]string{"create"}, args...)...)
+}
+
+// golden renders what a create run did: its output, its error, the requests
+// sent to the cluster and the data of the secret it left behind.
+func golden(t *testing.T, clientset *fake.Clientset, stdout, stderr string, runErr error) string {
+	var b strings.Builder
+
+	// The usage lists every flag, pinning it would break with each new one.
+	if before, _, found := strings.Cut(stdout, "Usage:"); found {
+		stdout = before + "(usage)\n"
+	}
+	fmt.Fprintf(&b, "--- stdout\n%s", stdout)
+	fmt.Fprintf(&b, "--- stderr\n%s", stderr)
+	if runErr != nil {
+		fmt.Fprintf(&b, "--- error\n%s\n", runErr)
+	}
+
+	b.WriteString("--- requests\n")
+	for _, action := range clientset.Actions() {
+		if named, ok := action.(k8stesting.GetAction); ok {
+			fmt.Fprintf(&b, "%s %s %s\n", action.GetVerb(), action.GetResource().Resource, named.GetName())
+			continue
+		}
+		fmt.Fprintf(&b, "%s %s\n", action.GetVerb(), action.GetResource().Resource)
+	}
+
+	b.WriteString("--- secret\n")
+	secret, err := clientset.Tracker().Get(v1.SchemeGroupVersion.WithResource("secrets"), "default", "api")
+	switch {
+	case kerr.IsNotFound(err):
+		b.WriteString("not found\n")
+	case err != nil:
+		require.NoError(t, err)
+	default:
+		data := secret.(*v1.Secret).Data
+		keys := make([]string, 0, len(data))
+		for key := range data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(&b, "%s=%q\n", key, data[key])
+		}
+	}
+
+	return b.String()
+}
+
+func TestCmdCreateGolden(t *testing.T) {
+	conflict := kerr.NewConflict(secretsResource, "api", errors.New("the object has been modified"))
+
+	tests := []struct {
+		setup   func(*fake.Clientset)
+		name    string
+		envFile string
+		args    []string
+		objects []runtime.Object
+		wantErr bool
+	}{
+		{name: "multiline", envFile: "multiline.env"},
+		{name: "quotes", envFile: "quotes.env"},
+		{name: "comments", envFile: "comments.env"},
+		{name: "unterminated", envFile: "unterminated.env", wantErr: true},
+		{name: "directives", envFile: "directives.env", wantErr: true},
+		{name: "values-without-template", envFile: "basic.env", args: []string{"--values", "values.yaml"}, wantErr: true},
+		{name: "output-yaml", envFile: "basic.env", args: []string{"-o", "yaml"}},
+		{
+			name:    "already-exists",
+			envFile: "basic.env",
+			objects: []runtime.Object{existingSecret(map[string]string{"API_URL": "https://old.example.com"})},
+			wantErr: true,
+		},
+		{
+			name:    "overwrite",
+			envFile: "basic.env",
+			args:    []string{"--overwrite"},
+			objects: []runtime.Object{existingSecret(map[string]string{"API_URL": "https://old.example.com", "DEBUG": "true"})},
+		},
+		{
+			name:    "overwrite-unchanged",
+			envFile: "basic.env",
+			args:    []string{"--overwrite"},
+			objects: []runtime.Object{existingSecret(map[string]string{"API_URL": "https://api.example.com", "LOG_LEVEL": "debug"})},
+		},
+		{
+			name:    "forbidden-preflight",
+			envFile: "basic.env",
+			args:    []string{"--overwrite"},
+			setup:   func(c *fake.Clientset) { denyVerbs(c, "create", "update") },
+			wantErr: true,
+		},
+		{
+			name:    "forbidden-before-load",
+			envFile: "unterminated.env",
+			setup:   func(c *fake.Clientset) { denyVerbs(c, "create") },
+			wantErr: true,
+		},
+		{
+			name:    "forbidden",
+			envFile: "basic.env",
+			setup: func(c *fake.Clientset) {
+				failTimes(c, "create", 1, kerr.NewForbidden(secretsResource, "api", errors.New("denied by admission webhook")))
+			},
+			wantErr: true,
+		},
+		{
+			name:    "conflict-retried",
+			envFile: "basic.env",
+			args:    []string{"--overwrite"},
+			objects: []runtime.Object{existingSecret(map[string]string{"API_URL": "https://old.example.com"})},
+			setup:   func(c *fake.Clientset) { failTimes(c, "update", 1, conflict) },
+		},
+		{
+			name:    "conflict-exhausted",
+			envFile: "basic.env",
+			args:    []string{"--overwrite", "--retries", "1"},
+			objects: []runtime.Object{existingSecret(map[string]string{"API_URL": "https://old.example.com"})},
+			setup:   func(c *fake.Clientset) { failTimes(c, "update", 1, conflict) },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset(tt.objects...)
+			denyVerbs(clientset)
+			if tt.setup != nil {
+				tt.setup(clientset)
+			}
+
+			stdout, stderr, err := runCreate(t, clientset, tt.envFile, append([]string{"api"}, tt.args...)...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			actual := golden(t, clientset, stdout, stderr, err)
+			path := filepath.Join("testdata", "create", tt.name+".golden")
+			if *update {
+				require.NoError(t, os.WriteFile(path, []byte(actual), 0644))
+			}
+			expected, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), actual)
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/ogticrd/kubectl-envsecret/internal/output"
+	"github.com/ogticrd/kubectl-envsecret/internal/parser"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// DeleteOptions contains the options for the delete command.
+type DeleteOptions struct {
+	genericclioptions.IOStreams
+	configFlags *genericclioptions.ConfigFlags
+	restConfig  *rest.Config
+	clientset   kubernetes.Interface
+	namespace   string
+	secretName  string
+	output      string
+	backupPath  string
+	retries     int
+	force       bool
+	dryRun      bool
+	unmanaged   bool
+}
+
+// NewDeleteOptions initializes DeleteOptions with the provided IO streams.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// options := NewDeleteOptions(genericclioptions.NewConfigFlags(true), streams)
+func NewDeleteOptions(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *DeleteOptions {
+	return &DeleteOptions{
+		configFlags: configFlags,
+		IOStreams:   streams,
+	}
+}
+
+// WithClientset sets the client used to talk to the cluster instead of one
+// created from the kubeconfig, e.g. a fake clientset in tests.
+//
+// Example usage:
+// options := NewDeleteOptions(configFlags, streams).WithClientset(fake.NewSimpleClientset())
+func (o *DeleteOptions) WithClientset(clientset kubernetes.Interface) *DeleteOptions {
+	o.clientset = clientset
+	return o
+}
+
+// NewCmdDelete creates a new cobra command for deleting a secret that is no longer used.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// cmd := NewCmdDelete(genericclioptions.NewConfigFlags(true), streams)
+// cmd.Execute()
+func NewCmdDelete(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *cobra.Command {
+	return NewCmdDeleteWithOptions(NewDeleteOptions(configFlags, streams))
+}
+
+// NewCmdDeleteWithOptions creates the delete command running with the given options.
+//
+// Example usage:
+// o := NewDeleteOptions(genericclioptions.NewConfigFlags(true), streams).WithClientset(clientset)
+// cmd := NewCmdDeleteWithOptions(o)
+func NewCmdDeleteWithOptions(o *DeleteOptions) *cobra.Command {
+	// deleteCmd represents the delete command
+	deleteCmd := &cobra.Command{
+		Use:   "delete [secret name] [flags]",
+		Short: "Delete a secret that is no longer used.",
+		Long: `The delete command deletes a secret along with its recorded revisions.
+
+  It refuses to delete a secret still used by workloads in the namespace, as reported by the who-uses command, unless --force is given. Only secrets written by kubectl-envsecret are deleted, unless --unmanaged is given. Use --backup-to to save the data of the secret to a .env file before deleting it, and --dry-run to run every check without deleting anything.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			if err := o.Run(ctx); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	deleteCmd.Flags().BoolVar(&o.force, "force", o.force, "Delete the secret even if workloads still use it.")
+	deleteCmd.Flags().BoolVar(&o.dryRun, "dry-run", o.dryRun, "Run every check and print what would be deleted without deleting the secret.")
+	deleteCmd.Flags().BoolVar(&o.unmanaged, "unmanaged", o.unmanaged, "Allow deleting secrets that were not written by kubectl-envsecret.")
+	deleteCmd.Flags().StringVar(&o.backupPath, "backup-to", o.backupPath, "Save the data of the secret to this .env file before deleting it. The file must not exist.")
+	deleteCmd.MarkFlagFilename("backup-to")
+	deleteCmd.Flags().StringVarP(&o.output, "output", "o", o.output, "Output format. One of: json, yaml. Prints human readable text when empty.")
+
+	return deleteCmd
+}
+
+// Complete completes all necessary settings.
+func (o *DeleteOptions) Complete(cmd *cobra.Command, args []string) error {
+	o.secretName = args[0]
+
+	var err error
+
+	// An injected clientset does not need the kubeconfig.
+	if o.clientset == nil {
+		o.restConfig, err = o.configFlags.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+	}
+
+	ns, err := cmd.Flags().GetString("namespace")
+	if err != nil {
+		return err
+	}
+
+	if len(ns) == 0 {
+		o.namespace = "default"
+	} else {
+		o.namespace = ns
+	}
+
+	o.retries, err = cmd.Flags().GetInt("retries")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate validates all set flags and args
+func (o *DeleteOptions) Validate() error {
+	if err := output.ValidateFormat(o.output); err != nil {
+		return err
+	}
+	if len(o.backupPath) > 0 {
+		if _, err := os.Stat(o.backupPath); err == nil {
+			return fmt.Errorf("backup file %s already exists", o.backupPath)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run checks that the secret can be deleted, backs it up and deletes it
+func (o *DeleteOptions) Run(ctx context.Context) error {
+	var client *k8sapi.K8sClient
+	if o.clientset != nil {
+		client = k8sapi.NewK8sClient(o.clientset, o.namespace)
+	} else {
+		var err error
+		client, err = k8sapi.NewK8sClientFromConfig(k8sapi.NewK8sConfig(o.restConfig, o.namespace))
+		if err != nil {
+			return err
+		}
+	}
+
+	client.WithRetryAttempts(o.retries)
+
+	verbs := []string{"get"}
+	if !o.dryRun {
+		verbs = append(verbs, "delete")
+	}
+	if err := client.Preflight(ctx, verbs...); err != nil {
+		return err
+	}
+
+	secret, err := client.GetSecret(ctx, o.secretName)
+	if err != nil {
+		return err
+	}
+
+	if !k8sapi.IsManaged(secret) && !o.unmanaged {
+		return fmt.Errorf("secret %s is not managed by kubectl-envsecret, use --unmanaged to delete it anyway", o.secretName)
+	}
+
+	consumers, err := client.FindConsumers(ctx, o.secretName)
+	if err != nil {
+		if !o.force {
+			return fmt.Errorf("cannot check which workloads use secret %s, use --force to delete it anyway: %w", o.secretName, err)
+		}
+		fmt.Fprintf(o.ErrOut, "Warning: cannot check which workloads use secret %s, deleting it anyway: %v\n", o.secretName, err)
+	}
+	if len(consumers) > 0 {
+		names := make([]string, 0, len(consumers))
+		for _, consumer := range consumers {
+			names = append(names, consumer.String())
+		}
+		if !o.force {
+			return fmt.Errorf("secret %s is used by %s, use --force to delete it anyway", o.secretName, strings.Join(names, ", "))
+		}
+		fmt.Fprintf(o.ErrOut, "Warning: deleting secret %s used by %s\n", o.secretName, strings.Join(names, ", "))
+	}
+
+	result := output.NewResult(secret, output.ActionDeleted)
+	if o.dryRun {
+		result.DryRun = true
+		return output.Print(o.Out, o.output, result)
+	}
+
+	if len(o.backupPath) > 0 {
+		if err := o.backup(secret.Data); err != nil {
+			return err
+		}
+	}
+
+	if err := client.DeleteSecret(ctx, secret); err != nil {
+		return err
+	}
+
+	return output.Print(o.Out, o.output, result)
+}
+
+// backup writes the data of the secret to the backup file, only readable by
+// the current user.
+func (o *DeleteOptions) backup(data map[string][]byte) error {
+	content, left := parser.Marshal(data)
+	if len(left) > 0 {
+		return fmt.Errorf("cannot back up keys %s as text, the secret was not deleted", strings.Join(left, ", "))
+	}
+
+	file, err := os.OpenFile(o.backupPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(o.ErrOut, "Saved a backup of secret %s to %s\n", o.secretName, o.backupPath)
+	return nil
+}
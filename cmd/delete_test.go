@@ -0,0 +1,65 @@
+package cmd_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/cmd"
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// failListDeployments makes listing deployments fail, as for a user whose
+// Role only grants access to secrets.
+func failListDeployments(client *fake.Clientset) {
+	client.PrependReactor("list", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, kerr.NewForbidden(deploymentsResource, "", errors.New("cannot list deployments"))
+	})
+}
+
+func runDelete(t *testing.T, clientset *fake.Clientset, args ...string) (string, string, error) {
+	return runWith(t, func(streams genericiooptions.IOStreams) *cobra.Command {
+		o := cmd.NewDeleteOptions(genericclioptions.NewConfigFlags(true), streams).WithClientset(clientset)
+		return cmd.NewCmdDeleteWithOptions(o)
+	}, append([]string{"delete"}, args...)...)
+}
+
+func TestCmdDeleteConsumersUnknown(t *testing.T) {
+	secret := existingSecret(map[string]string{"API_URL": "https://api.example.com"})
+	secret.Labels = map[string]string{k8sapi.LabelManagedBy: k8sapi.ManagedByValue}
+
+	t.Run("refuses without --force", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset(secret.DeepCopy())
+		denyVerbs(clientset)
+		failListDeployments(clientset)
+
+		_, _, err := runDelete(t, clientset, "api")
+		assert.ErrorContains(t, err, "cannot check which workloads use secret api, use --force to delete it anyway")
+
+		_, err = clientset.CoreV1().Secrets("default").Get(context.Background(), "api", metav1.GetOptions{})
+		assert.NoError(t, err)
+	})
+	t.Run("deletes with --force and a warning", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset(secret.DeepCopy())
+		denyVerbs(clientset)
+		failListDeployments(clientset)
+
+		stdout, stderr, err := runDelete(t, clientset, "api", "--force")
+		require.NoError(t, err)
+		assert.Contains(t, stderr, "Warning: cannot check which workloads use secret api, deleting it anyway")
+		assert.Contains(t, stdout, "secret/api deleted in namespace default")
+
+		_, err = clientset.CoreV1().Secrets("default").Get(context.Background(), "api", metav1.GetOptions{})
+		assert.True(t, kerr.IsNotFound(err))
+	})
+}
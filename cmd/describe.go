@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/rest"
+)
+
+// DescribeOptions contains the options for the describe command.
+type DescribeOptions struct {
+	genericclioptions.IOStreams
+	configFlags *genericclioptions.ConfigFlags
+	restConfig  *rest.Config
+	namespace   string
+	secretName  string
+}
+
+// NewDescribeOptions initializes DescribeOptions with the provided IO streams.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// options := NewDescribeOptions(genericclioptions.NewConfigFlags(true), streams)
+func NewDescribeOptions(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *DescribeOptions {
+	return &DescribeOptions{
+		configFlags: configFlags,
+		IOStreams:   streams,
+	}
+}
+
+// NewCmdDescribe creates a new cobra command for showing the details of a secret.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// cmd := NewCmdDescribe(genericclioptions.NewConfigFlags(true), streams)
+// cmd.Execute()
+func NewCmdDescribe(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewDescribeOptions(configFlags, streams)
+
+	// describeCmd represents the describe command
+	describeCmd := &cobra.Command{
+		Use:   "describe [secret name] [flags]",
+		Short: "Show the details of a secret and the workloads using it.",
+		Long: `The describe command shows what kubectl-envsecret recorded about a secret, its keys with the length of their values, and the workloads in the namespace that use it.
+
+  Values are never printed. Workloads are found in the pod templates of Deployments, StatefulSets, DaemonSets, Jobs, CronJobs and Pods.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(cmd, args); err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			if err := o.Run(ctx); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	return describeCmd
+}
+
+// Complete completes all necessary settings.
+func (o *DescribeOptions) Complete(cmd *cobra.Command, args []string) error {
+	o.secretName = args[0]
+
+	var err error
+
+	o.restConfig, err = o.configFlags.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+
+	ns, err := cmd.Flags().GetString("namespace")
+	if err != nil {
+		return err
+	}
+
+	if len(ns) == 0 {
+		o.namespace = "default"
+	} else {
+		o.namespace = ns
+	}
+
+	return nil
+}
+
+// Run prints the details of the secret
+func (o *DescribeOptions) Run(ctx context.Context) error {
+	client, err := k8sapi.NewK8sClientFromConfig(k8sapi.NewK8sConfig(o.restConfig, o.namespace))
+	if err != nil {
+		return err
+	}
+
+	if err := client.Preflight(ctx, "get"); err != nil {
+		return err
+	}
+
+	secret, err := client.GetSecret(ctx, o.secretName)
+	if err != nil {
+		return err
+	}
+
+	managed := "no"
+	if k8sapi.IsManaged(secret) {
+		managed = "yes"
+	}
+	updated := "<unknown>"
+	if at, ok := secret.Annotations[k8sapi.AnnotationUpdatedAt]; ok {
+		updated = fmt.Sprintf("%s (%s ago)", at, updatedAge(secret))
+	}
+	sources := strings.Join(k8sapi.SourceFiles(secret), ", ")
+	if len(sources) == 0 {
+		sources = "<none>"
+	}
+
+	w := printers.GetNewTabWriter(o.Out)
+	fmt.Fprintf(w, "Name:\t%s\n", secret.Name)
+	fmt.Fprintf(w, "Namespace:\t%s\n", secret.Namespace)
+	fmt.Fprintf(w, "Type:\t%s\n", secret.Type)
+	fmt.Fprintf(w, "Managed:\t%s\n", managed)
+	fmt.Fprintf(w, "Hash:\t%s\n", contentHash(secret))
+	fmt.Fprintf(w, "Updated:\t%s\n", updated)
+	fmt.Fprintf(w, "Sources:\t%s\n", sources)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprint(o.Out, "\nData\n====\n")
+	w = printers.GetNewTabWriter(o.Out)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s:\t%d bytes\n", key, len(secret.Data[key]))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprint(o.Out, "\nUsed By\n=======\n")
+	consumers, err := client.FindConsumers(ctx, o.secretName)
+	if err != nil {
+		// The secret itself was described, so missing access to workloads is
+		// not worth failing for.
+		fmt.Fprintf(o.Out, "<unknown: %v>\n", err)
+		return nil
+	}
+	if len(consumers) == 0 {
+		fmt.Fprintln(o.Out, "<none>")
+	}
+	for _, consumer := range consumers {
+		fmt.Fprintf(o.Out, "%s:\n", consumer)
+		for _, ref := range consumer.References {
+			fmt.Fprintf(o.Out, "  %s\n", ref)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/drift"
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/ogticrd/kubectl-envsecret/internal/manifest"
+	"github.com/ogticrd/kubectl-envsecret/internal/schema"
+	"github.com/ogticrd/kubectl-envsecret/internal/utils"
+	"github.com/ogticrd/kubectl-envsecret/pkg/envsecret"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// DriftOptions contains the options for the drift command.
+type DriftOptions struct {
+	genericclioptions.IOStreams
+	configFlags  *genericclioptions.ConfigFlags
+	restConfig   *rest.Config
+	clientset    kubernetes.Interface
+	namespace    string
+	manifestPath string
+	mode         string
+	output       string
+	overlay      string
+	schemaPath   string
+	targets      []manifest.Target
+	envFilePaths []string
+	valuesFiles  []string
+	template     bool
+}
+
+// NewDriftOptions initializes DriftOptions with the provided IO streams.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// options := NewDriftOptions(genericclioptions.NewConfigFlags(true), streams)
+func NewDriftOptions(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *DriftOptions {
+	return &DriftOptions{
+		configFlags:  configFlags,
+		IOStreams:    streams,
+		envFilePaths: []string{".env"},
+		mode:         string(drift.ModeFull),
+		output:       "text",
+	}
+}
+
+// WithClientset sets the client used to talk to the cluster instead of one
+// created from the kubeconfig, e.g. a fake clientset in tests.
+//
+// Example usage:
+// options := NewDriftOptions(configFlags, streams).WithClientset(fake.NewSimpleClientset())
+func (o *DriftOptions) WithClientset(clientset kubernetes.Interface) *DriftOptions {
+	o.clientset = clientset
+	return o
+}
+
+// NewCmdDrift creates a new cobra command for detecting secrets modified outside kubectl-envsecret.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// cmd := NewCmdDrift(genericclioptions.NewConfigFlags(true), streams)
+// cmd.Execute()
+func NewCmdDrift(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *cobra.Command {
+	return NewCmdDriftWithOptions(NewDriftOptions(configFlags, streams))
+}
+
+// NewCmdDriftWithOptions creates the drift command running with the given options.
+//
+// Example usage:
+// o := NewDriftOptions(genericclioptions.NewConfigFlags(true), streams).WithClientset(clientset)
+// cmd := NewCmdDriftWithOptions(o)
+func NewCmdDriftWithOptions(o *DriftOptions) *cobra.Command {
+	// driftCmd represents the drift command
+	driftCmd := &cobra.Command{
+		Use:   "drift [secret name] [flags]",
+		Short: "Detect secrets that differ from their .env files.",
+		Long: `The drift command compares the content expected from .env files with the secrets living in the cluster and reports any difference.
+
+  Secrets are read from a .envsecret.yaml manifest, or a single secret can be checked by passing its name and --from-env-file. Use --mode hash to rely on the content hash annotation written by kubectl-envsecret instead of comparing every value. The command exits with a non-zero status when drift is found, so it can be used from CI and scheduled jobs.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			// From here on errors report drift, not a wrong invocation.
+			cmd.SilenceUsage = true
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			if err := o.Run(ctx); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	driftCmd.Flags().StringVarP(&o.manifestPath, "filename", "f", o.manifestPath, fmt.Sprintf("Path to the manifest declaring the secrets to check. Defaults to %s when no secret name is given.", manifest.DefaultFile))
+	driftCmd.MarkFlagFilename("filename", "yaml", "yml")
+	driftCmd.Flags().StringSliceVar(&o.envFilePaths, "from-env-file", o.envFilePaths, "Specify the path to a file to read key=val pairs of the secret given by name.")
+	driftCmd.MarkFlagFilename("from-env-file")
+	driftCmd.Flags().BoolVar(&o.template, "template", o.template, "Render the env files of the secret given by name as Go templates, as create --template does.")
+	driftCmd.Flags().StringSliceVar(&o.valuesFiles, "values", o.valuesFiles, "Specify the path to a YAML file with values available to templates as .Values. Requires --template.")
+	driftCmd.MarkFlagFilename("values", "yaml", "yml", "json")
+	driftCmd.Flags().StringVar(&o.overlay, "overlay", o.overlay, "Also load the overlays of the env files of the secret given by name, as create --overlay does.")
+	driftCmd.Flags().StringVar(&o.schemaPath, "schema", o.schemaPath, fmt.Sprintf("Path to the schema file, usually %s, the secret given by name was validated against. Its default values are expected in the secret.", schema.DefaultFile))
+	driftCmd.MarkFlagFilename("schema", "schema", "yaml", "yml", "json")
+	driftCmd.Flags().StringVar(&o.mode, "mode", o.mode, "How to compare secrets. One of: full, hash.")
+	driftCmd.Flags().StringVarP(&o.output, "output", "o", o.output, "Output format of the report. One of: text, json, junit.")
+
+	return driftCmd
+}
+
+// Complete completes all necessary settings.
+func (o *DriftOptions) Complete(cmd *cobra.Command, args []string) error {
+	var err error
+
+	// An injected clientset does not need the kubeconfig.
+	if o.clientset == nil {
+		o.restConfig, err = o.configFlags.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+	}
+
+	ns, err := cmd.Flags().GetString("namespace")
+	if err != nil {
+		return err
+	}
+
+	if len(ns) == 0 {
+		o.namespace = "default"
+	} else {
+		o.namespace = ns
+	}
+
+	if len(args) == 1 {
+		if len(o.manifestPath) > 0 {
+			return fmt.Errorf("a secret name and --filename cannot be used together")
+		}
+		o.targets = []manifest.Target{{
+			Name:        args[0],
+			Namespace:   o.namespace,
+			Overlay:     o.overlay,
+			Schema:      o.schemaPath,
+			EnvFiles:    utils.RemoveDuplicatedStringE(o.envFilePaths),
+			ValuesFiles: o.valuesFiles,
+			Template:    o.template,
+		}}
+		return nil
+	}
+
+	for _, name := range []string{"from-env-file", "template", "values", "overlay", "schema"} {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--%s requires a secret name, set it in the manifest instead", name)
+		}
+	}
+	if len(o.manifestPath) == 0 {
+		o.manifestPath = manifest.DefaultFile
+	}
+
+	m, err := manifest.Load(o.manifestPath, o.namespace)
+	if err != nil {
+		return err
+	}
+	o.targets = m.Secrets
+
+	return nil
+}
+
+// Validate validates all set flags and args
+func (o *DriftOptions) Validate() error {
+	if _, err := drift.ParseMode(o.mode); err != nil {
+		return err
+	}
+	switch o.output {
+	case "text", "json", "junit":
+	default:
+		return fmt.Errorf("unknown output format %q, must be one of: text, json, junit", o.output)
+	}
+	return nil
+}
+
+// Run checks every target and prints the report
+func (o *DriftOptions) Run(ctx context.Context) error {
+	mode, _ := drift.ParseMode(o.mode)
+
+	report := &drift.Report{}
+	for _, target := range o.targets {
+		report.Results = append(report.Results, o.check(ctx, target, mode))
+	}
+
+	if err := report.Write(o.Out, o.output); err != nil {
+		return err
+	}
+
+	if failed := report.Failed(); failed > 0 {
+		return fmt.Errorf("drift detected in %d of %d secret(s)", failed, len(report.Results))
+	}
+
+	return nil
+}
+
+// check compares a single target with the live secret.
+func (o *DriftOptions) check(ctx context.Context, target manifest.Target, mode drift.Mode) drift.Result {
+	failed := func(err error) drift.Result {
+		return drift.Result{Name: target.Name, Namespace: target.Namespace, Status: drift.StatusError, Reason: err.Error()}
+	}
+
+	// Values are loaded as create loads them, filling generate directives so
+	// that the values the secret already has for them can be expected.
+	values, err := envsecret.Load(ctx, envsecret.Options{
+		Overlay:         target.Overlay,
+		Schema:          target.Schema,
+		Files:           target.EnvFiles,
+		ValuesFiles:     target.ValuesFiles,
+		Template:        target.Template,
+		GenerateMissing: true,
+	})
+	if err != nil {
+		return failed(flagError(err))
+	}
+
+	var client *k8sapi.K8sClient
+	if o.clientset != nil {
+		client = k8sapi.NewK8sClient(o.clientset, target.Namespace)
+	} else {
+		client, err = k8sapi.NewK8sClientFromConfig(k8sapi.NewK8sConfig(o.restConfig, target.Namespace))
+		if err != nil {
+			return failed(err)
+		}
+	}
+
+	var live *v1.Secret
+	live, err = client.GetSecret(ctx, target.Name)
+	if kerr.IsNotFound(err) {
+		live, err = nil, nil
+	}
+	if err != nil {
+		return failed(err)
+	}
+
+	result := drift.Check(envsecret.Expected(values, live), live, mode)
+	result.Name = target.Name
+	result.Namespace = target.Namespace
+	return result
+}
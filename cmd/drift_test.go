@@ -0,0 +1,46 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/cmd"
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func runDrift(t *testing.T, clientset *fake.Clientset, args ...string) (string, string, error) {
+	return runWith(t, func(streams genericiooptions.IOStreams) *cobra.Command {
+		o := cmd.NewDriftOptions(genericclioptions.NewConfigFlags(true), streams).WithClientset(clientset)
+		return cmd.NewCmdDriftWithOptions(o)
+	}, append([]string{"drift"}, args...)...)
+}
+
+func TestCmdDriftGeneratedAndRotatingKeys(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(envFile, []byte("API_TOKEN=new\nSESSION_KEY=!generate:32\n"), 0600))
+
+	secret := existingSecret(map[string]string{"API_TOKEN": "new", "API_TOKEN_PREVIOUS": "old", "SESSION_KEY": "generated"})
+	secret.Annotations = map[string]string{k8sapi.AnnotationRotatingKeys: "API_TOKEN"}
+
+	t.Run("in sync", func(t *testing.T) {
+		stdout, _, err := runDrift(t, fake.NewSimpleClientset(secret.DeepCopy()), "api", "--from-env-file", envFile)
+		require.NoError(t, err)
+		assert.Contains(t, stdout, "InSync")
+	})
+	t.Run("generated key missing", func(t *testing.T) {
+		missing := secret.DeepCopy()
+		delete(missing.Data, "SESSION_KEY")
+
+		stdout, _, err := runDrift(t, fake.NewSimpleClientset(missing), "api", "--from-env-file", envFile, "-o", "json")
+		assert.ErrorContains(t, err, "drift detected in 1 of 1 secret(s)")
+		assert.Contains(t, stdout, `"removed": [`)
+		assert.Contains(t, stdout, `"SESSION_KEY"`)
+	})
+}
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/diff"
+	"github.com/ogticrd/kubectl-envsecret/internal/editor"
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/ogticrd/kubectl-envsecret/internal/output"
+	"github.com/spf13/cobra"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+)
+
+// EditOptions contains the options for the edit command.
+type EditOptions struct {
+	genericclioptions.IOStreams
+	configFlags  *genericclioptions.ConfigFlags
+	restConfig   *rest.Config
+	namespace    string
+	secretName   string
+	output       string
+	historyLimit int
+	retries      int
+}
+
+// NewEditOptions initializes EditOptions with the provided IO streams.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// options := NewEditOptions(genericclioptions.NewConfigFlags(true), streams)
+func NewEditOptions(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *EditOptions {
+	return &EditOptions{
+		configFlags:  configFlags,
+		IOStreams:    streams,
+		historyLimit: k8sapi.DefaultHistoryLimit,
+	}
+}
+
+// NewCmdEdit creates a new cobra command for editing a secret as a .env file.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// cmd := NewCmdEdit(genericclioptions.NewConfigFlags(true), streams)
+// cmd.Execute()
+func NewCmdEdit(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewEditOptions(configFlags, streams)
+
+	// editCmd represents the edit command
+	editCmd := &cobra.Command{
+		Use:   "edit [secret name] [flags]",
+		Short: "Edit a secret as a .env file.",
+		Long: `The edit command opens the data of a secret in your editor as a .env file, with plain values instead of base64 and multiline values keeping their line breaks.
+
+  The editor is taken from $KUBE_EDITOR or $EDITOR, as with kubectl edit. When the file is saved it is parsed again, and reopened with the errors at the top if it cannot be parsed. The changed keys are shown without their values and the secret is only updated after confirmation. The temporary file is only readable by you and is overwritten before it is removed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			if err := o.Run(ctx); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	editCmd.Flags().StringVarP(&o.output, "output", "o", o.output, "Output format. One of: json, yaml. Prints human readable text when empty.")
+	editCmd.Flags().IntVar(&o.historyLimit, "history-limit", o.historyLimit, "Number of previous versions of the secret to keep. Use 0 to keep all of them.")
+
+	return editCmd
+}
+
+// Complete completes all necessary settings.
+func (o *EditOptions) Complete(cmd *cobra.Command, args []string) error {
+	o.secretName = args[0]
+
+	var err error
+
+	o.restConfig, err = o.configFlags.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+
+	ns, err := cmd.Flags().GetString("namespace")
+	if err != nil {
+		return err
+	}
+
+	if len(ns) == 0 {
+		o.namespace = "default"
+	} else {
+		o.namespace = ns
+	}
+
+	o.retries, err = cmd.Flags().GetInt("retries")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate validates all set flags and args
+func (o *EditOptions) Validate() error {
+	if err := output.ValidateFormat(o.output); err != nil {
+		return err
+	}
+	if o.historyLimit < 0 {
+		return fmt.Errorf("--history-limit must be greater than or equal to 0")
+	}
+	return nil
+}
+
+// Run opens the secret in the editor and applies the confirmed changes
+func (o *EditOptions) Run(ctx context.Context) error {
+	client, err := k8sapi.NewK8sClientFromConfig(k8sapi.NewK8sConfig(o.restConfig, o.namespace))
+	if err != nil {
+		return err
+	}
+
+	client.WithRetryAttempts(o.retries)
+
+	// Recording a revision lists and reads previous revisions.
+	verbs := []string{"get", "list", "create", "update"}
+	if o.historyLimit > 0 {
+		verbs = append(verbs, "delete")
+	}
+	if err := client.Preflight(ctx, verbs...); err != nil {
+		return err
+	}
+
+	secret, err := client.GetSecret(ctx, o.secretName)
+	if err != nil {
+		return err
+	}
+
+	edited, err := editor.NewDefaultEditor(o.IOStreams).Edit(secret.Data)
+	if errors.Is(err, editor.ErrEmpty) {
+		fmt.Fprintln(o.ErrOut, "Edit cancelled, saved file was empty.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	changes := diff.Compare(secret.Data, edited)
+	if changes.Empty() {
+		fmt.Fprintln(o.ErrOut, "Edit cancelled, no changes made.")
+		return nil
+	}
+
+	fmt.Fprintf(o.ErrOut, "Changes to secret %s/%s:\n", o.namespace, o.secretName)
+	for _, key := range changes.Added {
+		fmt.Fprintf(o.ErrOut, "  + %s\n", key)
+	}
+	for _, key := range changes.Changed {
+		fmt.Fprintf(o.ErrOut, "  ~ %s\n", key)
+	}
+	for _, key := range changes.Removed {
+		fmt.Fprintf(o.ErrOut, "  - %s\n", key)
+	}
+	if !o.confirm("Apply these changes?") {
+		fmt.Fprintln(o.ErrOut, "Edit cancelled, changes were not applied.")
+		return nil
+	}
+
+	// Refuse to silently overwrite changes made while the editor was open.
+	updated, err := client.ReplaceSecretData(ctx, secret, edited)
+	if kerr.IsConflict(err) {
+		return fmt.Errorf("secret %s was modified while it was being edited, run the command again to edit the latest version", o.secretName)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := client.RecordRevision(ctx, updated, o.historyLimit); err != nil {
+		return err
+	}
+
+	result := output.NewResult(updated, output.ActionUpdated)
+	result.Changes = changes.String()
+	return output.Print(o.Out, o.output, result)
+}
+
+// confirm asks a yes or no question and reports whether the answer is yes.
+func (o *EditOptions) confirm(question string) bool {
+	fmt.Fprintf(o.ErrOut, "%s [y/N]: ", question)
+	answer, _ := bufio.NewReader(o.In).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
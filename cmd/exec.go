@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/ogticrd/kubectl-envsecret/internal/runner"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+)
+
+// ExitError reports the exit code of a command run by the plugin, so the
+// plugin can exit with the same code.
+type ExitError struct {
+	Code int // Exit code of the command.
+}
+
+// Error returns the exit code as an error message.
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExecOptions contains the options for the exec command.
+type ExecOptions struct {
+	genericclioptions.IOStreams
+	configFlags   *genericclioptions.ConfigFlags
+	restConfig    *rest.Config
+	namespace     string
+	secretName    string
+	prefix        string
+	command       []string
+	overrideLocal bool
+}
+
+// NewExecOptions initializes ExecOptions with the provided IO streams.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// options := NewExecOptions(genericclioptions.NewConfigFlags(true), streams)
+func NewExecOptions(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *ExecOptions {
+	return &ExecOptions{
+		configFlags: configFlags,
+		IOStreams:   streams,
+	}
+}
+
+// NewCmdExec creates a new cobra command for running a command with the values of a secret in its environment.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// cmd := NewCmdExec(genericclioptions.NewConfigFlags(true), streams)
+// cmd.Execute()
+func NewCmdExec(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewExecOptions(configFlags, streams)
+
+	// execCmd represents the exec command
+	execCmd := &cobra.Command{
+		Use:   "exec [secret name] [flags] -- COMMAND [args...]",
+		Short: "Run a command with the values of a secret in its environment.",
+		Long: `The exec command reads a secret from the cluster and runs a command with its keys as environment variables, which helps debugging a service locally with the same configuration it gets in the cluster.
+
+  Variables already set in the local environment win over the secret, unless --override-local is given. Use --prefix to add a prefix to every key. Values are only handed to the command through its environment and never written to disk. Interrupt and termination signals are forwarded to the command, and the plugin exits with its exit code.`,
+		Example: `  # Run a service with the keys of the api secret
+  kubectl envsecret exec api -n production -- ./myservice --port 8080`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			err := o.Run(ctx)
+			if _, ok := err.(*ExitError); ok {
+				// The command already reported its own errors.
+				cmd.SilenceErrors = true
+			}
+			return err
+		},
+	}
+
+	execCmd.Flags().StringVar(&o.prefix, "prefix", o.prefix, "Prefix added to the name of every key of the secret.")
+	execCmd.Flags().BoolVar(&o.overrideLocal, "override-local", o.overrideLocal, "Let the values of the secret win over variables already set in the local environment.")
+
+	return execCmd
+}
+
+// Complete completes all necessary settings.
+func (o *ExecOptions) Complete(cmd *cobra.Command, args []string) error {
+	dash := cmd.ArgsLenAtDash()
+	if dash != 1 {
+		return fmt.Errorf("expected exactly one secret name followed by -- and the command to run")
+	}
+	o.secretName = args[0]
+	o.command = args[1:]
+
+	var err error
+
+	o.restConfig, err = o.configFlags.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+
+	ns, err := cmd.Flags().GetString("namespace")
+	if err != nil {
+		return err
+	}
+
+	if len(ns) == 0 {
+		o.namespace = "default"
+	} else {
+		o.namespace = ns
+	}
+
+	return nil
+}
+
+// Validate validates all set flags and args
+func (o *ExecOptions) Validate() error {
+	if len(o.command[0]) == 0 {
+		return fmt.Errorf("the command to run cannot be empty")
+	}
+	return nil
+}
+
+// Run reads the secret and runs the command with its values
+func (o *ExecOptions) Run(ctx context.Context) error {
+	client, err := k8sapi.NewK8sClientFromConfig(k8sapi.NewK8sConfig(o.restConfig, o.namespace))
+	if err != nil {
+		return err
+	}
+
+	if err := client.Preflight(ctx, "get"); err != nil {
+		return err
+	}
+
+	secret, err := client.GetSecret(ctx, o.secretName)
+	if err != nil {
+		return err
+	}
+
+	env, skipped := runner.Environ(os.Environ(), secret.Data, o.prefix, o.overrideLocal)
+	if len(skipped) > 0 {
+		fmt.Fprintf(o.ErrOut, "Warning: skipping keys that are not valid environment variable names: %s\n", strings.Join(skipped, ", "))
+	}
+
+	code, err := runner.Run(o.command[0], o.command[1:], env, o.In, o.Out, o.ErrOut)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return &ExitError{Code: code}
+	}
+
+	return nil
+}
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/ogticrd/kubectl-envsecret/internal/output"
+	"github.com/ogticrd/kubectl-envsecret/internal/vault"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// ExportOptions contains the options for the export command.
+type ExportOptions struct {
+	genericclioptions.IOStreams
+	configFlags *genericclioptions.ConfigFlags
+	restConfig  *rest.Config
+	clientset   kubernetes.Interface
+	vaultClient *vault.Client
+	namespace   string
+	secretName  string
+	vaultPath   string
+	output      string
+}
+
+// NewExportOptions initializes ExportOptions with the provided IO streams.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// options := NewExportOptions(genericclioptions.NewConfigFlags(true), streams)
+func NewExportOptions(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *ExportOptions {
+	return &ExportOptions{
+		configFlags: configFlags,
+		IOStreams:   streams,
+	}
+}
+
+// WithClientset sets the client used to talk to the cluster instead of one
+// created from the kubeconfig, e.g. a fake clientset in tests.
+//
+// Example usage:
+// options := NewExportOptions(configFlags, streams).WithClientset(fake.NewSimpleClientset())
+func (o *ExportOptions) WithClientset(clientset kubernetes.Interface) *ExportOptions {
+	o.clientset = clientset
+	return o
+}
+
+// NewCmdExport creates a new cobra command for exporting a secret to HashiCorp Vault.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// cmd := NewCmdExport(genericclioptions.NewConfigFlags(true), streams)
+// cmd.Execute()
+func NewCmdExport(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *cobra.Command {
+	return NewCmdExportWithOptions(NewExportOptions(configFlags, streams))
+}
+
+// NewCmdExportWithOptions creates the export command running with the given options.
+//
+// Example usage:
+// o := NewExportOptions(genericclioptions.NewConfigFlags(true), streams).WithClientset(clientset)
+// cmd := NewCmdExportWithOptions(o)
+func NewCmdExportWithOptions(o *ExportOptions) *cobra.Command {
+	// exportCmd represents the export command
+	exportCmd := &cobra.Command{
+		Use:   "export [secret name] --to-vault MOUNT/PATH [flags]",
+		Short: "Export a secret to HashiCorp Vault.",
+		Long: `The export command writes the data of a secret as a new version of a HashiCorp Vault KV v2 secret, so values can flow from Kubernetes to Vault without copying them by hand.
+
+  The server and token are taken from VAULT_ADDR and VAULT_TOKEN (or ~/.vault-token), and the namespace from VAULT_NAMESPACE, as with the vault CLI. The version is written with check-and-set, so it fails instead of overwriting a version written at the same time by someone else. Nothing is written when Vault already holds the same values.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			if err := o.Run(ctx); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	exportCmd.Flags().StringVar(&o.vaultPath, "to-vault", o.vaultPath, "Path of the HashiCorp Vault KV v2 secret to write, starting with its mount, e.g. secret/api.")
+	exportCmd.MarkFlagRequired("to-vault")
+	exportCmd.Flags().StringVarP(&o.output, "output", "o", o.output, "Output format. One of: json, yaml. Prints human readable text when empty.")
+
+	return exportCmd
+}
+
+// Complete completes all necessary settings.
+func (o *ExportOptions) Complete(cmd *cobra.Command, args []string) error {
+	o.secretName = args[0]
+
+	var err error
+
+	// An injected clientset does not need the kubeconfig.
+	if o.clientset == nil {
+		o.restConfig, err = o.configFlags.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+	}
+
+	ns, err := cmd.Flags().GetString("namespace")
+	if err != nil {
+		return err
+	}
+
+	if len(ns) == 0 {
+		o.namespace = "default"
+	} else {
+		o.namespace = ns
+	}
+
+	o.vaultClient, err = vault.NewClientFromEnv()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate validates all set flags and args
+func (o *ExportOptions) Validate() error {
+	if err := output.ValidateFormat(o.output); err != nil {
+		return err
+	}
+	_, _, err := vault.SplitPath(o.vaultPath)
+	return err
+}
+
+// Run writes the data of the secret to Vault
+func (o *ExportOptions) Run(ctx context.Context) error {
+	var client *k8sapi.K8sClient
+	if o.clientset != nil {
+		client = k8sapi.NewK8sClient(o.clientset, o.namespace)
+	} else {
+		var err error
+		client, err = k8sapi.NewK8sClientFromConfig(k8sapi.NewK8sConfig(o.restConfig, o.namespace))
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := client.Preflight(ctx, "get"); err != nil {
+		return err
+	}
+
+	secret, err := client.GetSecret(ctx, o.secretName)
+	if err != nil {
+		return err
+	}
+
+	data := make(map[string]string, len(secret.Data))
+	var binary []string
+	for key, value := range secret.Data {
+		if !utf8.Valid(value) {
+			binary = append(binary, key)
+			continue
+		}
+		data[key] = string(value)
+	}
+	if len(binary) > 0 {
+		sort.Strings(binary)
+		return fmt.Errorf("cannot export keys %s, their values are not text", strings.Join(binary, ", "))
+	}
+
+	// Check-and-set against the current version, 0 meaning that the secret
+	// must not exist yet. It is taken from the metadata, as reading the data
+	// fails when the latest version was deleted.
+	cas := 0
+	metadata, err := o.vaultClient.ReadMetadata(ctx, o.vaultPath)
+	switch {
+	case vault.IsNotFound(err):
+	case err != nil:
+		return fmt.Errorf("error reading vault secret %s: %w", o.vaultPath, err)
+	default:
+		cas = metadata.CurrentVersion
+	}
+
+	result := output.NewResult(secret, output.ActionExported)
+	result.Target = "vault " + o.vaultPath
+
+	if cas > 0 {
+		current, err := o.vaultClient.Read(ctx, o.vaultPath, 0)
+		switch {
+		case vault.IsNotFound(err):
+			// The latest version was deleted or destroyed.
+		case err != nil:
+			return fmt.Errorf("error reading vault secret %s: %w", o.vaultPath, err)
+		case current.Version == cas && maps.Equal(current.Data, data):
+			result.Action = output.ActionUnchanged
+			result.Version = current.Version
+			return output.Print(o.Out, o.output, result)
+		}
+	}
+
+	result.Version, err = o.vaultClient.Write(ctx, o.vaultPath, data, &cas)
+	if err != nil {
+		return fmt.Errorf("error writing vault secret %s: %w", o.vaultPath, err)
+	}
+
+	return output.Print(o.Out, o.output, result)
+}
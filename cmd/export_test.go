@@ -0,0 +1,65 @@
+package cmd_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/cmd"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func runExport(t *testing.T, clientset *fake.Clientset, args ...string) (string, string, error) {
+	return runWith(t, func(streams genericiooptions.IOStreams) *cobra.Command {
+		o := cmd.NewExportOptions(genericclioptions.NewConfigFlags(true), streams).WithClientset(clientset)
+		return cmd.NewCmdExportWithOptions(o)
+	}, append([]string{"export"}, args...)...)
+}
+
+// TestCmdExportDeletedVersion checks that a secret whose latest version was
+// deleted in Vault is written with check-and-set against that version.
+func TestCmdExportDeletedVersion(t *testing.T) {
+	var written struct {
+		Data    map[string]string `json:"data"`
+		Options map[string]int    `json:"options"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/secret/metadata/api":
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]int{"current_version": 2}})
+		case r.URL.Path == "/v1/secret/data/api" && r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string][]string{"errors": {}})
+		case r.URL.Path == "/v1/secret/data/api" && r.Method == http.MethodPost:
+			json.NewDecoder(r.Body).Decode(&written)
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]int{"version": 3}})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "token")
+	t.Setenv("VAULT_NAMESPACE", "")
+
+	clientset := fake.NewSimpleClientset(existingSecret(map[string]string{"API_URL": "https://api.example.com"}))
+	denyVerbs(clientset)
+
+	stdout, _, err := runExport(t, clientset, "api", "--to-vault", "secret/api", "-o", "json")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]int{"cas": 2}, written.Options)
+	assert.Equal(t, map[string]string{"API_URL": "https://api.example.com"}, written.Data)
+
+	var result map[string]any
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, "exported", result["action"])
+	assert.Equal(t, "vault secret/api", result["target"])
+	assert.EqualValues(t, 3, result["version"])
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/generate"
+	"github.com/ogticrd/kubectl-envsecret/internal/parser"
+	"github.com/ogticrd/kubectl-envsecret/pkg/envsecret"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+)
+
+// GenerateOptions contains the options for the generate command.
+type GenerateOptions struct {
+	genericiooptions.IOStreams // Input/output streams for the CLI.
+	namespace                  string
+	outputDir                  string
+	opts                       envsecret.Options
+	kustomize                  bool
+	helm                       bool
+	overwrite                  bool
+}
+
+// NewGenerateOptions initializes GenerateOptions with the provided IO streams.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// options := NewGenerateOptions(streams)
+func NewGenerateOptions(streams genericiooptions.IOStreams) *GenerateOptions {
+	return &GenerateOptions{
+		IOStreams: streams,
+		opts: envsecret.Options{
+			Files:    []string{".env"},
+			Warnings: streams.ErrOut,
+		},
+	}
+}
+
+// NewCmdGenerate creates a new cobra command for generating Kustomize and Helm manifests from .env files.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// cmd := NewCmdGenerate(streams)
+// cmd.Execute()
+func NewCmdGenerate(streams genericiooptions.IOStreams) *cobra.Command {
+	o := NewGenerateOptions(streams)
+
+	// generateCmd represents the generate command
+	generateCmd := &cobra.Command{
+		Use:   "generate [secret name] (--kustomize | --helm) [flags]",
+		Short: "Generate Kustomize or Helm manifests from .env files.",
+		Long: `The generate command turns .env files into manifests for other delivery tools instead of writing the secret to the cluster.
+
+  With --kustomize it generates a kustomization.yaml with a secretGenerator entry, an env file with the single line values and one file per multiline value. With --helm it generates a values.yaml snippet with the secret values and a templates/secret.yaml template rendering the secret from them. Files are printed to the standard output, each one preceded by a "# Source:" comment, or written to --output-dir.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			if err := o.Run(ctx); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	generateCmd.Flags().StringSliceVar(&o.opts.Files, "from-env-file", o.opts.Files, fmt.Sprintf("Specify the path to a file to read key=val pairs of the secret. Also accepts the URIs %s://PATH, %s://PREFIX, %s://PATH, %s://PATH and %s://PATH.", parser.SchemeFile, parser.SchemeEnv, parser.SchemeSops, parser.SchemeOnePassword, parser.SchemeBitwarden))
+	generateCmd.MarkFlagFilename("from-env-file")
+	generateCmd.Flags().BoolVar(&o.opts.Template, "template", o.opts.Template, "Render the env files as Go templates before parsing them. Templates can use .Env, .Values and the file, b64enc, b64dec and quote functions.")
+	generateCmd.Flags().StringSliceVar(&o.opts.ValuesFiles, "values", o.opts.ValuesFiles, "Specify the path to a YAML file with values available to templates as .Values. Requires --template.")
+	generateCmd.MarkFlagFilename("values", "yaml", "yml", "json")
+	generateCmd.Flags().StringVar(&o.opts.Overlay, "overlay", o.opts.Overlay, "Also load the .local, .OVERLAY and .OVERLAY.local variants of every env file that exist, e.g. .env.production, following the dotenv-flow convention.")
+	generateCmd.Flags().BoolVar(&o.kustomize, "kustomize", o.kustomize, "Generate a kustomization.yaml with a secretGenerator entry.")
+	generateCmd.Flags().BoolVar(&o.helm, "helm", o.helm, "Generate a values.yaml snippet and a templates/secret.yaml template.")
+	generateCmd.Flags().StringVar(&o.outputDir, "output-dir", o.outputDir, "Directory to write the generated files to. Prints them when empty.")
+	generateCmd.MarkFlagDirname("output-dir")
+	generateCmd.Flags().BoolVar(&o.overwrite, "overwrite", o.overwrite, "Replace files that already exist in --output-dir.")
+	generateCmd.MarkFlagsMutuallyExclusive("kustomize", "helm")
+	generateCmd.MarkFlagsOneRequired("kustomize", "helm")
+
+	return generateCmd
+}
+
+// Complete completes all necessary settings.
+func (o *GenerateOptions) Complete(cmd *cobra.Command, args []string) error {
+	o.opts.Name = args[0]
+
+	// The namespace is only written to the kustomization when given
+	// explicitly, so the generated files can be reused across namespaces.
+	if flag := cmd.Flags().Lookup("namespace"); flag != nil {
+		o.namespace = flag.Value.String()
+	}
+
+	return nil
+}
+
+// Validate validates all set flags and args
+func (o *GenerateOptions) Validate() error {
+	if o.overwrite && len(o.outputDir) == 0 {
+		return fmt.Errorf("--overwrite requires --output-dir")
+	}
+	if err := o.opts.Validate(); err != nil {
+		return flagError(err)
+	}
+	return nil
+}
+
+// Run generates the files and prints or writes them
+func (o *GenerateOptions) Run(ctx context.Context) error {
+	values, err := envsecret.Load(ctx, o.opts)
+	// Generated values would be written to the manifests and change on
+	// every run, so directives are left to create --generate-missing.
+	var directivesErr *envsecret.DirectivesError
+	if errors.As(err, &directivesErr) {
+		return fmt.Errorf("keys %s use %s directives, which cannot be written to manifests; set their values or create the secret with create --generate-missing", strings.Join(directivesErr.Keys, ", "), parser.DirectivePrefix)
+	}
+	if err != nil {
+		return flagError(err)
+	}
+
+	var files []generate.File
+	if o.kustomize {
+		files, err = generate.Kustomize(o.opts.Name, o.namespace, values.Data)
+	} else {
+		files, err = generate.Helm(o.opts.Name, values.Data)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(o.outputDir) == 0 {
+		return generate.Print(o.Out, files)
+	}
+
+	if err := generate.Write(o.outputDir, files, o.overwrite); err != nil {
+		return err
+	}
+	for _, file := range files {
+		fmt.Fprintf(o.Out, "wrote %s\n", file.Path)
+	}
+	return nil
+}
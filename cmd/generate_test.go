@@ -0,0 +1,36 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/cmd"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+)
+
+func runGenerate(t *testing.T, args ...string) (string, string, error) {
+	return runWith(t, func(streams genericiooptions.IOStreams) *cobra.Command {
+		return cmd.NewCmdGenerate(streams)
+	}, append([]string{"generate"}, args...)...)
+}
+
+func TestCmdGenerateDirectives(t *testing.T) {
+	_, _, err := runGenerate(t, "api", "--kustomize", "--from-env-file", filepath.Join("testdata", "create", "directives.env"))
+	assert.EqualError(t, err, "keys SESSION_KEY use !generate: directives, which cannot be written to manifests; set their values or create the secret with create --generate-missing")
+}
+
+func TestCmdGenerateTemplate(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+	valuesFile := filepath.Join(dir, "values.yaml")
+	require.NoError(t, os.WriteFile(envFile, []byte("API_URL=https://{{ .Values.host }}\n"), 0600))
+	require.NoError(t, os.WriteFile(valuesFile, []byte("host: api.example.com\n"), 0600))
+
+	stdout, _, err := runGenerate(t, "api", "--helm", "--from-env-file", envFile, "--template", "--values", valuesFile)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "API_URL: https://api.example.com")
+}
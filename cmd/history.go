@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/rest"
+)
+
+// HistoryOptions contains the options for the history command.
+type HistoryOptions struct {
+	genericclioptions.IOStreams
+	configFlags *genericclioptions.ConfigFlags
+	restConfig  *rest.Config
+	namespace   string
+	secretName  string
+}
+
+// NewHistoryOptions initializes HistoryOptions with the provided IO streams.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// options := NewHistoryOptions(genericclioptions.NewConfigFlags(true), streams)
+func NewHistoryOptions(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *HistoryOptions {
+	return &HistoryOptions{
+		configFlags: configFlags,
+		IOStreams:   streams,
+	}
+}
+
+// NewCmdHistory creates a new cobra command for listing the revisions of a secret.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// cmd := NewCmdHistory(genericclioptions.NewConfigFlags(true), streams)
+// cmd.Execute()
+func NewCmdHistory(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewHistoryOptions(configFlags, streams)
+
+	// historyCmd represents the history command
+	historyCmd := &cobra.Command{
+		Use:   "history [secret name] [flags]",
+		Short: "List the recorded revisions of a secret.",
+		Long: `The history command lists the revisions recorded every time kubectl-envsecret writes a secret.
+
+  Each revision shows when it was recorded and which keys it added (+), changed (~) or removed (-). Values are never printed. Use the rollback command to restore one of them.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(cmd, args); err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			if err := o.Run(ctx); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	return historyCmd
+}
+
+// Complete completes all necessary settings.
+func (o *HistoryOptions) Complete(cmd *cobra.Command, args []string) error {
+	o.secretName = args[0]
+
+	var err error
+
+	o.restConfig, err = o.configFlags.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+
+	ns, err := cmd.Flags().GetString("namespace")
+	if err != nil {
+		return err
+	}
+
+	if len(ns) == 0 {
+		o.namespace = "default"
+	} else {
+		o.namespace = ns
+	}
+
+	return nil
+}
+
+// Run prints the revisions of the secret
+func (o *HistoryOptions) Run(ctx context.Context) error {
+	client, err := k8sapi.NewK8sClientFromConfig(k8sapi.NewK8sConfig(o.restConfig, o.namespace))
+	if err != nil {
+		return err
+	}
+
+	revisions, err := client.ListRevisions(ctx, o.secretName)
+	if err != nil {
+		return err
+	}
+	if len(revisions) == 0 {
+		return fmt.Errorf("no revisions found for secret %s in namespace %s", o.secretName, o.namespace)
+	}
+
+	w := printers.GetNewTabWriter(o.Out)
+	fmt.Fprintln(w, "REVISION\tRECORDED\tCHANGES")
+	for _, revision := range revisions {
+		recordedAt := "<unknown>"
+		if !revision.RecordedAt.IsZero() {
+			recordedAt = revision.RecordedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", revision.Number, recordedAt, revision.ChangedKeys)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/lint"
+	"github.com/ogticrd/kubectl-envsecret/internal/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+)
+
+// LintOptions contains the options for the lint command.
+type LintOptions struct {
+	genericiooptions.IOStreams // Input/output streams for the CLI.
+	output                     string
+	keyCase                    string
+	filePaths                  []string
+	fix                        bool
+}
+
+// NewLintOptions initializes LintOptions with the provided IO streams.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// options := NewLintOptions(streams)
+func NewLintOptions(streams genericiooptions.IOStreams) *LintOptions {
+	return &LintOptions{
+		IOStreams: streams,
+		output:    "text",
+		keyCase:   string(lint.KeyCaseUpper),
+	}
+}
+
+// NewCmdLint creates a new cobra command for checking .env files.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// cmd := NewCmdLint(streams)
+// cmd.Execute()
+func NewCmdLint(streams genericiooptions.IOStreams) *cobra.Command {
+	o := NewLintOptions(streams)
+
+	// lintCmd represents the lint command
+	lintCmd := &cobra.Command{
+		Use:   "lint FILE... [flags]",
+		Short: "Check .env files for common mistakes.",
+		Long: `The lint command checks .env files without connecting to the cluster.
+
+  It reports duplicate keys, keys that are not valid in a Kubernetes secret, keys not following the case convention, empty values, unquoted values containing '#', quotes that are never closed, trailing whitespace, mixed CRLF and LF line endings and byte order marks. Use --fix to remove trailing whitespace, byte order marks and mixed line endings in place, and -o sarif to upload the results to code scanning tools. The command exits with a non-zero status when errors are found.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			// From here on errors report lint issues, not a wrong invocation.
+			cmd.SilenceUsage = true
+			if err := o.Run(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	lintCmd.Flags().BoolVar(&o.fix, "fix", o.fix, "Fix trailing whitespace, byte order marks and mixed line endings in place.")
+	lintCmd.Flags().StringVar(&o.keyCase, "key-case", o.keyCase, "Case convention of keys. One of: upper, lower, any.")
+	lintCmd.Flags().StringVarP(&o.output, "output", "o", o.output, "Output format of the report. One of: text, json, sarif.")
+
+	return lintCmd
+}
+
+// Complete completes all necessary settings.
+func (o *LintOptions) Complete(cmd *cobra.Command, args []string) error {
+	o.filePaths = utils.RemoveDuplicatedStringE(args)
+	return nil
+}
+
+// Validate validates all set flags and args
+func (o *LintOptions) Validate() error {
+	if _, err := lint.ParseKeyCase(o.keyCase); err != nil {
+		return err
+	}
+	switch o.output {
+	case "text", "json", "sarif":
+	default:
+		return fmt.Errorf("unknown output format %q, must be one of: text, json, sarif", o.output)
+	}
+	return utils.ValidatePaths(o.filePaths)
+}
+
+// Run lints every file and prints the report
+func (o *LintOptions) Run() error {
+	keyCase, _ := lint.ParseKeyCase(o.keyCase)
+
+	report := &lint.Report{Issues: []lint.Issue{}}
+	for _, path := range o.filePaths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if o.fix {
+			if content, err = o.fixFile(path, content); err != nil {
+				return err
+			}
+		}
+
+		report.Issues = append(report.Issues, lint.Lint(path, content, lint.Options{KeyCase: keyCase})...)
+	}
+
+	if err := report.Write(o.Out, o.output); err != nil {
+		return err
+	}
+
+	if errors := report.Errors(); errors > 0 {
+		return fmt.Errorf("found %d error(s) in %d file(s)", errors, len(o.filePaths))
+	}
+
+	return nil
+}
+
+// fixFile applies the safe fixes to a file and returns its new content.
+func (o *LintOptions) fixFile(path string, content []byte) ([]byte, error) {
+	fixed := lint.Fix(content)
+	if bytes.Equal(fixed, content) {
+		return content, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, fixed, info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(o.ErrOut, "Fixed %s\n", path)
+
+	return fixed, nil
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/ogticrd/kubectl-envsecret/internal/output"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/rest"
+)
+
+// ListOptions contains the options for the list command.
+type ListOptions struct {
+	genericclioptions.IOStreams
+	configFlags   *genericclioptions.ConfigFlags
+	restConfig    *rest.Config
+	namespace     string
+	allNamespaces bool
+}
+
+// NewListOptions initializes ListOptions with the provided IO streams.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// options := NewListOptions(genericclioptions.NewConfigFlags(true), streams)
+func NewListOptions(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *ListOptions {
+	return &ListOptions{
+		configFlags: configFlags,
+		IOStreams:   streams,
+	}
+}
+
+// NewCmdList creates a new cobra command for listing the secrets managed by kubectl-envsecret.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// cmd := NewCmdList(genericclioptions.NewConfigFlags(true), streams)
+// cmd.Execute()
+func NewCmdList(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewListOptions(configFlags, streams)
+
+	// listCmd represents the list command
+	listCmd := &cobra.Command{
+		Use:   "list [flags]",
+		Short: "List the secrets managed by kubectl-envsecret.",
+		Long: fmt.Sprintf(`The list command shows the secrets written by kubectl-envsecret, found through the %s=%s label it sets on them.
+
+  For every secret it shows the number of keys, the size of the data, the content hash, when it was last written and the files it was written from. Values are never printed.`, k8sapi.LabelManagedBy, k8sapi.ManagedByValue),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(cmd, args); err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			if err := o.Run(ctx); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	listCmd.Flags().BoolVarP(&o.allNamespaces, "all-namespaces", "A", o.allNamespaces, "List the secrets across all namespaces.")
+
+	return listCmd
+}
+
+// Complete completes all necessary settings.
+func (o *ListOptions) Complete(cmd *cobra.Command, args []string) error {
+	var err error
+
+	o.restConfig, err = o.configFlags.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+
+	ns, err := cmd.Flags().GetString("namespace")
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case o.allNamespaces:
+		o.namespace = metav1.NamespaceAll
+	case len(ns) == 0:
+		o.namespace = "default"
+	default:
+		o.namespace = ns
+	}
+
+	return nil
+}
+
+// Run prints the managed secrets
+func (o *ListOptions) Run(ctx context.Context) error {
+	client, err := k8sapi.NewK8sClientFromConfig(k8sapi.NewK8sConfig(o.restConfig, o.namespace))
+	if err != nil {
+		return err
+	}
+
+	if err := client.Preflight(ctx, "list"); err != nil {
+		return err
+	}
+
+	secrets, err := client.ListManagedSecrets(ctx)
+	if err != nil {
+		return err
+	}
+	if len(secrets) == 0 {
+		if o.allNamespaces {
+			fmt.Fprintln(o.ErrOut, "No managed secrets found.")
+		} else {
+			fmt.Fprintf(o.ErrOut, "No managed secrets found in %s namespace.\n", o.namespace)
+		}
+		return nil
+	}
+
+	w := printers.GetNewTabWriter(o.Out)
+	if o.allNamespaces {
+		fmt.Fprint(w, "NAMESPACE\t")
+	}
+	fmt.Fprintln(w, "NAME\tKEYS\tSIZE\tHASH\tUPDATED\tSOURCES")
+	for i := range secrets {
+		secret := &secrets[i]
+		if o.allNamespaces {
+			fmt.Fprintf(w, "%s\t", secret.Namespace)
+		}
+		sources := strings.Join(k8sapi.SourceFiles(secret), ",")
+		if len(sources) == 0 {
+			sources = "<none>"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n", secret.Name, len(secret.Data), output.Size(dataSize(secret)), output.ShortHash(contentHash(secret)), updatedAge(secret), sources)
+	}
+
+	return w.Flush()
+}
+
+// dataSize returns the number of bytes of the values of a secret.
+func dataSize(secret *v1.Secret) int {
+	size := 0
+	for _, value := range secret.Data {
+		size += len(value)
+	}
+	return size
+}
+
+// contentHash returns the content hash recorded on a secret, or computes it
+// for secrets without the annotation.
+func contentHash(secret *v1.Secret) string {
+	if hash := secret.Annotations[k8sapi.AnnotationContentHash]; len(hash) > 0 {
+		return hash
+	}
+	return k8sapi.ContentHash(secret.Data)
+}
+
+// updatedAge returns how long ago kubectl-envsecret last wrote a secret, as
+// kubectl prints ages.
+func updatedAge(secret *v1.Secret) string {
+	updatedAt, err := time.Parse(time.RFC3339, secret.Annotations[k8sapi.AnnotationUpdatedAt])
+	if err != nil {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(time.Since(updatedAt))
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/ogticrd/kubectl-envsecret/internal/output"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+)
+
+// RollbackOptions contains the options for the rollback command.
+type RollbackOptions struct {
+	genericclioptions.IOStreams
+	configFlags  *genericclioptions.ConfigFlags
+	restConfig   *rest.Config
+	namespace    string
+	secretName   string
+	output       string
+	toRevision   int
+	historyLimit int
+	retries      int
+}
+
+// NewRollbackOptions initializes RollbackOptions with the provided IO streams.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// options := NewRollbackOptions(genericclioptions.NewConfigFlags(true), streams)
+func NewRollbackOptions(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *RollbackOptions {
+	return &RollbackOptions{
+		configFlags:  configFlags,
+		IOStreams:    streams,
+		historyLimit: k8sapi.DefaultHistoryLimit,
+	}
+}
+
+// NewCmdRollback creates a new cobra command for restoring a previous revision of a secret.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// cmd := NewCmdRollback(genericclioptions.NewConfigFlags(true), streams)
+// cmd.Execute()
+func NewCmdRollback(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewRollbackOptions(configFlags, streams)
+
+	// rollbackCmd represents the rollback command
+	rollbackCmd := &cobra.Command{
+		Use:   "rollback [secret name] --to-revision N [flags]",
+		Short: "Restore a secret to one of its recorded revisions.",
+		Long: `The rollback command replaces the data of a secret with the data of one of its recorded revisions.
+
+  The restored data is recorded as a new revision, so the rollback shows up in the history command and can be undone the same way.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			if err := o.Run(ctx); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	rollbackCmd.Flags().IntVar(&o.toRevision, "to-revision", o.toRevision, "The revision to restore.")
+	rollbackCmd.MarkFlagRequired("to-revision")
+	rollbackCmd.Flags().StringVarP(&o.output, "output", "o", o.output, "Output format. One of: json, yaml. Prints human readable text when empty.")
+	rollbackCmd.Flags().IntVar(&o.historyLimit, "history-limit", o.historyLimit, "Number of previous versions of the secret to keep. Use 0 to keep all of them.")
+
+	return rollbackCmd
+}
+
+// Complete completes all necessary settings.
+func (o *RollbackOptions) Complete(cmd *cobra.Command, args []string) error {
+	o.secretName = args[0]
+
+	var err error
+
+	o.restConfig, err = o.configFlags.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+
+	ns, err := cmd.Flags().GetString("namespace")
+	if err != nil {
+		return err
+	}
+
+	if len(ns) == 0 {
+		o.namespace = "default"
+	} else {
+		o.namespace = ns
+	}
+
+	o.retries, err = cmd.Flags().GetInt("retries")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate validates all set flags and args
+func (o *RollbackOptions) Validate() error {
+	if err := output.ValidateFormat(o.output); err != nil {
+		return err
+	}
+	if o.toRevision < 1 {
+		return fmt.Errorf("--to-revision must be greater than 0")
+	}
+	if o.historyLimit < 0 {
+		return fmt.Errorf("--history-limit must be greater than or equal to 0")
+	}
+	return nil
+}
+
+// Run restores the secret revision
+func (o *RollbackOptions) Run(ctx context.Context) error {
+	client, err := k8sapi.NewK8sClientFromConfig(k8sapi.NewK8sConfig(o.restConfig, o.namespace))
+	if err != nil {
+		return err
+	}
+
+	client.WithRetryAttempts(o.retries)
+
+	verbs := []string{"get", "list", "create", "update"}
+	if o.historyLimit > 0 {
+		verbs = append(verbs, "delete")
+	}
+	if err := client.Preflight(ctx, verbs...); err != nil {
+		return err
+	}
+
+	secret, err := client.Rollback(ctx, o.secretName, o.toRevision, o.historyLimit)
+	if err != nil {
+		return err
+	}
+
+	result := output.NewResult(secret, output.ActionRolledBack)
+	result.Revision = o.toRevision
+	return output.Print(o.Out, o.output, result)
+}
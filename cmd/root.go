@@ -6,6 +6,10 @@
 package cmd
 
 import (
+	"context"
+	"time"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/genericiooptions"
@@ -16,6 +20,9 @@ type RootCmdOptions struct {
 	configFlags *genericclioptions.ConfigFlags // Configuration flags from kubectl CLI.
 
 	genericiooptions.IOStreams // Input/output streams for the CLI.
+
+	timeout time.Duration // Maximum duration of a whole command.
+	retries int           // Number of attempts for write requests.
 }
 
 // NewRootCmdOptions creates a new NativeOptions instance with the provided IO streams.
@@ -28,6 +35,8 @@ func NewRootCmdOptions(streams genericiooptions.IOStreams) *RootCmdOptions {
 		configFlags: genericclioptions.NewConfigFlags(true),
 
 		IOStreams: streams,
+
+		retries: k8sapi.DefaultRetryAttempts,
 	}
 }
 
@@ -65,6 +74,8 @@ func NewCmdEnvSecret(streams genericiooptions.IOStreams) *cobra.Command {
 	}
 
 	o.configFlags.AddFlags(cmd.PersistentFlags())
+	cmd.PersistentFlags().DurationVar(&o.timeout, "timeout", o.timeout, "Maximum time to wait for the whole command to finish, including every API request it makes (e.g. 30s, 2m). Zero means no timeout.")
+	cmd.PersistentFlags().IntVar(&o.retries, "retries", o.retries, "Number of attempts for writes that fail with a conflict, throttling, a server error or a timeout. Use 1 to disable retries.")
 
 	// Set StdIn/StdOut/StdErr
 	cmd.SetIn(streams.In)
@@ -72,8 +83,39 @@ func NewCmdEnvSecret(streams genericiooptions.IOStreams) *cobra.Command {
 	cmd.SetErr(streams.ErrOut)
 
 	// create subcommands
-	cmd.AddCommand(NewCmdCreate(streams))
+	cmd.AddCommand(NewCmdCanI(o.configFlags, streams))
+	cmd.AddCommand(NewCmdCreate(o.configFlags, streams))
+	cmd.AddCommand(NewCmdCopy(o.configFlags, streams))
+	cmd.AddCommand(NewCmdDelete(o.configFlags, streams))
+	cmd.AddCommand(NewCmdDescribe(o.configFlags, streams))
+	cmd.AddCommand(NewCmdDrift(o.configFlags, streams))
+	cmd.AddCommand(NewCmdEdit(o.configFlags, streams))
+	cmd.AddCommand(NewCmdExec(o.configFlags, streams))
+	cmd.AddCommand(NewCmdExport(o.configFlags, streams))
+	cmd.AddCommand(NewCmdGenerate(streams))
+	cmd.AddCommand(NewCmdHistory(o.configFlags, streams))
+	cmd.AddCommand(NewCmdLint(streams))
+	cmd.AddCommand(NewCmdList(o.configFlags, streams))
+	cmd.AddCommand(NewCmdRollback(o.configFlags, streams))
+	cmd.AddCommand(NewCmdRotate(o.configFlags, streams))
 	cmd.AddCommand(NewCmdVersion(streams))
+	cmd.AddCommand(NewCmdWhoUses(o.configFlags, streams))
 
 	return cmd
 }
+
+// commandContext returns the context for running a command, bounded by the
+// --timeout flag. The context is also cancelled when the parent context is,
+// e.g. when the user presses Ctrl-C.
+func commandContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	if err == nil && timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
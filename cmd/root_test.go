@@ -2,11 +2,13 @@ package cmd_test
 
 import (
 	"bytes"
+	"context"
 	"testing"
 
 	"github.com/ogticrd/kubectl-envsecret/cmd"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"k8s.io/cli-runtime/pkg/genericiooptions"
 )
 
@@ -39,6 +41,7 @@ func TestNewCmdEnvSecret(t *testing.T) {
 
 	// Test if the persistent flags include config flags
 	assert.NotNil(t, rootCmd.PersistentFlags().Lookup("kubeconfig"))
+	assert.NotNil(t, rootCmd.PersistentFlags().Lookup("timeout"))
 }
 
 func TestCmdExecute(t *testing.T) {
@@ -57,3 +60,51 @@ func TestCmdExecute(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Contains(t, outBuf.String(), cmd.LongDescription)
 }
+
+func TestCmdVersion(t *testing.T) {
+	inBuf := new(bytes.Buffer)
+	outBuf := new(bytes.Buffer)
+	errBuf := new(bytes.Buffer)
+	streams := genericiooptions.IOStreams{In: inBuf, Out: outBuf, ErrOut: errBuf}
+
+	rootCmd := cmd.NewCmdEnvSecret(streams)
+	rootCmd.SetArgs([]string{"version"})
+	err := rootCmd.Execute()
+
+	assert.Nil(t, err)
+	assert.Equal(t, cmd.AppVersion+"\n", outBuf.String())
+}
+
+func TestCmdExecRequiresDash(t *testing.T) {
+	streams := genericiooptions.IOStreams{In: new(bytes.Buffer), Out: new(bytes.Buffer), ErrOut: new(bytes.Buffer)}
+
+	rootCmd := cmd.NewCmdEnvSecret(streams)
+	rootCmd.SetArgs([]string{"exec", "api", "./myservice"})
+	err := rootCmd.Execute()
+
+	assert.ErrorContains(t, err, "followed by --")
+}
+
+func TestExitError(t *testing.T) {
+	var err error = &cmd.ExitError{Code: 3}
+	assert.Equal(t, "exit status 3", err.Error())
+}
+
+// runWith runs the plugin with args, replacing the subcommand of the same name
+// with the one returned by build, e.g. one created with an injected clientset.
+func runWith(t *testing.T, build func(genericiooptions.IOStreams) *cobra.Command, args ...string) (string, string, error) {
+	outBuf := new(bytes.Buffer)
+	errBuf := new(bytes.Buffer)
+	streams := genericiooptions.IOStreams{In: new(bytes.Buffer), Out: outBuf, ErrOut: errBuf}
+
+	rootCmd := cmd.NewCmdEnvSecret(streams)
+	replacement := build(streams)
+	existing, _, err := rootCmd.Find([]string{replacement.Name()})
+	require.NoError(t, err)
+	rootCmd.RemoveCommand(existing)
+	rootCmd.AddCommand(replacement)
+
+	rootCmd.SetArgs(args)
+	err = rootCmd.ExecuteContext(context.Background())
+	return outBuf.String(), errBuf.String(), err
+}
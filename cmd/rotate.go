@@ -0,0 +1,344 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/diff"
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/ogticrd/kubectl-envsecret/internal/output"
+	"github.com/ogticrd/kubectl-envsecret/internal/parser"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// RotateOptions contains the options for the rotate command.
+type RotateOptions struct {
+	genericclioptions.IOStreams
+	configFlags  *genericclioptions.ConfigFlags
+	restConfig   *rest.Config
+	clientset    kubernetes.Interface
+	namespace    string
+	secretName   string
+	fromFile     string
+	output       string
+	keys         []string
+	historyLimit int
+	retries      int
+	finalize     bool
+	restart      bool
+	force        bool
+}
+
+// NewRotateOptions initializes RotateOptions with the provided IO streams.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// options := NewRotateOptions(genericclioptions.NewConfigFlags(true), streams)
+func NewRotateOptions(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *RotateOptions {
+	return &RotateOptions{
+		configFlags:  configFlags,
+		IOStreams:    streams,
+		historyLimit: k8sapi.DefaultHistoryLimit,
+		restart:      true,
+	}
+}
+
+// WithClientset sets the client used to talk to the cluster instead of one
+// created from the kubeconfig, e.g. a fake clientset in tests.
+//
+// Example usage:
+// options := NewRotateOptions(configFlags, streams).WithClientset(fake.NewSimpleClientset())
+func (o *RotateOptions) WithClientset(clientset kubernetes.Interface) *RotateOptions {
+	o.clientset = clientset
+	return o
+}
+
+// NewCmdRotate creates a new cobra command for rotating keys of a secret in two steps.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// cmd := NewCmdRotate(genericclioptions.NewConfigFlags(true), streams)
+// cmd.Execute()
+func NewCmdRotate(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *cobra.Command {
+	return NewCmdRotateWithOptions(NewRotateOptions(configFlags, streams))
+}
+
+// NewCmdRotateWithOptions creates the rotate command running with the given options.
+//
+// Example usage:
+// o := NewRotateOptions(genericclioptions.NewConfigFlags(true), streams).WithClientset(clientset)
+// cmd := NewCmdRotateWithOptions(o)
+func NewCmdRotateWithOptions(o *RotateOptions) *cobra.Command {
+	// rotateCmd represents the rotate command
+	rotateCmd := &cobra.Command{
+		Use:   "rotate [secret name] [flags]",
+		Short: "Rotate keys of a secret while keeping their previous values.",
+		Long: fmt.Sprintf(`The rotate command replaces credentials that support two active values without downtime.
+
+  It writes the new value of each --key, taken from --from-file, and keeps the current value under KEY%s, so that both values are valid while consumers pick up the new one. Deployments, StatefulSets and DaemonSets using the secret are then restarted, as with kubectl rollout restart. Values in the file may be %sLENGTH[:CHARSET] directives to generate the new value.
+
+  Once every consumer uses the new values, run the command again with --finalize to remove the previous values. The keys being rotated are recorded in the %s annotation of the secret.`, k8sapi.PreviousKeySuffix, parser.DirectivePrefix, k8sapi.AnnotationRotatingKeys),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			if err := o.Run(ctx); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	rotateCmd.Flags().StringSliceVar(&o.keys, "key", o.keys, "Key to rotate. Can be repeated. With --finalize, defaults to every key being rotated.")
+	rotateCmd.Flags().StringVar(&o.fromFile, "from-file", o.fromFile, "Path to a .env file with the new values of the keys.")
+	rotateCmd.MarkFlagFilename("from-file")
+	rotateCmd.Flags().BoolVar(&o.finalize, "finalize", o.finalize, "Remove the previous values of the keys being rotated.")
+	rotateCmd.Flags().BoolVar(&o.restart, "restart", o.restart, "Restart the Deployments, StatefulSets and DaemonSets using the secret after writing the new values.")
+	rotateCmd.Flags().BoolVar(&o.force, "force", o.force, "With --finalize, remove previous values even if workloads still reference them by key.")
+	rotateCmd.Flags().IntVar(&o.historyLimit, "history-limit", o.historyLimit, "Number of previous versions of the secret to keep. Use 0 to keep all of them.")
+	rotateCmd.Flags().StringVarP(&o.output, "output", "o", o.output, "Output format. One of: json, yaml. Prints human readable text when empty.")
+
+	return rotateCmd
+}
+
+// Complete completes all necessary settings.
+func (o *RotateOptions) Complete(cmd *cobra.Command, args []string) error {
+	o.secretName = args[0]
+
+	var err error
+
+	// An injected clientset does not need the kubeconfig.
+	if o.clientset == nil {
+		o.restConfig, err = o.configFlags.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+	}
+
+	ns, err := cmd.Flags().GetString("namespace")
+	if err != nil {
+		return err
+	}
+
+	if len(ns) == 0 {
+		o.namespace = "default"
+	} else {
+		o.namespace = ns
+	}
+
+	o.retries, err = cmd.Flags().GetInt("retries")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate validates all set flags and args
+func (o *RotateOptions) Validate() error {
+	if err := output.ValidateFormat(o.output); err != nil {
+		return err
+	}
+	if o.historyLimit < 0 {
+		return fmt.Errorf("--history-limit must be greater than or equal to 0")
+	}
+	for _, key := range o.keys {
+		if strings.HasSuffix(key, k8sapi.PreviousKeySuffix) {
+			return fmt.Errorf("cannot rotate key %s, it holds the previous value of another key", key)
+		}
+	}
+	if o.finalize {
+		if len(o.fromFile) > 0 {
+			return fmt.Errorf("--from-file cannot be used with --finalize")
+		}
+		return nil
+	}
+	if len(o.keys) == 0 {
+		return fmt.Errorf("at least one --key is required")
+	}
+	if len(o.fromFile) == 0 {
+		return fmt.Errorf("--from-file is required to rotate keys")
+	}
+	if o.force {
+		return fmt.Errorf("--force can only be used with --finalize")
+	}
+	return nil
+}
+
+// Run starts or finalizes the rotation of the keys of the secret
+func (o *RotateOptions) Run(ctx context.Context) error {
+	var client *k8sapi.K8sClient
+	if o.clientset != nil {
+		client = k8sapi.NewK8sClient(o.clientset, o.namespace)
+	} else {
+		var err error
+		client, err = k8sapi.NewK8sClientFromConfig(k8sapi.NewK8sConfig(o.restConfig, o.namespace))
+		if err != nil {
+			return err
+		}
+	}
+
+	client.WithRetryAttempts(o.retries)
+
+	// Recording a revision lists and reads previous revisions.
+	verbs := []string{"get", "list", "create", "update"}
+	if o.historyLimit > 0 {
+		verbs = append(verbs, "delete")
+	}
+	if err := client.Preflight(ctx, verbs...); err != nil {
+		return err
+	}
+	if !o.finalize && o.restart {
+		if err := client.PreflightRestart(ctx); err != nil {
+			return fmt.Errorf("%w, or use --restart=false and restart the workloads by hand", err)
+		}
+	}
+
+	secret, err := client.GetSecret(ctx, o.secretName)
+	if err != nil {
+		return err
+	}
+
+	if o.finalize {
+		return o.runFinalize(ctx, client, secret)
+	}
+	return o.runStart(ctx, client, secret)
+}
+
+// runStart writes the new values of the keys and restarts the consumers of the secret.
+func (o *RotateOptions) runStart(ctx context.Context, client *k8sapi.K8sClient, secret *v1.Secret) error {
+	values, err := o.newValues()
+	if err != nil {
+		return err
+	}
+
+	updated, err := client.StartRotation(ctx, o.secretName, values)
+	if err != nil {
+		return err
+	}
+	if _, err := client.RecordRevision(ctx, updated, o.historyLimit); err != nil {
+		return err
+	}
+
+	for _, key := range o.keys {
+		fmt.Fprintf(o.ErrOut, "Kept the previous value of %s under %s, run with --finalize to remove it\n", key, k8sapi.PreviousKey(key))
+	}
+	if o.restart {
+		if err := o.restartConsumers(ctx, client); err != nil {
+			return err
+		}
+	}
+
+	result := output.NewResult(updated, output.ActionRotated)
+	result.Changes = diff.Compare(secret.Data, updated.Data).String()
+	return output.Print(o.Out, o.output, result)
+}
+
+// runFinalize removes the previous values of the keys being rotated.
+func (o *RotateOptions) runFinalize(ctx context.Context, client *k8sapi.K8sClient, secret *v1.Secret) error {
+	keys := o.keys
+	if len(keys) == 0 {
+		keys = k8sapi.RotatingKeys(secret)
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("secret %s has no rotation in progress", o.secretName)
+	}
+
+	// Removing a previous value breaks the workloads that still reference it
+	// by key, once their pods are recreated.
+	consumers, err := client.FindConsumers(ctx, o.secretName)
+	if err != nil {
+		if !o.force {
+			return fmt.Errorf("cannot check which workloads use secret %s, use --force to finalize the rotation anyway: %w", o.secretName, err)
+		}
+		fmt.Fprintf(o.ErrOut, "Warning: cannot check which workloads use secret %s, finalizing the rotation anyway: %v\n", o.secretName, err)
+	}
+	var users []string
+	for _, usage := range k8sapi.KeyUsages(secret, consumers) {
+		previous := slices.ContainsFunc(keys, func(key string) bool { return k8sapi.PreviousKey(key) == usage.Key })
+		if previous && len(usage.Reference.Key) > 0 {
+			users = append(users, fmt.Sprintf("%s (key %s)", usage.Consumer, usage.Key))
+		}
+	}
+	if len(users) > 0 {
+		if !o.force {
+			return fmt.Errorf("previous values are still used by %s, use --force to finalize the rotation anyway", strings.Join(users, ", "))
+		}
+		fmt.Fprintf(o.ErrOut, "Warning: removing previous values still used by %s\n", strings.Join(users, ", "))
+	}
+
+	updated, err := client.FinalizeRotation(ctx, o.secretName, keys)
+	if err != nil {
+		return err
+	}
+	if _, err := client.RecordRevision(ctx, updated, o.historyLimit); err != nil {
+		return err
+	}
+
+	result := output.NewResult(updated, output.ActionFinalized)
+	result.Changes = diff.Compare(secret.Data, updated.Data).String()
+	return output.Print(o.Out, o.output, result)
+}
+
+// newValues reads the new values of the keys from the file, generating the
+// values given as directives.
+func (o *RotateOptions) newValues() (map[string]string, error) {
+	parsedFile, err := parser.Load(o.fromFile)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(o.keys))
+	for _, key := range o.keys {
+		value, ok := parsedFile[key]
+		if !ok {
+			return nil, fmt.Errorf("key %s not found in %s", key, o.fromFile)
+		}
+		directive, err := parser.ParseDirective(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		if directive != nil {
+			if value, err = directive.Generate(); err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(o.ErrOut, "Generated a new value for %s\n", key)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// restartConsumers restarts the workloads using the secret and reports the
+// ones that must be restarted by hand.
+func (o *RotateOptions) restartConsumers(ctx context.Context, client *k8sapi.K8sClient) error {
+	consumers, err := client.FindConsumers(ctx, o.secretName)
+	if err != nil {
+		return fmt.Errorf("the new values were written but the workloads using secret %s could not be restarted: %w", o.secretName, err)
+	}
+
+	restarted, skipped, err := client.RestartConsumers(ctx, consumers)
+	for _, consumer := range restarted {
+		fmt.Fprintf(o.ErrOut, "Restarted %s\n", consumer)
+	}
+	if err != nil {
+		return fmt.Errorf("the new values were written but not every workload was restarted: %w", err)
+	}
+	for _, consumer := range skipped {
+		fmt.Fprintf(o.ErrOut, "Warning: %s cannot be restarted, it picks up the new values when its pods are recreated\n", consumer)
+	}
+	return nil
+}
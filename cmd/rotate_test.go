@@ -0,0 +1,71 @@
+package cmd_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/cmd"
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func runRotate(t *testing.T, clientset *fake.Clientset, args ...string) (string, string, error) {
+	return runWith(t, func(streams genericiooptions.IOStreams) *cobra.Command {
+		o := cmd.NewRotateOptions(genericclioptions.NewConfigFlags(true), streams).WithClientset(clientset)
+		return cmd.NewCmdRotateWithOptions(o)
+	}, append([]string{"rotate"}, args...)...)
+}
+
+func TestCmdRotateFinalizeConsumersUnknown(t *testing.T) {
+	secret := existingSecret(map[string]string{"API_TOKEN": "new", "API_TOKEN_PREVIOUS": "old"})
+	secret.Annotations = map[string]string{k8sapi.AnnotationRotatingKeys: "API_TOKEN"}
+
+	t.Run("refuses without --force", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset(secret.DeepCopy())
+		denyVerbs(clientset)
+		failListDeployments(clientset)
+
+		_, _, err := runRotate(t, clientset, "api", "--finalize")
+		assert.ErrorContains(t, err, "cannot check which workloads use secret api, use --force to finalize the rotation anyway")
+	})
+	t.Run("finalizes with --force and a warning", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset(secret.DeepCopy())
+		denyVerbs(clientset)
+		failListDeployments(clientset)
+
+		_, stderr, err := runRotate(t, clientset, "api", "--finalize", "--force")
+		require.NoError(t, err)
+		assert.Contains(t, stderr, "Warning: cannot check which workloads use secret api, finalizing the rotation anyway")
+
+		updated, err := clientset.CoreV1().Secrets("default").Get(context.Background(), "api", metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]byte{"API_TOKEN": []byte("new")}, updated.Data)
+		assert.Empty(t, k8sapi.RotatingKeys(updated))
+	})
+}
+
+func TestCmdRotateRestartForbidden(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(envFile, []byte("API_TOKEN=new\n"), 0600))
+	clientset := fake.NewSimpleClientset(existingSecret(map[string]string{"API_TOKEN": "old"}))
+	denyVerbs(clientset, "patch")
+
+	_, _, err := runRotate(t, clientset, "api", "--key", "API_TOKEN", "--from-file", envFile)
+	assert.ErrorContains(t, err, `you are not allowed to patch deployments.apps in namespace "default"`)
+	assert.ErrorContains(t, err, "--restart=false")
+
+	secret, err := clientset.CoreV1().Secrets("default").Get(context.Background(), "api", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"API_TOKEN": []byte("old")}, secret.Data)
+
+	_, _, err = runRotate(t, clientset, "api", "--key", "API_TOKEN", "--from-file", envFile, "--restart=false")
+	require.NoError(t, err)
+}
@@ -25,23 +25,30 @@ func NewVersionOptions(streams genericiooptions.IOStreams) *VersionOptions {
 	}
 }
 
-// NewCmdCreate creates a new cobra command for printing kubectl-envsecret version.
+// NewCmdVersion creates a new cobra command for printing kubectl-envsecret version.
 //
 // Example usage:
 // streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
 // cmd := NewCmdVersion(streams)
 // cmd.Execute()
 func NewCmdVersion(streams genericiooptions.IOStreams) *cobra.Command {
+	o := NewVersionOptions(streams)
+
 	// versionCmd represents the version command
 	versionCmd := &cobra.Command{
 		Use:   "version",
 		Short: "Print kubectl-envsecret version.",
 		Long:  "Print kubectl-envsecret version.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			fmt.Println(AppVersion)
-			return nil
+			return o.Run()
 		},
 	}
 
 	return versionCmd
 }
+
+// Run prints the version
+func (o *VersionOptions) Run() error {
+	_, err := fmt.Fprintln(o.Out, o.version)
+	return err
+}
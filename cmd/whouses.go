@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/ogticrd/kubectl-envsecret/internal/output"
+	"github.com/spf13/cobra"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// WhoUsesOptions contains the options for the who-uses command.
+type WhoUsesOptions struct {
+	genericclioptions.IOStreams
+	configFlags *genericclioptions.ConfigFlags
+	restConfig  *rest.Config
+	clientset   kubernetes.Interface
+	namespace   string
+	secretName  string
+	output      string
+}
+
+// whoUsesReport is the result of the who-uses command, as printed with -o json or -o yaml.
+type whoUsesReport struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Usages    []k8sapi.KeyUsage `json:"usages"`
+	Unused    []string          `json:"unused"`
+	Found     bool              `json:"found"`
+}
+
+// NewWhoUsesOptions initializes WhoUsesOptions with the provided IO streams.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// options := NewWhoUsesOptions(genericclioptions.NewConfigFlags(true), streams)
+func NewWhoUsesOptions(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *WhoUsesOptions {
+	return &WhoUsesOptions{
+		configFlags: configFlags,
+		IOStreams:   streams,
+	}
+}
+
+// WithClientset sets the client used to talk to the cluster instead of one
+// created from the kubeconfig, e.g. a fake clientset in tests.
+//
+// Example usage:
+// options := NewWhoUsesOptions(configFlags, streams).WithClientset(fake.NewSimpleClientset())
+func (o *WhoUsesOptions) WithClientset(clientset kubernetes.Interface) *WhoUsesOptions {
+	o.clientset = clientset
+	return o
+}
+
+// NewCmdWhoUses creates a new cobra command for finding the workloads using each key of a secret.
+//
+// Example usage:
+// streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+// cmd := NewCmdWhoUses(genericclioptions.NewConfigFlags(true), streams)
+// cmd.Execute()
+func NewCmdWhoUses(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *cobra.Command {
+	return NewCmdWhoUsesWithOptions(NewWhoUsesOptions(configFlags, streams))
+}
+
+// NewCmdWhoUsesWithOptions creates the who-uses command running with the given options.
+//
+// Example usage:
+// o := NewWhoUsesOptions(genericclioptions.NewConfigFlags(true), streams).WithClientset(clientset)
+// cmd := NewCmdWhoUsesWithOptions(o)
+func NewCmdWhoUsesWithOptions(o *WhoUsesOptions) *cobra.Command {
+	// whoUsesCmd represents the who-uses command
+	whoUsesCmd := &cobra.Command{
+		Use:   "who-uses [secret name] [flags]",
+		Short: "Show which workloads use each key of a secret.",
+		Long: `The who-uses command shows the blast radius of a secret before deleting or rotating it.
+
+  It scans the pod templates of Deployments, StatefulSets, DaemonSets, Jobs, CronJobs and Pods in the namespace for envFrom sources, environment variables, secret volumes and projected volumes using the secret, and lists the workloads using each key. Keys used by nobody are listed too. References to keys that the secret does not have are flagged as missing, and make the command exit with a non-zero status unless they are optional.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			// From here on errors report missing keys, not a wrong invocation.
+			cmd.SilenceUsage = true
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			if err := o.Run(ctx); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	whoUsesCmd.Flags().StringVarP(&o.output, "output", "o", o.output, "Output format. One of: json, yaml. Prints a table when empty.")
+
+	return whoUsesCmd
+}
+
+// Complete completes all necessary settings.
+func (o *WhoUsesOptions) Complete(cmd *cobra.Command, args []string) error {
+	o.secretName = args[0]
+
+	var err error
+
+	// An injected clientset does not need the kubeconfig.
+	if o.clientset == nil {
+		o.restConfig, err = o.configFlags.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+	}
+
+	ns, err := cmd.Flags().GetString("namespace")
+	if err != nil {
+		return err
+	}
+
+	if len(ns) == 0 {
+		o.namespace = "default"
+	} else {
+		o.namespace = ns
+	}
+
+	return nil
+}
+
+// Validate validates all set flags and args
+func (o *WhoUsesOptions) Validate() error {
+	return output.ValidateFormat(o.output)
+}
+
+// Run finds the consumers of the secret and prints them by key
+func (o *WhoUsesOptions) Run(ctx context.Context) error {
+	var client *k8sapi.K8sClient
+	if o.clientset != nil {
+		client = k8sapi.NewK8sClient(o.clientset, o.namespace)
+	} else {
+		var err error
+		client, err = k8sapi.NewK8sClientFromConfig(k8sapi.NewK8sConfig(o.restConfig, o.namespace))
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := client.Preflight(ctx, "get"); err != nil {
+		return err
+	}
+
+	// Workloads may still reference a secret that was deleted.
+	secret, getErr := client.GetSecret(ctx, o.secretName)
+	if getErr != nil && !kerr.IsNotFound(getErr) {
+		return getErr
+	}
+	if getErr != nil {
+		secret = nil
+	}
+
+	consumers, err := client.FindConsumers(ctx, o.secretName)
+	if err != nil {
+		return err
+	}
+	if secret == nil && len(consumers) == 0 {
+		return getErr
+	}
+
+	report := whoUsesReport{
+		Name:      o.secretName,
+		Namespace: o.namespace,
+		Usages:    k8sapi.KeyUsages(secret, consumers),
+		Unused:    []string{},
+		Found:     secret != nil,
+	}
+	if report.Usages == nil {
+		report.Usages = []k8sapi.KeyUsage{}
+	}
+	if secret != nil {
+		used := make(map[string]bool)
+		for _, usage := range report.Usages {
+			used[usage.Key] = true
+		}
+		for key := range secret.Data {
+			if !used[key] {
+				report.Unused = append(report.Unused, key)
+			}
+		}
+		sort.Strings(report.Unused)
+	}
+
+	if err := o.print(report); err != nil {
+		return err
+	}
+
+	missing := 0
+	for _, usage := range report.Usages {
+		if usage.Missing && !usage.Reference.Optional {
+			missing++
+		}
+	}
+	if missing > 0 {
+		return fmt.Errorf("found %d reference(s) to missing keys of secret %s", missing, o.secretName)
+	}
+
+	return nil
+}
+
+// print writes the report in the selected output format.
+func (o *WhoUsesOptions) print(report whoUsesReport) error {
+	if o.output != output.FormatText {
+		return output.Encode(o.Out, o.output, report)
+	}
+
+	if !report.Found {
+		fmt.Fprintf(o.ErrOut, "Warning: secret %s not found in namespace %s, but workloads still use it\n", report.Name, report.Namespace)
+	}
+
+	w := printers.GetNewTabWriter(o.Out)
+	fmt.Fprintln(w, "KEY\tCONSUMER\tREFERENCE\tSTATUS")
+	for _, usage := range report.Usages {
+		status := "ok"
+		if usage.Missing {
+			status = "missing"
+		}
+		// The key is already in its own column.
+		ref := usage.Reference
+		ref.Key = ""
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", usage.Key, usage.Consumer, ref, status)
+	}
+	for _, key := range report.Unused {
+		fmt.Fprintf(w, "%s\t<none>\t\tunused\n", key)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,57 @@
+package cmd_test
+
+import (
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/cmd"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func runWhoUses(t *testing.T, clientset *fake.Clientset, args ...string) (string, string, error) {
+	return runWith(t, func(streams genericiooptions.IOStreams) *cobra.Command {
+		o := cmd.NewWhoUsesOptions(genericclioptions.NewConfigFlags(true), streams).WithClientset(clientset)
+		return cmd.NewCmdWhoUsesWithOptions(o)
+	}, append([]string{"who-uses"}, args...)...)
+}
+
+func TestCmdWhoUsesYAML(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{
+						Name: "app",
+						Env: []v1.EnvVar{{
+							Name: "API_URL",
+							ValueFrom: &v1.EnvVarSource{
+								SecretKeyRef: &v1.SecretKeySelector{
+									LocalObjectReference: v1.LocalObjectReference{Name: "api"},
+									Key:                  "API_URL",
+								},
+							},
+						}},
+					}},
+				},
+			},
+		},
+	}
+	clientset := fake.NewSimpleClientset(existingSecret(map[string]string{"API_URL": "https://api.example.com", "DEBUG": "true"}), deployment)
+	denyVerbs(clientset)
+
+	stdout, _, err := runWhoUses(t, clientset, "api", "-o", "yaml")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "consumer: Deployment/web")
+	assert.Contains(t, stdout, "unused:\n- DEBUG\n")
+
+	_, _, err = runWhoUses(t, clientset, "api", "-o", "text")
+	assert.ErrorContains(t, err, `unknown output format "text"`)
+}
@@ -0,0 +1,86 @@
+// Package diff provides utilities for comparing secret data.
+//
+// This package compares two sets of key-value pairs and reports which keys
+// were added, removed or changed. Values are never included in the result,
+// so it is safe to print or store the comparison output.
+package diff
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+)
+
+// Changes holds the keys that differ between two sets of secret data.
+type Changes struct {
+	Added   []string // Keys present only in the new data.
+	Removed []string // Keys present only in the old data.
+	Changed []string // Keys present in both with different values.
+}
+
+// Compare compares the old and new secret data and returns the keys that differ.
+//
+// Parameters:
+// - oldData: The previous secret data.
+// - newData: The desired secret data.
+//
+// Returns:
+// - A Changes instance with sorted key lists.
+//
+// Example usage:
+// changes := diff.Compare(secret.Data, utils.MapStringToBytes(parsedFile))
+// fmt.Println(changes) // Output: +NEW_KEY ~CHANGED_KEY -REMOVED_KEY
+func Compare(oldData, newData map[string][]byte) Changes {
+	var changes Changes
+
+	for key, newValue := range newData {
+		oldValue, found := oldData[key]
+		if !found {
+			changes.Added = append(changes.Added, key)
+		} else if !bytes.Equal(oldValue, newValue) {
+			changes.Changed = append(changes.Changed, key)
+		}
+	}
+	for key := range oldData {
+		if _, found := newData[key]; !found {
+			changes.Removed = append(changes.Removed, key)
+		}
+	}
+
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Removed)
+	sort.Strings(changes.Changed)
+
+	return changes
+}
+
+// Empty reports whether there are no differences.
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0
+}
+
+// Keys returns all the keys that differ, sorted alphabetically.
+func (c Changes) Keys() []string {
+	keys := make([]string, 0, len(c.Added)+len(c.Removed)+len(c.Changed))
+	keys = append(keys, c.Added...)
+	keys = append(keys, c.Removed...)
+	keys = append(keys, c.Changed...)
+	sort.Strings(keys)
+	return keys
+}
+
+// String returns a redacted summary of the changes, prefixing added keys with
+// "+", changed keys with "~" and removed keys with "-".
+func (c Changes) String() string {
+	parts := make([]string, 0, len(c.Added)+len(c.Removed)+len(c.Changed))
+	for _, key := range c.Added {
+		parts = append(parts, "+"+key)
+	}
+	for _, key := range c.Changed {
+		parts = append(parts, "~"+key)
+	}
+	for _, key := range c.Removed {
+		parts = append(parts, "-"+key)
+	}
+	return strings.Join(parts, " ")
+}
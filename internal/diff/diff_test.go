@@ -0,0 +1,51 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/diff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		oldData  map[string][]byte
+		newData  map[string][]byte
+		name     string
+		expected string
+		empty    bool
+	}{
+		{
+			name:     "No changes",
+			oldData:  map[string][]byte{"A": []byte("1")},
+			newData:  map[string][]byte{"A": []byte("1")},
+			expected: "",
+			empty:    true,
+		},
+		{
+			name:     "Added, changed and removed keys",
+			oldData:  map[string][]byte{"A": []byte("1"), "B": []byte("2")},
+			newData:  map[string][]byte{"A": []byte("10"), "C": []byte("3")},
+			expected: "+C ~A -B",
+		},
+		{
+			name:     "From empty data",
+			oldData:  nil,
+			newData:  map[string][]byte{"B": []byte("2"), "A": []byte("1")},
+			expected: "+A +B",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := diff.Compare(tt.oldData, tt.newData)
+			assert.Equal(t, tt.expected, changes.String())
+			assert.Equal(t, tt.empty, changes.Empty())
+		})
+	}
+}
+
+func TestChangesKeys(t *testing.T) {
+	changes := diff.Changes{Added: []string{"C"}, Removed: []string{"A"}, Changed: []string{"B"}}
+	assert.Equal(t, []string{"A", "B", "C"}, changes.Keys())
+}
@@ -0,0 +1,110 @@
+// Package drift provides utilities for detecting changes made to secrets outside kubectl-envsecret.
+//
+// This package compares the content expected from local .env files with the
+// secrets living in the cluster, either key by key or through the content
+// hash annotation stamped by kubectl-envsecret, and renders the results as
+// reports that CI systems and scheduled jobs can consume.
+package drift
+
+import (
+	"fmt"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/diff"
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Mode selects how expected and live secrets are compared.
+type Mode string
+
+const (
+	// ModeFull compares every key and value of the secret.
+	ModeFull Mode = "full"
+	// ModeHash compares content hashes, using the annotation stamped by kubectl-envsecret.
+	ModeHash Mode = "hash"
+)
+
+// Status is the outcome of a drift check.
+type Status string
+
+const (
+	// StatusInSync means the live secret matches the expected content.
+	StatusInSync Status = "InSync"
+	// StatusDrifted means the live secret differs from the expected content.
+	StatusDrifted Status = "Drifted"
+	// StatusMissing means the secret does not exist in the cluster.
+	StatusMissing Status = "Missing"
+	// StatusError means the check could not be completed.
+	StatusError Status = "Error"
+)
+
+// Result is the outcome of checking a single secret.
+type Result struct {
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace"`
+	Status    Status   `json:"status"`
+	Reason    string   `json:"reason,omitempty"`
+	Added     []string `json:"added,omitempty"`   // Keys only present in the live secret.
+	Removed   []string `json:"removed,omitempty"` // Keys missing from the live secret.
+	Changed   []string `json:"changed,omitempty"` // Keys with a different live value.
+}
+
+// Check compares the expected secret data with the live secret.
+//
+// Parameters:
+// - expected: The data built from the local .env files.
+// - live: The secret as returned by the API server, or nil if it does not exist.
+// - mode: How to compare the expected and live content.
+//
+// Returns:
+// - The check result. Name and namespace are taken from the live secret when available.
+//
+// Example usage:
+// secret, err := k8sClient.GetSecret("my-secret")
+// result := drift.Check(utils.MapStringToBytes(parsedFile), secret, drift.ModeFull)
+func Check(expected map[string][]byte, live *v1.Secret, mode Mode) Result {
+	if live == nil {
+		return Result{Status: StatusMissing, Reason: "secret does not exist"}
+	}
+
+	result := Result{Name: live.Name, Namespace: live.Namespace, Status: StatusInSync}
+
+	switch mode {
+	case ModeHash:
+		annotated := live.Annotations[k8sapi.AnnotationContentHash]
+		switch {
+		case len(annotated) == 0:
+			result.Status = StatusDrifted
+			result.Reason = fmt.Sprintf("secret has no %s annotation", k8sapi.AnnotationContentHash)
+		case annotated != k8sapi.ContentHash(live.Data):
+			result.Status = StatusDrifted
+			result.Reason = "secret data was modified outside kubectl-envsecret"
+		case annotated != k8sapi.ContentHash(expected):
+			result.Status = StatusDrifted
+			result.Reason = "env files changed since the secret was last written"
+		}
+	default:
+		changes := diff.Compare(expected, live.Data)
+		if !changes.Empty() {
+			result.Status = StatusDrifted
+			result.Reason = fmt.Sprintf("%d key(s) differ", len(changes.Keys()))
+			result.Added = changes.Added
+			result.Removed = changes.Removed
+			result.Changed = changes.Changed
+		}
+	}
+
+	return result
+}
+
+// ParseMode validates a comparison mode name.
+//
+// Example usage:
+// mode, err := drift.ParseMode("hash")
+func ParseMode(s string) (Mode, error) {
+	switch Mode(s) {
+	case ModeFull, ModeHash:
+		return Mode(s), nil
+	}
+	return "", fmt.Errorf("unknown drift mode %q, must be one of: %s, %s", s, ModeFull, ModeHash)
+}
@@ -0,0 +1,117 @@
+package drift_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/drift"
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func mockLiveSecret(data map[string][]byte, hash string) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "api",
+			Namespace:   "production",
+			Annotations: map[string]string{k8sapi.AnnotationContentHash: hash},
+		},
+		Data: data,
+	}
+}
+
+func TestCheck(t *testing.T) {
+	expected := map[string][]byte{"A": []byte("1"), "B": []byte("2")}
+	edited := map[string][]byte{"A": []byte("10"), "C": []byte("3")}
+
+	tests := []struct {
+		live           *v1.Secret
+		name           string
+		mode           drift.Mode
+		expectedStatus drift.Status
+		expectedKeys   []string
+	}{
+		{
+			name:           "Missing secret",
+			live:           nil,
+			mode:           drift.ModeFull,
+			expectedStatus: drift.StatusMissing,
+		},
+		{
+			name:           "Full mode in sync",
+			live:           mockLiveSecret(expected, ""),
+			mode:           drift.ModeFull,
+			expectedStatus: drift.StatusInSync,
+		},
+		{
+			name:           "Full mode drifted",
+			live:           mockLiveSecret(edited, k8sapi.ContentHash(expected)),
+			mode:           drift.ModeFull,
+			expectedStatus: drift.StatusDrifted,
+			expectedKeys:   []string{"C", "B", "A"},
+		},
+		{
+			name:           "Hash mode in sync",
+			live:           mockLiveSecret(expected, k8sapi.ContentHash(expected)),
+			mode:           drift.ModeHash,
+			expectedStatus: drift.StatusInSync,
+		},
+		{
+			name:           "Hash mode hand edited",
+			live:           mockLiveSecret(edited, k8sapi.ContentHash(expected)),
+			mode:           drift.ModeHash,
+			expectedStatus: drift.StatusDrifted,
+		},
+		{
+			name:           "Hash mode without annotation",
+			live:           mockLiveSecret(expected, ""),
+			mode:           drift.ModeHash,
+			expectedStatus: drift.StatusDrifted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := drift.Check(expected, tt.live, tt.mode)
+			assert.Equal(t, tt.expectedStatus, result.Status)
+
+			var keys []string
+			keys = append(keys, result.Added...)
+			keys = append(keys, result.Removed...)
+			keys = append(keys, result.Changed...)
+			assert.Equal(t, tt.expectedKeys, keys)
+		})
+	}
+}
+
+func TestReportWrite(t *testing.T) {
+	report := &drift.Report{Results: []drift.Result{
+		{Name: "api", Namespace: "production", Status: drift.StatusInSync},
+		{Name: "worker", Namespace: "production", Status: drift.StatusDrifted, Reason: "1 key(s) differ", Changed: []string{"TOKEN"}},
+		{Name: "cron", Namespace: "jobs", Status: drift.StatusError, Reason: "forbidden"},
+	}}
+
+	assert.Equal(t, 2, report.Failed())
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		require.Nil(t, report.Write(&out, "json"))
+
+		var decoded drift.Report
+		require.Nil(t, json.Unmarshal(out.Bytes(), &decoded))
+		assert.Equal(t, *report, decoded)
+	})
+	t.Run("junit", func(t *testing.T) {
+		var out bytes.Buffer
+		require.Nil(t, report.Write(&out, "junit"))
+		assert.Contains(t, out.String(), `<testsuite name="kubectl-envsecret drift" tests="3" failures="1" errors="1">`)
+		assert.Contains(t, out.String(), "changed in cluster: TOKEN")
+	})
+	t.Run("unknown format", func(t *testing.T) {
+		assert.NotNil(t, report.Write(&bytes.Buffer{}, "csv"))
+	})
+}
@@ -0,0 +1,126 @@
+package drift
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"k8s.io/cli-runtime/pkg/printers"
+)
+
+// Report groups the results of checking several secrets.
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// Failed returns the number of results that are not in sync.
+func (r *Report) Failed() int {
+	failed := 0
+	for _, result := range r.Results {
+		if result.Status != StatusInSync {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Write renders the report in the given format: text, json or junit.
+//
+// Example usage:
+// err := report.Write(os.Stdout, "junit")
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case "", "text":
+		return r.writeText(w)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	case "junit":
+		return r.writeJUnit(w)
+	}
+	return fmt.Errorf("unknown report format %q, must be one of: text, json, junit", format)
+}
+
+func (r *Report) writeText(w io.Writer) error {
+	tw := printers.GetNewTabWriter(w)
+	fmt.Fprintln(tw, "NAMESPACE\tNAME\tSTATUS\tREASON")
+	for _, result := range r.Results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", result.Namespace, result.Name, result.Status, result.Reason)
+	}
+	return tw.Flush()
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	TestCases []junitCase `xml:"testcase"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Errors    int         `xml:"errors,attr"`
+}
+
+type junitCase struct {
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Details string `xml:",chardata"`
+}
+
+func (r *Report) writeJUnit(w io.Writer) error {
+	suite := junitSuite{Name: "kubectl-envsecret drift", Tests: len(r.Results)}
+	for _, result := range r.Results {
+		testCase := junitCase{ClassName: result.Namespace, Name: result.Name}
+
+		failure := &junitFailure{Message: result.Reason, Type: string(result.Status), Details: details(result)}
+		switch result.Status {
+		case StatusInSync:
+		case StatusError:
+			suite.Errors++
+			testCase.Error = failure
+		default:
+			suite.Failures++
+			testCase.Failure = failure
+		}
+
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(junitTestSuites{Suites: []junitSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// details lists the keys that differ, never their values.
+func details(result Result) string {
+	var lines []string
+	for _, key := range result.Added {
+		lines = append(lines, "added in cluster: "+key)
+	}
+	for _, key := range result.Removed {
+		lines = append(lines, "missing in cluster: "+key)
+	}
+	for _, key := range result.Changed {
+		lines = append(lines, "changed in cluster: "+key)
+	}
+	return strings.Join(lines, "\n")
+}
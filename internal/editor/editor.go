@@ -0,0 +1,253 @@
+// Package editor provides utilities for editing the data of a secret as a
+// .env file in the editor of the user.
+//
+// Values are written as they are, instead of base64 encoded, and multiline
+// values keep their line breaks. The temporary file is only readable by the
+// current user and its content is overwritten before it is removed.
+package editor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/parser"
+	"github.com/ogticrd/kubectl-envsecret/internal/runner"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+)
+
+// ErrEmpty is returned when the user saves an empty file, which aborts the edit.
+var ErrEmpty = errors.New("edit cancelled, saved file was empty")
+
+// intro explains the file to the user, as kubectl edit does.
+var intro = []string{
+	"# Please edit the values below. Lines beginning with a '#' will be ignored,",
+	"# and an empty file will abort the edit. If an error occurs while saving this file will be",
+	"# reopened with the relevant failures.",
+}
+
+// Editor opens files in the editor of the user.
+type Editor struct {
+	genericiooptions.IOStreams          // Streams connected to the editor.
+	Args                       []string // Command and arguments of the editor. The file path is appended.
+}
+
+// NewDefaultEditor returns the editor set in $KUBE_EDITOR or $EDITOR, as
+// kubectl edit does, falling back to vi, or notepad on Windows.
+//
+// Example usage:
+// e := editor.NewDefaultEditor(genericiooptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr})
+func NewDefaultEditor(streams genericiooptions.IOStreams) *Editor {
+	args := []string{"vi"}
+	if runtime.GOOS == "windows" {
+		args = []string{"notepad"}
+	}
+	for _, name := range []string{"KUBE_EDITOR", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(name)); len(fields) > 0 {
+			args = fields
+			break
+		}
+	}
+	return &Editor{IOStreams: streams, Args: args}
+}
+
+// Launch writes content to a temporary file, opens it in the editor and
+// returns the content saved by the user.
+//
+// The file is created with mode 0600, and overwritten with zeros before it is
+// removed.
+//
+// Parameters:
+// - content: The initial content of the file.
+//
+// Returns:
+// - The content of the file when the editor exits.
+// - An error if the file cannot be written or the editor fails.
+//
+// Example usage:
+// edited, err := e.Launch([]byte("API_TOKEN=tok_123\n"))
+func (e *Editor) Launch(content []byte) ([]byte, error) {
+	file, err := os.CreateTemp("", "envsecret-*.env")
+	if err != nil {
+		return nil, err
+	}
+	path := file.Name()
+	defer shred(path)
+
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	args := append(append([]string{}, e.Args[1:]...), path)
+	code, err := runner.Run(e.Args[0], args, os.Environ(), e.In, e.Out, e.ErrOut)
+	if err != nil {
+		return nil, fmt.Errorf("error launching editor %s: %w", e.Args[0], err)
+	}
+	if code != 0 {
+		return nil, fmt.Errorf("editor %s exited with status %d", e.Args[0], code)
+	}
+
+	return os.ReadFile(path)
+}
+
+// shred overwrites the file with zeros and removes it. Editors may replace
+// the file instead of writing to it, so the overwrite is best effort.
+func shred(path string) {
+	if info, err := os.Stat(path); err == nil {
+		if file, err := os.OpenFile(path, os.O_WRONLY, 0); err == nil {
+			file.Write(make([]byte, info.Size()))
+			file.Sync()
+			file.Close()
+		}
+	}
+	os.Remove(path)
+}
+
+// Problem is an error found in the edited file.
+type Problem struct {
+	Message string // Description of the problem.
+	Line    int    // Line of the problem, starting at 1, or 0 if unknown.
+}
+
+// String returns the problem prefixed with its line, if known.
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return p.Message
+	}
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+// Edit opens the data of a secret in the editor and returns the data saved
+// by the user.
+//
+// The file is re-parsed on save. When it cannot be parsed, it is reopened
+// with the errors as comments at the top, as kubectl edit does, until it is
+// valid or the user saves an empty file. Values that cannot be written as
+// text, such as binary data, are left out of the file and kept unless the
+// file defines them.
+//
+// Parameters:
+// - data: The data of the secret.
+//
+// Returns:
+// - The edited data. It equals data when the user made no changes.
+// - ErrEmpty if the user saved an empty file, or an error if the editor fails.
+//
+// Example usage:
+// edited, err := e.Edit(secret.Data)
+// changes := diff.Compare(secret.Data, edited)
+func (e *Editor) Edit(data map[string][]byte) (map[string][]byte, error) {
+	body, kept := parser.Marshal(data)
+
+	var problems []Problem
+	for {
+		head := header(kept, problems)
+		content, err := e.Launch(append(head, body...))
+		if err != nil {
+			return nil, err
+		}
+
+		// Keep what the user wrote when reopening, without the previous header.
+		body = bytes.TrimPrefix(content, head)
+		if isEmpty(body) {
+			return nil, ErrEmpty
+		}
+
+		var values map[string]string
+		values, problems = Parse(body)
+		if len(problems) > 0 {
+			fmt.Fprintf(e.ErrOut, "error: the edited file is not valid, reopening it with %d problem(s)\n", len(problems))
+			continue
+		}
+
+		edited := make(map[string][]byte, len(values)+len(kept))
+		for _, key := range kept {
+			edited[key] = data[key]
+		}
+		for key, value := range values {
+			edited[key] = []byte(value)
+		}
+		return edited, nil
+	}
+}
+
+// header returns the comments written at the top of the file. Lines of the
+// problems, relative to the body, are shifted to lines of the whole file.
+func header(kept []string, problems []Problem) []byte {
+	lines := append([]string{}, intro...)
+	lines = append(lines, "#")
+	if len(kept) > 0 {
+		lines = append(lines, "# These keys cannot be edited as text and are kept unchanged: "+strings.Join(kept, ", "), "#")
+	}
+
+	if len(problems) > 0 {
+		offset := len(lines) + len(problems) + 1
+		for _, problem := range problems {
+			if problem.Line > 0 {
+				problem.Line += offset
+			}
+			message := strings.Join(strings.Fields(problem.String()), " ")
+			lines = append(lines, "# error: "+message)
+		}
+		lines = append(lines, "#")
+	}
+
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+// isEmpty reports whether content only has comments and blank lines.
+func isEmpty(content []byte) bool {
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) > 0 && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
+
+// Parse parses an edited file and checks that its keys are valid secret keys.
+//
+// Parameters:
+// - content: The content of the file.
+//
+// Returns:
+// - The parsed values, if there are no problems.
+// - The problems found, with their lines in content.
+//
+// Example usage:
+// values, problems := editor.Parse([]byte("API_TOKEN=tok_123\n"))
+func Parse(content []byte) (map[string]string, []Problem) {
+	entries, err := parser.ParseEntries(content)
+	if err != nil {
+		var syntaxErr *parser.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return nil, []Problem{{Line: syntaxErr.Line, Message: syntaxErr.Err.Error()}}
+		}
+		return nil, []Problem{{Message: err.Error()}}
+	}
+
+	var problems []Problem
+	for _, entry := range entries {
+		if errs := validation.IsConfigMapKey(entry.Key); len(errs) > 0 {
+			problems = append(problems, Problem{Line: entry.Line, Message: fmt.Sprintf("invalid key %q: %s", entry.Key, strings.Join(errs, ", "))})
+		}
+	}
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
+	values, err := parser.Parse(content)
+	if err != nil {
+		return nil, []Problem{{Message: err.Error()}}
+	}
+	return values, nil
+}
@@ -0,0 +1,126 @@
+package editor_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/editor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+)
+
+var data = map[string][]byte{
+	"API_TOKEN": []byte("tok_123"),
+	"EMPTY":     []byte(""),
+	"GREETING":  []byte("hello world # not a comment"),
+	"PRICE":     []byte("$5 or \"five\""),
+	"TLS_KEY":   []byte("-----BEGIN KEY-----\nabc\n-----END KEY-----\n"),
+	"WINDOWS":   []byte("a\r\nb'c"),
+	"BINARY":    {0xff, 0x00, 0x01},
+	"ENDS":      []byte(`it's a "quote"`),
+}
+
+func TestParse(t *testing.T) {
+	values, problems := editor.Parse([]byte("# comment\nA=1\nB='two\nlines'\n"))
+	assert.Empty(t, problems)
+	assert.Equal(t, map[string]string{"A": "1", "B": "two\nlines"}, values)
+
+	_, problems = editor.Parse([]byte("A=1\nB='unbalanced\n"))
+	require.Len(t, problems, 1)
+	assert.Equal(t, 2, problems[0].Line)
+	assert.Contains(t, problems[0].String(), "line 2: unbalanced quotes")
+
+	_, problems = editor.Parse([]byte("GOOD=1\nBAD/KEY=2\n"))
+	require.Len(t, problems, 1)
+	assert.Equal(t, 2, problems[0].Line)
+	assert.Contains(t, problems[0].Message, `invalid key "BAD/KEY"`)
+}
+
+// scriptEditor returns an editor running a shell script with the path of the
+// file as $1.
+func scriptEditor(t *testing.T, script string) (*editor.Editor, *bytes.Buffer) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	errOut := new(bytes.Buffer)
+	streams := genericiooptions.IOStreams{In: new(bytes.Buffer), Out: new(bytes.Buffer), ErrOut: errOut}
+	return &editor.Editor{IOStreams: streams, Args: []string{"sh", "-c", script, "editor"}}, errOut
+}
+
+func TestEdit(t *testing.T) {
+	dir := t.TempDir()
+	record := filepath.Join(dir, "record")
+	e, _ := scriptEditor(t, `echo "$1" > `+record+`; ls -l "$1" | cut -c1-10 >> `+record+`; sed 's/^API_TOKEN=.*/API_TOKEN=rotated/' "$1" > "$1.new"; mv "$1.new" "$1"; echo "NEW='x y'" >> "$1"`)
+
+	edited, err := e.Edit(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, "rotated", string(edited["API_TOKEN"]))
+	assert.Equal(t, "x y", string(edited["NEW"]))
+	assert.Equal(t, data["TLS_KEY"], edited["TLS_KEY"])
+	assert.Equal(t, data["BINARY"], edited["BINARY"], "values that cannot be edited are kept")
+	assert.Len(t, edited, len(data)+1)
+
+	content, err := os.ReadFile(record)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, "-rw-------", lines[1])
+	assert.NoFileExists(t, lines[0])
+}
+
+func TestEditReopensOnErrors(t *testing.T) {
+	dir := t.TempDir()
+	saved := filepath.Join(dir, "saved")
+	e, errOut := scriptEditor(t, `if [ ! -f `+saved+` ]; then echo "BROKEN='x" >> "$1"; touch `+saved+`; else cp "$1" `+saved+`; sed '$d' "$1" > "$1.new"; mv "$1.new" "$1"; fi`)
+
+	edited, err := e.Edit(map[string][]byte{"A": []byte("1")})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"A": []byte("1")}, edited)
+	assert.Contains(t, errOut.String(), "reopening")
+
+	// The reopened file points at the line of the broken entry.
+	content, err := os.ReadFile(saved)
+	require.NoError(t, err)
+	lines := strings.Split(string(content), "\n")
+	var reported string
+	for i, line := range lines {
+		if strings.HasPrefix(line, "# error: line ") {
+			reported = strings.Fields(strings.TrimPrefix(line, "# error: line "))[0]
+		}
+		if strings.HasPrefix(line, "BROKEN=") {
+			assert.Equal(t, strings.TrimSuffix(reported, ":"), strconv.Itoa(i+1))
+		}
+	}
+	assert.NotEmpty(t, reported)
+}
+
+func TestEditEmpty(t *testing.T) {
+	e, _ := scriptEditor(t, `grep '^#' "$1" > "$1.new"; mv "$1.new" "$1"`)
+	_, err := e.Edit(data)
+	assert.ErrorIs(t, err, editor.ErrEmpty)
+}
+
+func TestEditEditorFails(t *testing.T) {
+	e, _ := scriptEditor(t, `exit 2`)
+	_, err := e.Edit(data)
+	assert.ErrorContains(t, err, "exited with status 2")
+}
+
+func TestNewDefaultEditor(t *testing.T) {
+	t.Setenv("KUBE_EDITOR", "")
+	t.Setenv("EDITOR", "code --wait")
+	e := editor.NewDefaultEditor(genericiooptions.IOStreams{})
+	assert.Equal(t, []string{"code", "--wait"}, e.Args)
+
+	t.Setenv("KUBE_EDITOR", "nano")
+	e = editor.NewDefaultEditor(genericiooptions.IOStreams{})
+	assert.Equal(t, []string{"nano"}, e.Args)
+}
@@ -0,0 +1,210 @@
+// Package generate provides utilities for turning parsed .env files into
+// manifests for other delivery tools, such as Kustomize and Helm, so secrets
+// can be shipped through GitOps pipelines without re-typing their keys.
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// File is a file produced by a generator.
+type File struct {
+	Path      string // Path of the file, relative to the output directory.
+	Content   []byte // Content of the file.
+	Sensitive bool   // Whether the file contains secret values.
+}
+
+// Kustomize returns a kustomization.yaml with a secretGenerator entry for the
+// secret, along with the files it references.
+//
+// Single line values are written to NAME.env and referenced with envs.
+// Kustomize env files cannot hold multiline values, so those are written to
+// NAME/KEY files and referenced with files.
+//
+// Parameters:
+// - name: Name of the secret.
+// - namespace: Namespace of the secret. Empty leaves it to Kustomize.
+// - values: The key-value pairs of the secret.
+//
+// Returns:
+// - The generated files, kustomization.yaml first.
+// - An error if the kustomization cannot be serialized.
+//
+// Example usage:
+// files, err := generate.Kustomize("my-secret", "production", values)
+func Kustomize(name, namespace string, values map[string]string) ([]File, error) {
+	type secretGenerator struct {
+		Name      string   `json:"name"`
+		Namespace string   `json:"namespace,omitempty"`
+		Type      string   `json:"type"`
+		Envs      []string `json:"envs,omitempty"`
+		Files     []string `json:"files,omitempty"`
+	}
+	type kustomization struct {
+		APIVersion      string            `json:"apiVersion"`
+		Kind            string            `json:"kind"`
+		SecretGenerator []secretGenerator `json:"secretGenerator"`
+	}
+
+	generator := secretGenerator{Name: name, Namespace: namespace, Type: "Opaque"}
+	var files []File
+
+	var env strings.Builder
+	for _, key := range sortedKeys(values) {
+		value := values[key]
+		if strings.ContainsAny(value, "\r\n") {
+			path := filepath.ToSlash(filepath.Join(name, key))
+			generator.Files = append(generator.Files, key+"="+path)
+			files = append(files, File{Path: path, Content: []byte(value), Sensitive: true})
+			continue
+		}
+		fmt.Fprintf(&env, "%s=%s\n", key, value)
+	}
+	if env.Len() > 0 {
+		path := name + ".env"
+		generator.Envs = []string{path}
+		files = append([]File{{Path: path, Content: []byte(env.String()), Sensitive: true}}, files...)
+	}
+
+	content, err := yaml.Marshal(kustomization{
+		APIVersion:      "kustomize.config.k8s.io/v1beta1",
+		Kind:            "Kustomization",
+		SecretGenerator: []secretGenerator{generator},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]File{{Path: "kustomization.yaml", Content: content}}, files...), nil
+}
+
+// helmTemplate renders a secret from the values of a chart. %[1]q is the
+// secret name.
+const helmTemplate = `{{- $secret := index .Values.secrets %[1]q }}
+apiVersion: v1
+kind: Secret
+metadata:
+  name: %[1]s
+  namespace: {{ .Release.Namespace }}
+  labels:
+    app.kubernetes.io/managed-by: {{ .Release.Service }}
+type: Opaque
+data:
+{{- range $key, $value := $secret.data }}
+  {{ $key }}: {{ $value | toString | b64enc }}
+{{- end }}
+`
+
+// Helm returns a values.yaml snippet holding the secret values and a
+// templates/secret.yaml template that renders the secret from them.
+//
+// Values are stored under secrets.NAME.data, so several secrets can share a
+// chart. The secret is created in the namespace of the release.
+//
+// Parameters:
+// - name: Name of the secret.
+// - values: The key-value pairs of the secret.
+//
+// Returns:
+// - The generated files, values.yaml first.
+// - An error if the values cannot be serialized.
+//
+// Example usage:
+// files, err := generate.Helm("my-secret", values)
+func Helm(name string, values map[string]string) ([]File, error) {
+	content, err := yaml.Marshal(map[string]interface{}{
+		"secrets": map[string]interface{}{
+			name: map[string]interface{}{"data": values},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []File{
+		{Path: "values.yaml", Content: content, Sensitive: true},
+		{Path: filepath.ToSlash(filepath.Join("templates", "secret.yaml")), Content: []byte(fmt.Sprintf(helmTemplate, name))},
+	}, nil
+}
+
+// Print writes the files to w, each one preceded by a "# Source:" comment
+// with its path, as helm template does.
+//
+// Example usage:
+// err := generate.Print(os.Stdout, files)
+func Print(w io.Writer, files []File) error {
+	for _, file := range files {
+		if _, err := fmt.Fprintf(w, "---\n# Source: %s\n", file.Path); err != nil {
+			return err
+		}
+		if _, err := w.Write(file.Content); err != nil {
+			return err
+		}
+		if len(file.Content) > 0 && file.Content[len(file.Content)-1] != '\n' {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Write saves the files in dir. Files with secret values are only readable by
+// the current user.
+//
+// Parameters:
+// - dir: The output directory, created if needed.
+// - files: The files to write.
+// - overwrite: Whether existing files can be replaced.
+//
+// Returns:
+// - An error if a file exists and overwrite is false, or a file cannot be written.
+//
+// Example usage:
+// err := generate.Write("deploy/base", files, false)
+func Write(dir string, files []File, overwrite bool) error {
+	if !overwrite {
+		for _, file := range files {
+			path := filepath.Join(dir, filepath.FromSlash(file.Path))
+			if _, err := os.Stat(path); err == nil {
+				return fmt.Errorf("file %s already exists, use --overwrite to replace it", path)
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+	}
+
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file.Path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+
+		var perm os.FileMode = 0644
+		if file.Sensitive {
+			perm = 0600
+		}
+		if err := os.WriteFile(path, file.Content, perm); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,114 @@
+package generate_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/generate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+var values = map[string]string{
+	"API_TOKEN": "tok_123",
+	"URL":       "https://example.com/?a=b",
+	"TLS_KEY":   "-----BEGIN KEY-----\nabc\n-----END KEY-----\n",
+}
+
+func TestKustomize(t *testing.T) {
+	files, err := generate.Kustomize("api", "production", values)
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+
+	assert.Equal(t, "kustomization.yaml", files[0].Path)
+	assert.False(t, files[0].Sensitive)
+	assert.Equal(t, `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+secretGenerator:
+- envs:
+  - api.env
+  files:
+  - TLS_KEY=api/TLS_KEY
+  name: api
+  namespace: production
+  type: Opaque
+`, string(files[0].Content))
+
+	assert.Equal(t, generate.File{Path: "api.env", Content: []byte("API_TOKEN=tok_123\nURL=https://example.com/?a=b\n"), Sensitive: true}, files[1])
+	assert.Equal(t, generate.File{Path: "api/TLS_KEY", Content: []byte(values["TLS_KEY"]), Sensitive: true}, files[2])
+}
+
+func TestKustomizeOnlyMultiline(t *testing.T) {
+	files, err := generate.Kustomize("certs", "", map[string]string{"CA": "a\nb"})
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	var kustomization map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(files[0].Content, &kustomization))
+	generator := kustomization["secretGenerator"].([]interface{})[0].(map[string]interface{})
+	assert.NotContains(t, generator, "envs")
+	assert.NotContains(t, generator, "namespace")
+	assert.Equal(t, []interface{}{"CA=certs/CA"}, generator["files"])
+}
+
+func TestHelm(t *testing.T) {
+	files, err := generate.Helm("api", values)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	assert.Equal(t, "values.yaml", files[0].Path)
+	assert.True(t, files[0].Sensitive)
+	var chartValues struct {
+		Secrets map[string]struct {
+			Data map[string]string `json:"data"`
+		} `json:"secrets"`
+	}
+	require.NoError(t, yaml.Unmarshal(files[0].Content, &chartValues))
+	assert.Equal(t, values, chartValues.Secrets["api"].Data)
+
+	assert.Equal(t, "templates/secret.yaml", files[1].Path)
+	assert.False(t, files[1].Sensitive)
+	assert.Contains(t, string(files[1].Content), `{{- $secret := index .Values.secrets "api" }}`)
+	assert.Contains(t, string(files[1].Content), "  name: api\n")
+	assert.Contains(t, string(files[1].Content), "{{ $value | toString | b64enc }}")
+}
+
+func TestPrint(t *testing.T) {
+	var out bytes.Buffer
+	err := generate.Print(&out, []generate.File{
+		{Path: "a.yaml", Content: []byte("a: 1\n")},
+		{Path: "b/KEY", Content: []byte("no trailing newline")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "---\n# Source: a.yaml\na: 1\n---\n# Source: b/KEY\nno trailing newline\n", out.String())
+}
+
+func TestWrite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	files := []generate.File{
+		{Path: "kustomization.yaml", Content: []byte("kind: Kustomization\n")},
+		{Path: "api/KEY", Content: []byte("secret"), Sensitive: true},
+	}
+
+	require.NoError(t, generate.Write(dir, files, false))
+
+	info, err := os.Stat(filepath.Join(dir, "kustomization.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+	info, err = os.Stat(filepath.Join(dir, "api", "KEY"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	err = generate.Write(dir, files, false)
+	assert.ErrorContains(t, err, "already exists")
+
+	files[1].Content = []byte("rotated")
+	require.NoError(t, generate.Write(dir, files, true))
+	content, err := os.ReadFile(filepath.Join(dir, "api", "KEY"))
+	require.NoError(t, err)
+	assert.Equal(t, "rotated", string(content))
+}
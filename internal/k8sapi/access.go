@@ -0,0 +1,137 @@
+package k8sapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SecretVerbs lists every verb kubectl-envsecret may use on secrets.
+var SecretVerbs = []string{"get", "list", "create", "update", "delete"}
+
+// Resource is a kind of API object access is checked on.
+type Resource struct {
+	Group string // API group, empty for the core group.
+	Name  string // Plural name of the resource, e.g. "deployments".
+}
+
+// SecretsResource is the resource of secrets.
+var SecretsResource = Resource{Name: "secrets"}
+
+// String returns the resource as kubectl names it, e.g. "deployments.apps".
+func (r Resource) String() string {
+	if len(r.Group) == 0 {
+		return r.Name
+	}
+	return r.Name + "." + r.Group
+}
+
+// AccessCheck is the outcome of checking a single verb on a resource.
+type AccessCheck struct {
+	Verb      string // Verb checked, e.g. "create".
+	Resource  string // Resource checked, e.g. "secrets" or "deployments.apps".
+	Namespace string // Namespace the verb was checked in.
+	Reason    string // Reason given by the authorizer, if any.
+	Allowed   bool   // Whether the current user may use the verb.
+}
+
+// CheckAccess asks the API server whether the current user may use the given
+// verbs on secrets in the client namespace, using SelfSubjectAccessReviews.
+//
+// Parameters:
+// - ctx: Context for the API requests.
+// - verbs: Verbs to check, e.g. "get" or "create".
+//
+// Returns:
+// - One AccessCheck per verb, in the same order.
+// - An error if the access reviews cannot be created.
+//
+// Example usage:
+// checks, err := k8sClient.CheckAccess(ctx, "get", "create")
+func (c *K8sClient) CheckAccess(ctx context.Context, verbs ...string) ([]AccessCheck, error) {
+	return c.CheckResourceAccess(ctx, SecretsResource, verbs...)
+}
+
+// CheckResourceAccess is CheckAccess for any resource in the client namespace.
+//
+// Example usage:
+// checks, err := k8sClient.CheckResourceAccess(ctx, k8sapi.Resource{Group: "apps", Name: "deployments"}, "list", "patch")
+func (c *K8sClient) CheckResourceAccess(ctx context.Context, resource Resource, verbs ...string) ([]AccessCheck, error) {
+	checks := make([]AccessCheck, 0, len(verbs))
+	for _, verb := range verbs {
+		review, err := c.client.AuthorizationV1().SelfSubjectAccessReviews().Create(
+			ctx,
+			&authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Namespace: c.namespace,
+						Verb:      verb,
+						Group:     resource.Group,
+						Resource:  resource.Name,
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("unable to check %s access on %s in namespace %s: %w", verb, resource, c.namespace, err)
+		}
+
+		checks = append(checks, AccessCheck{
+			Verb:      verb,
+			Resource:  resource.String(),
+			Namespace: c.namespace,
+			Reason:    review.Status.Reason,
+			Allowed:   review.Status.Allowed,
+		})
+	}
+
+	return checks, nil
+}
+
+// Preflight checks that the current user may use the given verbs on secrets
+// and returns an actionable error naming the missing permissions otherwise.
+//
+// Parameters:
+// - ctx: Context for the API requests.
+// - verbs: Verbs required by the operation about to run.
+//
+// Returns:
+// - An error if any verb is denied or the access cannot be checked.
+//
+// Example usage:
+// if err := k8sClient.Preflight(ctx, "get", "create"); err != nil {
+// return err
+// }
+func (c *K8sClient) Preflight(ctx context.Context, verbs ...string) error {
+	return c.PreflightResource(ctx, SecretsResource, verbs...)
+}
+
+// PreflightResource is Preflight for any resource in the client namespace.
+//
+// Example usage:
+// err := k8sClient.PreflightResource(ctx, k8sapi.Resource{Group: "apps", Name: "deployments"}, "patch")
+func (c *K8sClient) PreflightResource(ctx context.Context, resource Resource, verbs ...string) error {
+	checks, err := c.CheckResourceAccess(ctx, resource, verbs...)
+	if err != nil {
+		return err
+	}
+
+	var denied []string
+	for _, check := range checks {
+		if !check.Allowed {
+			denied = append(denied, check.Verb)
+		}
+	}
+	if len(denied) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"you are not allowed to %s %s in namespace %q; ask a cluster administrator for a Role in that namespace granting these verbs on the %q resource",
+		strings.Join(denied, ", "), resource, c.namespace, resource,
+	)
+}
@@ -0,0 +1,78 @@
+package k8sapi_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// allowVerbs makes the fake clientset answer access reviews, allowing only the given verbs.
+func allowVerbs(client *fake.Clientset, verbs ...string) {
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		for _, verb := range verbs {
+			if review.Spec.ResourceAttributes.Verb == verb {
+				review.Status.Allowed = true
+			}
+		}
+		return true, review, nil
+	})
+}
+
+func TestK8sCheckAccess(t *testing.T) {
+	ctx := context.Background()
+	fakeClient := fake.NewSimpleClientset()
+	allowVerbs(fakeClient, "get")
+
+	k := k8sapi.NewK8sClient(fakeClient, "test")
+
+	checks, err := k.CheckAccess(ctx, "get", "create")
+	require.Nil(t, err)
+	assert.Equal(t, []k8sapi.AccessCheck{
+		{Verb: "get", Resource: "secrets", Namespace: "test", Allowed: true},
+		{Verb: "create", Resource: "secrets", Namespace: "test", Allowed: false},
+	}, checks)
+}
+
+func TestK8sPreflight(t *testing.T) {
+	ctx := context.Background()
+	fakeClient := fake.NewSimpleClientset()
+	allowVerbs(fakeClient, "get", "list")
+
+	k := k8sapi.NewK8sClient(fakeClient, "test")
+
+	t.Run("test Preflight succeeds when all verbs are allowed", func(t *testing.T) {
+		assert.Nil(t, k.Preflight(ctx, "get", "list"))
+	})
+	t.Run("test Preflight names the missing verbs", func(t *testing.T) {
+		err := k.Preflight(ctx, "get", "create", "update")
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "not allowed to create, update secrets in namespace \"test\"")
+	})
+}
+
+func TestK8sPreflightResource(t *testing.T) {
+	ctx := context.Background()
+	fakeClient := fake.NewSimpleClientset()
+	allowVerbs(fakeClient, "list")
+
+	k := k8sapi.NewK8sClient(fakeClient, "test")
+	deployments := k8sapi.Resource{Group: "apps", Name: "deployments"}
+
+	checks, err := k.CheckResourceAccess(ctx, deployments, "list")
+	require.Nil(t, err)
+	assert.Equal(t, []k8sapi.AccessCheck{{Verb: "list", Resource: "deployments.apps", Namespace: "test", Allowed: true}}, checks)
+
+	err = k.PreflightResource(ctx, deployments, "list", "patch")
+	assert.EqualError(t, err, `you are not allowed to patch deployments.apps in namespace "test"; ask a cluster administrator for a Role in that namespace granting these verbs on the "deployments.apps" resource`)
+
+	err = k.PreflightRestart(ctx)
+	assert.ErrorContains(t, err, "not allowed to patch deployments.apps")
+}
@@ -0,0 +1,279 @@
+package k8sapi
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Sources of the references of a pod spec to a secret.
+const (
+	SourceEnvFrom   = "envFrom"
+	SourceEnv       = "env"
+	SourceVolume    = "volume"
+	SourceProjected = "projected"
+)
+
+// Reference is a use of a secret in a pod spec.
+type Reference struct {
+	Source   string `json:"source"`             // How the secret is used, e.g. SourceEnvFrom.
+	Target   string `json:"target"`             // Container using the secret from its environment, or name of the volume.
+	Key      string `json:"key,omitempty"`      // Key used, empty when every key of the secret is used.
+	Optional bool   `json:"optional,omitempty"` // Whether the pod starts when the secret or key is missing.
+}
+
+// String describes the reference, e.g. "env of container app (key API_TOKEN)".
+func (r Reference) String() string {
+	var s string
+	switch r.Source {
+	case SourceEnvFrom, SourceEnv:
+		s = fmt.Sprintf("%s of container %s", r.Source, r.Target)
+	case SourceProjected:
+		s = "projected volume " + r.Target
+	default:
+		s = "volume " + r.Target
+	}
+	if len(r.Key) > 0 {
+		s += fmt.Sprintf(" (key %s)", r.Key)
+	}
+	if r.Optional {
+		s += " (optional)"
+	}
+	return s
+}
+
+// Consumer is a workload using a secret.
+type Consumer struct {
+	Kind       string      // Kind of the workload, e.g. Deployment.
+	Name       string      // Name of the workload.
+	References []Reference // Uses of the secret in the pod template of the workload.
+}
+
+// String returns the kind and name of the workload, e.g. Deployment/api.
+func (c Consumer) String() string {
+	return c.Kind + "/" + c.Name
+}
+
+// SecretReferences returns the uses of a secret in a pod spec: environment
+// variables, envFrom sources, secret volumes and projected volumes, in
+// containers, init containers and ephemeral containers.
+//
+// Parameters:
+// - spec: The pod spec.
+// - secretName: Name of the secret.
+//
+// Returns:
+// - The references, in the order they appear in the spec.
+//
+// Example usage:
+// refs := SecretReferences(&deployment.Spec.Template.Spec, "my-secret")
+func SecretReferences(spec *v1.PodSpec, secretName string) []Reference {
+	var refs []Reference
+
+	envRefs := func(container string, envFrom []v1.EnvFromSource, env []v1.EnvVar) {
+		for _, source := range envFrom {
+			if source.SecretRef != nil && source.SecretRef.Name == secretName {
+				refs = append(refs, Reference{Source: SourceEnvFrom, Target: container, Optional: isOptional(source.SecretRef.Optional)})
+			}
+		}
+		for _, variable := range env {
+			if variable.ValueFrom == nil || variable.ValueFrom.SecretKeyRef == nil {
+				continue
+			}
+			if ref := variable.ValueFrom.SecretKeyRef; ref.Name == secretName {
+				refs = append(refs, Reference{Source: SourceEnv, Target: container, Key: ref.Key, Optional: isOptional(ref.Optional)})
+			}
+		}
+	}
+	for _, container := range spec.InitContainers {
+		envRefs(container.Name, container.EnvFrom, container.Env)
+	}
+	for _, container := range spec.Containers {
+		envRefs(container.Name, container.EnvFrom, container.Env)
+	}
+	for _, container := range spec.EphemeralContainers {
+		envRefs(container.Name, container.EnvFrom, container.Env)
+	}
+
+	volumeRefs := func(source, volume string, items []v1.KeyToPath, optional bool) {
+		if len(items) == 0 {
+			refs = append(refs, Reference{Source: source, Target: volume, Optional: optional})
+			return
+		}
+		for _, item := range items {
+			refs = append(refs, Reference{Source: source, Target: volume, Key: item.Key, Optional: optional})
+		}
+	}
+	for _, volume := range spec.Volumes {
+		if volume.Secret != nil && volume.Secret.SecretName == secretName {
+			volumeRefs(SourceVolume, volume.Name, volume.Secret.Items, isOptional(volume.Secret.Optional))
+		}
+		if volume.Projected == nil {
+			continue
+		}
+		for _, source := range volume.Projected.Sources {
+			if source.Secret != nil && source.Secret.Name == secretName {
+				volumeRefs(SourceProjected, volume.Name, source.Secret.Items, isOptional(source.Secret.Optional))
+			}
+		}
+	}
+
+	return refs
+}
+
+func isOptional(optional *bool) bool {
+	return optional != nil && *optional
+}
+
+// FindConsumers returns the workloads in the client namespace whose pod
+// templates use a secret: Deployments, StatefulSets, DaemonSets, Jobs,
+// CronJobs and Pods.
+//
+// Pods and Jobs created by a controller, such as the pods of a Deployment or
+// the jobs of a CronJob, are reported through their controller only.
+//
+// Parameters:
+// - ctx: Context for the API requests.
+// - secretName: Name of the secret.
+//
+// Returns:
+// - The consumers, sorted by kind and name.
+// - An error if a kind of workload cannot be listed.
+//
+// Example usage:
+// consumers, err := k8sClient.FindConsumers(ctx, "my-secret")
+func (c *K8sClient) FindConsumers(ctx context.Context, secretName string) ([]Consumer, error) {
+	var consumers []Consumer
+	add := func(kind string, meta metav1.ObjectMeta, spec *v1.PodSpec) {
+		if refs := SecretReferences(spec, secretName); len(refs) > 0 {
+			consumers = append(consumers, Consumer{Kind: kind, Name: meta.Name, References: refs})
+		}
+	}
+
+	deployments, err := c.client.AppsV1().Deployments(c.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing deployments: %w", err)
+	}
+	for _, item := range deployments.Items {
+		add("Deployment", item.ObjectMeta, &item.Spec.Template.Spec)
+	}
+
+	statefulSets, err := c.client.AppsV1().StatefulSets(c.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing statefulsets: %w", err)
+	}
+	for _, item := range statefulSets.Items {
+		add("StatefulSet", item.ObjectMeta, &item.Spec.Template.Spec)
+	}
+
+	daemonSets, err := c.client.AppsV1().DaemonSets(c.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing daemonsets: %w", err)
+	}
+	for _, item := range daemonSets.Items {
+		add("DaemonSet", item.ObjectMeta, &item.Spec.Template.Spec)
+	}
+
+	cronJobs, err := c.client.BatchV1().CronJobs(c.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing cronjobs: %w", err)
+	}
+	for _, item := range cronJobs.Items {
+		add("CronJob", item.ObjectMeta, &item.Spec.JobTemplate.Spec.Template.Spec)
+	}
+
+	jobs, err := c.client.BatchV1().Jobs(c.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing jobs: %w", err)
+	}
+	for _, item := range jobs.Items {
+		if owner := metav1.GetControllerOf(&item); owner != nil && owner.Kind == "CronJob" {
+			continue
+		}
+		add("Job", item.ObjectMeta, &item.Spec.Template.Spec)
+	}
+
+	pods, err := c.client.CoreV1().Pods(c.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods: %w", err)
+	}
+	for _, item := range pods.Items {
+		if metav1.GetControllerOf(&item) != nil {
+			continue
+		}
+		add("Pod", item.ObjectMeta, &item.Spec)
+	}
+
+	sort.SliceStable(consumers, func(i, j int) bool {
+		if consumers[i].Kind != consumers[j].Kind {
+			return consumers[i].Kind < consumers[j].Kind
+		}
+		return consumers[i].Name < consumers[j].Name
+	})
+	return consumers, nil
+}
+
+// KeyUsage is a use of a key of a secret by a workload.
+type KeyUsage struct {
+	Key       string    `json:"key"`               // Key of the secret, or "*" for every key of a missing secret.
+	Consumer  string    `json:"consumer"`          // Kind and name of the workload, e.g. Deployment/api.
+	Reference Reference `json:"reference"`         // How the workload uses the key.
+	Missing   bool      `json:"missing,omitempty"` // Whether the key does not exist in the secret.
+}
+
+// KeyUsages breaks the references of the consumers of a secret down by key.
+//
+// References to the whole secret, such as envFrom sources, count as a use of
+// every key. References to keys that the secret does not have are flagged as
+// missing. When the secret does not exist, references to the whole secret
+// are reported under the "*" key, flagged as missing.
+//
+// Parameters:
+// - secret: The secret, or nil if it does not exist.
+// - consumers: The consumers of the secret, as returned by FindConsumers.
+//
+// Returns:
+// - The uses of the keys, sorted by key and consumer.
+//
+// Example usage:
+// usages := KeyUsages(secret, consumers)
+func KeyUsages(secret *v1.Secret, consumers []Consumer) []KeyUsage {
+	var data map[string][]byte
+	var keys []string
+	if secret != nil {
+		data = secret.Data
+		for key := range data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+	}
+
+	var usages []KeyUsage
+	for _, consumer := range consumers {
+		for _, ref := range consumer.References {
+			if len(ref.Key) > 0 {
+				_, found := data[ref.Key]
+				usages = append(usages, KeyUsage{Key: ref.Key, Consumer: consumer.String(), Reference: ref, Missing: !found})
+				continue
+			}
+			if secret == nil {
+				usages = append(usages, KeyUsage{Key: "*", Consumer: consumer.String(), Reference: ref, Missing: true})
+				continue
+			}
+			for _, key := range keys {
+				usages = append(usages, KeyUsage{Key: key, Consumer: consumer.String(), Reference: ref})
+			}
+		}
+	}
+
+	sort.SliceStable(usages, func(i, j int) bool {
+		if usages[i].Key != usages[j].Key {
+			return usages[i].Key < usages[j].Key
+		}
+		return usages[i].Consumer < usages[j].Consumer
+	})
+	return usages
+}
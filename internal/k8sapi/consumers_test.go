@@ -0,0 +1,140 @@
+package k8sapi_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func podSpec() v1.PodSpec {
+	optional := true
+	return v1.PodSpec{
+		InitContainers: []v1.Container{{
+			Name:    "migrate",
+			EnvFrom: []v1.EnvFromSource{{SecretRef: &v1.SecretEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: "api"}}}},
+		}},
+		Containers: []v1.Container{{
+			Name: "app",
+			Env: []v1.EnvVar{
+				{Name: "TOKEN", ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{Name: "api"}, Key: "API_TOKEN",
+				}}},
+				{Name: "OTHER", ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{Name: "other"}, Key: "API_TOKEN",
+				}}},
+				{Name: "PLAIN", Value: "1"},
+			},
+		}},
+		Volumes: []v1.Volume{
+			{Name: "certs", VolumeSource: v1.VolumeSource{Secret: &v1.SecretVolumeSource{
+				SecretName: "api", Items: []v1.KeyToPath{{Key: "TLS_CERT", Path: "tls.crt"}}, Optional: &optional,
+			}}},
+			{Name: "all", VolumeSource: v1.VolumeSource{Projected: &v1.ProjectedVolumeSource{Sources: []v1.VolumeProjection{
+				{Secret: &v1.SecretProjection{LocalObjectReference: v1.LocalObjectReference{Name: "api"}}},
+				{Secret: &v1.SecretProjection{LocalObjectReference: v1.LocalObjectReference{Name: "other"}}},
+			}}}},
+		},
+	}
+}
+
+func TestSecretReferences(t *testing.T) {
+	spec := podSpec()
+	assert.Equal(t, []k8sapi.Reference{
+		{Source: k8sapi.SourceEnvFrom, Target: "migrate"},
+		{Source: k8sapi.SourceEnv, Target: "app", Key: "API_TOKEN"},
+		{Source: k8sapi.SourceVolume, Target: "certs", Key: "TLS_CERT", Optional: true},
+		{Source: k8sapi.SourceProjected, Target: "all"},
+	}, k8sapi.SecretReferences(&spec, "api"))
+
+	assert.Empty(t, k8sapi.SecretReferences(&spec, "unused"))
+}
+
+func TestReferenceString(t *testing.T) {
+	assert.Equal(t, "envFrom of container migrate", k8sapi.Reference{Source: k8sapi.SourceEnvFrom, Target: "migrate"}.String())
+	assert.Equal(t, "env of container app (key API_TOKEN)", k8sapi.Reference{Source: k8sapi.SourceEnv, Target: "app", Key: "API_TOKEN"}.String())
+	assert.Equal(t, "volume certs (key TLS_CERT) (optional)", k8sapi.Reference{Source: k8sapi.SourceVolume, Target: "certs", Key: "TLS_CERT", Optional: true}.String())
+	assert.Equal(t, "projected volume all", k8sapi.Reference{Source: k8sapi.SourceProjected, Target: "all"}.String())
+}
+
+func TestK8sFindConsumers(t *testing.T) {
+	ctx := context.Background()
+	meta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: "production"}
+	}
+	controlled := func(name, kind string) metav1.ObjectMeta {
+		controller := true
+		m := meta(name)
+		m.OwnerReferences = []metav1.OwnerReference{{Kind: kind, Name: "owner", Controller: &controller}}
+		return m
+	}
+	template := v1.PodTemplateSpec{Spec: podSpec()}
+
+	client := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: meta("web"), Spec: appsv1.DeploymentSpec{Template: template}},
+		&appsv1.Deployment{ObjectMeta: meta("unrelated")},
+		&appsv1.StatefulSet{ObjectMeta: meta("db"), Spec: appsv1.StatefulSetSpec{Template: template}},
+		&appsv1.DaemonSet{ObjectMeta: meta("agent"), Spec: appsv1.DaemonSetSpec{Template: template}},
+		&batchv1.CronJob{ObjectMeta: meta("cleanup"), Spec: batchv1.CronJobSpec{JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: template}}}},
+		&batchv1.Job{ObjectMeta: controlled("cleanup-123", "CronJob"), Spec: batchv1.JobSpec{Template: template}},
+		&batchv1.Job{ObjectMeta: meta("backfill"), Spec: batchv1.JobSpec{Template: template}},
+		&v1.Pod{ObjectMeta: controlled("web-abc", "ReplicaSet"), Spec: podSpec()},
+		&v1.Pod{ObjectMeta: meta("debug"), Spec: podSpec()},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "elsewhere", Namespace: "staging"}, Spec: appsv1.DeploymentSpec{Template: template}},
+	)
+
+	consumers, err := k8sapi.NewK8sClient(client, "production").FindConsumers(ctx, "api")
+	require.NoError(t, err)
+
+	var names []string
+	for _, consumer := range consumers {
+		names = append(names, consumer.String())
+		assert.Len(t, consumer.References, 4)
+	}
+	assert.Equal(t, []string{"CronJob/cleanup", "DaemonSet/agent", "Deployment/web", "Job/backfill", "Pod/debug", "StatefulSet/db"}, names)
+}
+
+func TestKeyUsages(t *testing.T) {
+	consumers := []k8sapi.Consumer{
+		{Kind: "Deployment", Name: "web", References: []k8sapi.Reference{
+			{Source: k8sapi.SourceEnv, Target: "app", Key: "API_TOKEN"},
+			{Source: k8sapi.SourceEnv, Target: "app", Key: "GONE"},
+		}},
+		{Kind: "CronJob", Name: "cleanup", References: []k8sapi.Reference{
+			{Source: k8sapi.SourceEnvFrom, Target: "job"},
+		}},
+	}
+	secret := &v1.Secret{Data: map[string][]byte{"API_TOKEN": []byte("1"), "DB_URL": []byte("2")}}
+
+	type row struct {
+		key, consumer string
+		missing       bool
+	}
+	rows := func(usages []k8sapi.KeyUsage) []row {
+		var result []row
+		for _, usage := range usages {
+			result = append(result, row{usage.Key, usage.Consumer, usage.Missing})
+		}
+		return result
+	}
+
+	assert.Equal(t, []row{
+		{"API_TOKEN", "CronJob/cleanup", false},
+		{"API_TOKEN", "Deployment/web", false},
+		{"DB_URL", "CronJob/cleanup", false},
+		{"GONE", "Deployment/web", true},
+	}, rows(k8sapi.KeyUsages(secret, consumers)))
+
+	assert.Equal(t, []row{
+		{"*", "CronJob/cleanup", true},
+		{"API_TOKEN", "Deployment/web", true},
+		{"GONE", "Deployment/web", true},
+	}, rows(k8sapi.KeyUsages(nil, consumers)))
+}
@@ -0,0 +1,89 @@
+package k8sapi
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// lastAppliedAnnotation is set by kubectl apply and refers to the source object.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// PrepareCopy returns a copy of a secret that can be created somewhere else.
+//
+// Server-managed metadata (uid, resourceVersion, managedFields, creation
+// timestamp, owner references, ...) is stripped, so the copy can be created
+// in another namespace or cluster. Labels and annotations are preserved.
+//
+// Parameters:
+// - secret: The source secret as returned by the API server.
+// - name: Name of the copy. The source name is kept when empty.
+// - keys: Keys to keep. All keys are kept when empty.
+//
+// Returns:
+// - The secret copy.
+// - An error if one of the requested keys does not exist in the source secret.
+//
+// Example usage:
+// source, err := srcClient.GetSecret(ctx, "my-secret")
+// secret, err := PrepareCopy(source, "my-secret-copy", []string{"DB_PASSWORD"})
+// created, err := dstClient.CreateSecretFromObject(ctx, secret)
+func PrepareCopy(secret *v1.Secret, name string, keys []string) (*v1.Secret, error) {
+	if len(name) == 0 {
+		name = secret.Name
+	}
+
+	data := secret.Data
+	if len(keys) > 0 {
+		data = make(map[string][]byte, len(keys))
+		var missing []string
+		for _, key := range keys {
+			value, found := secret.Data[key]
+			if !found {
+				missing = append(missing, key)
+				continue
+			}
+			data[key] = value
+		}
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			return nil, fmt.Errorf("secret %s does not contain key(s) %v", secret.Name, missing)
+		}
+	}
+
+	copied := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      copyStringMap(secret.Labels),
+			Annotations: copyStringMap(secret.Annotations),
+		},
+		Type: secret.Type,
+		Data: make(map[string][]byte, len(data)),
+	}
+	delete(copied.Annotations, lastAppliedAnnotation)
+	// The content hash no longer matches when keys are filtered. It is
+	// stamped again when the copy is written.
+	delete(copied.Annotations, AnnotationContentHash)
+	// The source files live where the source was written, not where the
+	// copy is. The update time is stamped again too.
+	delete(copied.Annotations, AnnotationSourceFiles)
+	delete(copied.Annotations, AnnotationUpdatedAt)
+	for key, value := range data {
+		copied.Data[key] = append([]byte(nil), value...)
+	}
+
+	return copied, nil
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(m))
+	for key, value := range m {
+		copied[key] = value
+	}
+	return copied
+}
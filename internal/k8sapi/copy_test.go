@@ -0,0 +1,70 @@
+package k8sapi_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPrepareCopy(t *testing.T) {
+	ctx := context.Background()
+	source := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "source",
+			Namespace:       "staging",
+			UID:             "1234",
+			ResourceVersion: "42",
+			ManagedFields:   []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+			Labels:          map[string]string{"app": "api"},
+			Annotations: map[string]string{
+				"team": "platform",
+				"kubectl.kubernetes.io/last-applied-configuration": "{}",
+				k8sapi.AnnotationContentHash:                       "sha256:0123",
+				k8sapi.AnnotationSourceFiles:                       "/home/dev/app/.env",
+				k8sapi.AnnotationUpdatedAt:                         "2024-01-01T00:00:00Z",
+			},
+		},
+		Type: v1.SecretTypeOpaque,
+		Data: map[string][]byte{"A": []byte("1"), "B": []byte("2")},
+	}
+
+	t.Run("test PrepareCopy strips server-managed metadata", func(t *testing.T) {
+		secret, err := k8sapi.PrepareCopy(source, "", nil)
+		require.Nil(t, err)
+		assert.Equal(t, "source", secret.Name)
+		assert.Empty(t, secret.Namespace)
+		assert.Empty(t, secret.UID)
+		assert.Empty(t, secret.ResourceVersion)
+		assert.Empty(t, secret.ManagedFields)
+		assert.Equal(t, map[string]string{"app": "api"}, secret.Labels)
+		assert.Equal(t, map[string]string{"team": "platform"}, secret.Annotations)
+		assert.Equal(t, source.Data, secret.Data)
+	})
+	t.Run("test PrepareCopy renames and filters keys", func(t *testing.T) {
+		secret, err := k8sapi.PrepareCopy(source, "target", []string{"B"})
+		require.Nil(t, err)
+		assert.Equal(t, "target", secret.Name)
+		assert.Equal(t, map[string][]byte{"B": []byte("2")}, secret.Data)
+	})
+	t.Run("test PrepareCopy fails with missing keys", func(t *testing.T) {
+		_, err := k8sapi.PrepareCopy(source, "", []string{"B", "C"})
+		assert.NotNil(t, err)
+	})
+	t.Run("test copy can be created in another namespace", func(t *testing.T) {
+		secret, err := k8sapi.PrepareCopy(source, "", nil)
+		require.Nil(t, err)
+
+		k := k8sapi.NewK8sClient(fake.NewSimpleClientset(), "production")
+		created, err := k.CreateSecretFromObject(ctx, secret)
+		require.Nil(t, err)
+		assert.Equal(t, "production", created.Namespace)
+		assert.NotContains(t, created.Annotations, k8sapi.AnnotationSourceFiles)
+		assert.NotEqual(t, "2024-01-01T00:00:00Z", created.Annotations[k8sapi.AnnotationUpdatedAt])
+	})
+}
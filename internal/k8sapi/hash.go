@@ -0,0 +1,56 @@
+package k8sapi
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// AnnotationContentHash holds the hash of the secret data as written by kubectl-envsecret.
+const AnnotationContentHash = "envsecret.ogticrd.io/content-hash"
+
+// ContentHash returns a stable hash of secret data.
+//
+// Keys are sorted and every key and value is length-prefixed before hashing,
+// so the hash does not depend on map ordering and different splits of the
+// same bytes into keys and values produce different hashes.
+//
+// Parameters:
+// - data: The secret data.
+//
+// Returns:
+// - The hash, formatted as "sha256:<hex digest>".
+//
+// Example usage:
+// hash := ContentHash(utils.MapStringToBytes(parsedFile))
+func ContentHash(data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	var size [8]byte
+	for _, key := range keys {
+		binary.BigEndian.PutUint64(size[:], uint64(len(key)))
+		h.Write(size[:])
+		h.Write([]byte(key))
+		binary.BigEndian.PutUint64(size[:], uint64(len(data[key])))
+		h.Write(size[:])
+		h.Write(data[key])
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// stampContentHash sets the content hash annotation from the secret data.
+func stampContentHash(secret *v1.Secret) {
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	secret.Annotations[AnnotationContentHash] = ContentHash(secret.Data)
+}
@@ -0,0 +1,58 @@
+package k8sapi_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestContentHash(t *testing.T) {
+	tests := []struct {
+		a     map[string][]byte
+		b     map[string][]byte
+		name  string
+		equal bool
+	}{
+		{
+			name:  "Same data",
+			a:     map[string][]byte{"A": []byte("1"), "B": []byte("2")},
+			b:     map[string][]byte{"B": []byte("2"), "A": []byte("1")},
+			equal: true,
+		},
+		{
+			name:  "Different values",
+			a:     map[string][]byte{"A": []byte("1")},
+			b:     map[string][]byte{"A": []byte("2")},
+			equal: false,
+		},
+		{
+			name:  "Same bytes split differently",
+			a:     map[string][]byte{"AB": []byte("C")},
+			b:     map[string][]byte{"A": []byte("BC")},
+			equal: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.equal, k8sapi.ContentHash(tt.a) == k8sapi.ContentHash(tt.b))
+		})
+	}
+}
+
+func TestK8sContentHashAnnotation(t *testing.T) {
+	ctx := context.Background()
+	k := k8sapi.NewK8sClient(fake.NewSimpleClientset(), "test")
+
+	secret, err := k.CreateSecret(ctx, "test", map[string]string{"A": "1"})
+	require.Nil(t, err)
+	assert.Equal(t, k8sapi.ContentHash(secret.Data), secret.Annotations[k8sapi.AnnotationContentHash])
+
+	secret, err = k.UpdateSecret(ctx, "test", map[string]string{"A": "2"})
+	require.Nil(t, err)
+	assert.Equal(t, k8sapi.ContentHash(secret.Data), secret.Annotations[k8sapi.AnnotationContentHash])
+}
@@ -0,0 +1,282 @@
+package k8sapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/diff"
+	v1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// DefaultHistoryLimit is the number of revisions kept for each secret by default.
+	DefaultHistoryLimit = 10
+
+	// LabelRevisionOf is set on revision secrets with the name of the secret
+	// they belong to, shortened when it is longer than a label value allows.
+	LabelRevisionOf = "envsecret.ogticrd.io/revision-of"
+	// LabelRevision is set on revision secrets with their revision number.
+	LabelRevision = "envsecret.ogticrd.io/revision"
+	// AnnotationRecordedAt holds the time a revision was recorded, in RFC 3339 format.
+	AnnotationRecordedAt = "envsecret.ogticrd.io/recorded-at"
+	// AnnotationChangedKeys holds the redacted summary of keys changed by a revision.
+	AnnotationChangedKeys = "envsecret.ogticrd.io/changed-keys"
+)
+
+// Revision describes a recorded version of a secret.
+type Revision struct {
+	RecordedAt  time.Time // Time the revision was recorded.
+	Name        string    // Name of the secret holding the revision data.
+	ChangedKeys string    // Redacted summary of the keys changed by the revision.
+	Number      int       // Revision number, starting at 1.
+}
+
+// revisionHashLength is the number of hex digits of the hash ending shortened names.
+const revisionHashLength = 10
+
+// RevisionName returns the name of the secret holding the given revision.
+// Names that would be longer than a secret name allows are shortened, see
+// shortenName.
+//
+// Example usage:
+// name := RevisionName("my-secret", 3) // Output: my-secret-rev-3
+func RevisionName(secretName string, revision int) string {
+	suffix := fmt.Sprintf("-rev-%d", revision)
+	return shortenName(secretName, validation.DNS1123SubdomainMaxLength-len(suffix)) + suffix
+}
+
+// revisionOf returns the value of the LabelRevisionOf label of the revisions
+// of a secret. Secret names can be longer than label values, so long names are
+// shortened, see shortenName.
+func revisionOf(secretName string) string {
+	return shortenName(secretName, validation.LabelValueMaxLength)
+}
+
+// shortenName returns name when it has at most max characters. Otherwise it
+// returns the start of name followed by a hash of the whole name, so shortened
+// names of different secrets still differ.
+func shortenName(name string, max int) string {
+	if len(name) <= max {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	hash := hex.EncodeToString(sum[:])[:revisionHashLength]
+	// Names and label values must start and end with an alphanumeric character.
+	prefix := strings.TrimRight(name[:max-revisionHashLength-1], "-.")
+	return prefix + "-" + hash
+}
+
+// RecordRevision stores the current data of a secret as a new revision.
+//
+// The revision is saved as an immutable secret named NAME-rev-N, labeled with
+// the primary secret name and owned by it, so it is garbage collected when the
+// primary secret is deleted. Revisions beyond the limit are pruned, oldest
+// first.
+//
+// Parameters:
+// - ctx: Context for the API requests.
+// - secret: The primary secret as returned by the API server.
+// - limit: Maximum number of revisions to keep. Values lower than 1 disable pruning.
+//
+// Returns:
+// - The recorded revision.
+// - An error if the revision cannot be stored.
+//
+// Example usage:
+// secret, err := k8sClient.CreateSecret(ctx, "my-secret", secrets)
+// revision, err := k8sClient.RecordRevision(ctx, secret, k8sapi.DefaultHistoryLimit)
+func (c *K8sClient) RecordRevision(ctx context.Context, secret *v1.Secret, limit int) (*Revision, error) {
+	var revision *Revision
+	var revisions []Revision
+
+	// Another writer may record the same revision number first, in which case
+	// the revisions are listed again to pick the next free number.
+	err := c.withRetry(ctx, func(err error) bool {
+		return kerr.IsAlreadyExists(err) || IsTransient(err)
+	}, func() error {
+		var err error
+		revision, revisions, err = c.createRevision(ctx, secret)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	revisions = append(revisions, *revision)
+	if limit > 0 && len(revisions) > limit {
+		for _, old := range revisions[:len(revisions)-limit] {
+			err := c.withRetry(ctx, IsTransient, func() error {
+				err := c.client.CoreV1().Secrets(c.namespace).Delete(ctx, old.Name, metav1.DeleteOptions{})
+				if kerr.IsNotFound(err) {
+					return nil
+				}
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return revision, nil
+}
+
+// createRevision stores the secret data under the next revision number and
+// returns the new revision along with the revisions that existed before.
+func (c *K8sClient) createRevision(ctx context.Context, secret *v1.Secret) (*Revision, []Revision, error) {
+	revisions, err := c.ListRevisions(ctx, secret.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	number := 1
+	var previousData map[string][]byte
+	if len(revisions) > 0 {
+		latest := revisions[len(revisions)-1]
+		number = latest.Number + 1
+
+		previous, err := c.GetSecret(ctx, latest.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		previousData = previous.Data
+	}
+
+	revision := &Revision{
+		RecordedAt:  time.Now().UTC().Truncate(time.Second),
+		Name:        RevisionName(secret.Name, number),
+		ChangedKeys: diff.Compare(previousData, secret.Data).String(),
+		Number:      number,
+	}
+
+	immutable := true
+	_, err = c.client.CoreV1().Secrets(c.namespace).Create(
+		ctx,
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: revision.Name,
+				Labels: map[string]string{
+					LabelRevisionOf: revisionOf(secret.Name),
+					LabelRevision:   strconv.Itoa(number),
+				},
+				Annotations: map[string]string{
+					AnnotationRecordedAt:  revision.RecordedAt.Format(time.RFC3339),
+					AnnotationChangedKeys: revision.ChangedKeys,
+				},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: "v1",
+					Kind:       "Secret",
+					Name:       secret.Name,
+					UID:        secret.UID,
+				}},
+			},
+			Immutable: &immutable,
+			Type:      secret.Type,
+			Data:      secret.Data,
+		},
+		metav1.CreateOptions{},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return revision, revisions, nil
+}
+
+// ListRevisions returns the recorded revisions of a secret sorted by revision number.
+//
+// Parameters:
+// - ctx: Context for the API requests.
+// - secretName: Name of the primary Kubernetes secret.
+//
+// Returns:
+// - The recorded revisions, oldest first.
+// - An error if the revisions cannot be listed.
+//
+// Example usage:
+// revisions, err := k8sClient.ListRevisions(ctx, "my-secret")
+func (c *K8sClient) ListRevisions(ctx context.Context, secretName string) ([]Revision, error) {
+	list, err := c.client.CoreV1().Secrets(c.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", LabelRevisionOf, revisionOf(secretName)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]Revision, 0, len(list.Items))
+	for _, item := range list.Items {
+		number, err := strconv.Atoi(item.Labels[LabelRevision])
+		if err != nil {
+			return nil, fmt.Errorf("secret %s has an invalid %s label: %w", item.Name, LabelRevision, err)
+		}
+
+		// A missing or malformed timestamp leaves the zero time, which is
+		// still enough to list the revision.
+		recordedAt, _ := time.Parse(time.RFC3339, item.Annotations[AnnotationRecordedAt])
+
+		revisions = append(revisions, Revision{
+			RecordedAt:  recordedAt,
+			Name:        item.Name,
+			ChangedKeys: item.Annotations[AnnotationChangedKeys],
+			Number:      number,
+		})
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Number < revisions[j].Number
+	})
+
+	return revisions, nil
+}
+
+// Rollback restores the data of a secret from one of its recorded revisions.
+//
+// The restored data is recorded as a new revision, so a rollback can itself be
+// rolled back.
+//
+// Parameters:
+// - ctx: Context for the API requests.
+// - secretName: Name of the primary Kubernetes secret.
+// - revision: Number of the revision to restore.
+// - limit: Maximum number of revisions to keep after recording the rollback.
+//
+// Returns:
+// - The updated secret as returned by the API server.
+// - An error if the revision does not exist or the update fails.
+//
+// Example usage:
+// secret, err := k8sClient.Rollback(ctx, "my-secret", 2, k8sapi.DefaultHistoryLimit)
+func (c *K8sClient) Rollback(ctx context.Context, secretName string, revision int, limit int) (*v1.Secret, error) {
+	source, err := c.GetSecret(ctx, RevisionName(secretName, revision))
+	if err != nil {
+		return nil, fmt.Errorf("revision %d of secret %s not found: %w", revision, secretName, err)
+	}
+	if source.Labels[LabelRevisionOf] != revisionOf(secretName) {
+		return nil, fmt.Errorf("secret %s is not a revision of %s", source.Name, secretName)
+	}
+
+	secrets := make(map[string]string, len(source.Data))
+	for key, value := range source.Data {
+		secrets[key] = string(value)
+	}
+
+	secret, err := c.UpdateSecret(ctx, secretName, secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := c.RecordRevision(ctx, secret, limit); err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
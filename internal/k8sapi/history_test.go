@@ -0,0 +1,140 @@
+package k8sapi_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestK8sRecordRevision(t *testing.T) {
+	ctx := context.Background()
+	fakeClient := fake.NewSimpleClientset()
+
+	k := k8sapi.NewK8sClient(fakeClient, "test")
+
+	secret, err := k.CreateSecret(ctx, "test", map[string]string{"A": "1", "B": "2"})
+	require.Nil(t, err)
+
+	revision, err := k.RecordRevision(ctx, secret, 2)
+	require.Nil(t, err)
+	assert.Equal(t, 1, revision.Number)
+	assert.Equal(t, "test-rev-1", revision.Name)
+	assert.Equal(t, "+A +B", revision.ChangedKeys)
+
+	for i, value := range []string{"10", "20"} {
+		secret, err = k.UpdateSecret(ctx, "test", map[string]string{"A": value})
+		require.Nil(t, err)
+
+		revision, err = k.RecordRevision(ctx, secret, 2)
+		require.Nil(t, err)
+		assert.Equal(t, i+2, revision.Number)
+	}
+
+	revisions, err := k.ListRevisions(ctx, "test")
+	require.Nil(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, 2, revisions[0].Number)
+	assert.Equal(t, "~A -B", revisions[0].ChangedKeys)
+	assert.Equal(t, 3, revisions[1].Number)
+	assert.Equal(t, "~A", revisions[1].ChangedKeys)
+	assert.False(t, revisions[1].RecordedAt.IsZero())
+
+	_, err = k.GetSecret(ctx, "test-rev-1")
+	assert.True(t, kerr.IsNotFound(err), "oldest revision should be pruned")
+
+	stored, err := k.GetSecret(ctx, "test-rev-3")
+	require.Nil(t, err)
+	assert.True(t, *stored.Immutable)
+	assert.Equal(t, "test", stored.OwnerReferences[0].Name)
+}
+
+func TestK8sRollback(t *testing.T) {
+	ctx := context.Background()
+	fakeClient := fake.NewSimpleClientset()
+
+	k := k8sapi.NewK8sClient(fakeClient, "test")
+
+	secret, err := k.CreateSecret(ctx, "test", map[string]string{"A": "1"})
+	require.Nil(t, err)
+	_, err = k.RecordRevision(ctx, secret, k8sapi.DefaultHistoryLimit)
+	require.Nil(t, err)
+
+	secret, err = k.UpdateSecret(ctx, "test", map[string]string{"A": "bad"})
+	require.Nil(t, err)
+	_, err = k.RecordRevision(ctx, secret, k8sapi.DefaultHistoryLimit)
+	require.Nil(t, err)
+
+	t.Run("test Rollback restores the revision data", func(t *testing.T) {
+		secret, err := k.Rollback(ctx, "test", 1, k8sapi.DefaultHistoryLimit)
+		require.Nil(t, err)
+		assert.Equal(t, "1", string(secret.Data["A"]))
+
+		revisions, err := k.ListRevisions(ctx, "test")
+		require.Nil(t, err)
+		assert.Len(t, revisions, 3)
+	})
+	t.Run("test Rollback fails with unknown revision", func(t *testing.T) {
+		_, err := k.Rollback(ctx, "test", 42, k8sapi.DefaultHistoryLimit)
+		assert.NotNil(t, err)
+		assert.True(t, kerr.IsNotFound(err))
+	})
+}
+
+func TestK8sRecordRevisionLongName(t *testing.T) {
+	ctx := context.Background()
+	fakeClient := fake.NewSimpleClientset()
+	k := k8sapi.NewK8sClient(fakeClient, "test")
+
+	// The longest name a secret can have.
+	name := strings.Repeat("a", 62) + "." + strings.Repeat("b", 190)
+	require.Empty(t, validation.IsDNS1123Subdomain(name))
+
+	secret, err := k.CreateSecret(ctx, name, map[string]string{"A": "1"})
+	require.NoError(t, err)
+	revision, err := k.RecordRevision(ctx, secret, 0)
+	require.NoError(t, err)
+
+	assert.Empty(t, validation.IsDNS1123Subdomain(revision.Name))
+	stored, err := k.GetSecret(ctx, revision.Name)
+	require.NoError(t, err)
+	for key, value := range stored.Labels {
+		assert.Empty(t, validation.IsValidLabelValue(value), "label %s", key)
+	}
+
+	// Another long name sharing the same start gets its own revisions.
+	other, err := k.CreateSecret(ctx, name[:252]+"c", map[string]string{"A": "2"})
+	require.NoError(t, err)
+	otherRevision, err := k.RecordRevision(ctx, other, 0)
+	require.NoError(t, err)
+	assert.NotEqual(t, revision.Name, otherRevision.Name)
+
+	revisions, err := k.ListRevisions(ctx, name)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, revision.Name, revisions[0].Name)
+
+	secret, err = k.UpdateSecret(ctx, name, map[string]string{"A": "changed"})
+	require.NoError(t, err)
+	_, err = k.RecordRevision(ctx, secret, 0)
+	require.NoError(t, err)
+	secret, err = k.Rollback(ctx, name, 1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(secret.Data["A"]))
+}
+
+func TestRevisionName(t *testing.T) {
+	assert.Equal(t, "my-secret-rev-3", k8sapi.RevisionName("my-secret", 3))
+
+	long := strings.Repeat("a", 253)
+	first, second := k8sapi.RevisionName(long, 1), k8sapi.RevisionName(long[:252]+"b", 1)
+	assert.Len(t, first, validation.DNS1123SubdomainMaxLength)
+	assert.Empty(t, validation.IsDNS1123Subdomain(first))
+	assert.NotEqual(t, first, second)
+}
@@ -10,15 +10,20 @@ import (
 
 	"github.com/ogticrd/kubectl-envsecret/internal/utils"
 	v1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
 
 // K8sClient encapsulates a Kubernetes client and the namespace it operates within.
 type K8sClient struct {
-	client    kubernetes.Interface // Kubernetes client interface.
-	namespace string               // Namespace for the Kubernetes operations.
+	client      kubernetes.Interface   // Kubernetes client interface.
+	owner       *metav1.OwnerReference // Owner added to the written secrets, if any.
+	namespace   string                 // Namespace for the Kubernetes operations.
+	sourceFiles []string               // Paths of the files written secrets come from.
+	backoff     wait.Backoff           // Backoff between attempts of write operations.
 }
 
 // K8sConfig holds the configuration needed to create a Kubernetes client.
@@ -43,6 +48,7 @@ func NewK8sClient(client kubernetes.Interface, namespace string) *K8sClient {
 	return &K8sClient{
 		client:    client,
 		namespace: namespace,
+		backoff:   DefaultBackoff,
 	}
 }
 
@@ -87,37 +93,231 @@ func NewK8sClientFromConfig(config *K8sConfig) (*K8sClient, error) {
 // CreateSecret creates a new Kubernetes secret with the provided name and data.
 //
 // Parameters:
+// - ctx: Context for the API requests.
 // - secretName: Name of the Kubernetes secret.
 // - secrets: Map containing the secret data as key-value pairs.
 //
 // Returns:
+// - The created secret as returned by the API server.
 // - An error if the secret creation fails.
 //
 // Example usage:
 // secrets := map[string]string{"username": "admin", "password": "secret"}
-// err := k8sClient.CreateSecret("my-secret", secrets)
-func (c *K8sClient) CreateSecret(secretName string, secrets map[string]string) error {
+// secret, err := k8sClient.CreateSecret(ctx, "my-secret", secrets)
+func (c *K8sClient) CreateSecret(ctx context.Context, secretName string, secrets map[string]string) (*v1.Secret, error) {
 	if len(secrets) == 0 {
-		return fmt.Errorf("no secrets provided")
+		return nil, fmt.Errorf("no secrets provided")
 	}
 
 	const secretType v1.SecretType = "Opaque"
 
-	_, err := c.client.CoreV1().Secrets(c.namespace).Create(
-		context.TODO(),
-		&v1.Secret{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: secretName,
-			},
-			Type: secretType,
-			Data: utils.MapStringToBytes(secrets),
+	return c.CreateSecretFromObject(ctx, &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: secretName,
 		},
-		metav1.CreateOptions{},
-	)
+		Type: secretType,
+		Data: utils.MapStringToBytes(secrets),
+	})
+}
+
+// CreateSecretFromObject creates the provided Kubernetes secret in the client namespace.
+//
+// A request failing with a transient error may still have created the
+// secret, so when a retry finds it already existing with the same data, it is
+// returned as created.
+//
+// Parameters:
+// - ctx: Context for the API requests.
+// - secret: The secret to create. Its namespace is ignored.
+//
+// Returns:
+// - The created secret as returned by the API server.
+// - An error if the secret creation fails.
+//
+// Example usage:
+// secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "my-secret"}, Data: data}
+// created, err := k8sClient.CreateSecretFromObject(ctx, secret)
+func (c *K8sClient) CreateSecretFromObject(ctx context.Context, secret *v1.Secret) (*v1.Secret, error) {
+	secret = secret.DeepCopy()
+	secret.Namespace = c.namespace
+	if err := c.stampOwner(secret); err != nil {
+		return nil, err
+	}
+	stampContentHash(secret)
+	c.stampManaged(secret)
+
+	var created *v1.Secret
+	retried := false
+	err := c.withRetry(ctx, IsTransient, func() error {
+		var err error
+		created, err = c.client.CoreV1().Secrets(c.namespace).Create(ctx, secret, metav1.CreateOptions{})
+		switch {
+		case IsTransient(err):
+			retried = true
+		case kerr.IsAlreadyExists(err) && retried:
+			// The attempt that failed with a transient error may have
+			// created the secret anyway.
+			existing, getErr := c.GetSecret(ctx, secret.Name)
+			if getErr == nil && ContentHash(existing.Data) == ContentHash(secret.Data) {
+				created = existing
+				return nil
+			}
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// GetSecret retrieves the Kubernetes secret with the provided name.
+//
+// Parameters:
+// - ctx: Context for the API requests.
+// - secretName: Name of the Kubernetes secret.
+//
+// Returns:
+// - The secret as returned by the API server.
+// - An error if the secret cannot be retrieved.
+//
+// Example usage:
+// secret, err := k8sClient.GetSecret(ctx, "my-secret")
+func (c *K8sClient) GetSecret(ctx context.Context, secretName string) (*v1.Secret, error) {
+	return c.client.CoreV1().Secrets(c.namespace).Get(ctx, secretName, metav1.GetOptions{})
+}
+
+// UpdateSecret replaces the data of an existing Kubernetes secret. The
+// previous values of keys being rotated are kept, see KeepPreviousValues.
+//
+// Parameters:
+// - ctx: Context for the API requests.
+// - secretName: Name of the Kubernetes secret.
+// - secrets: Map containing the new secret data as key-value pairs.
+//
+// Returns:
+// - The updated secret as returned by the API server.
+// - An error if the secret does not exist or the update fails.
+//
+// Example usage:
+// secrets := map[string]string{"username": "admin", "password": "new-secret"}
+// secret, err := k8sClient.UpdateSecret(ctx, "my-secret", secrets)
+func (c *K8sClient) UpdateSecret(ctx context.Context, secretName string, secrets map[string]string) (*v1.Secret, error) {
+	if len(secrets) == 0 {
+		return nil, fmt.Errorf("no secrets provided")
+	}
+
+	return c.updateSecretWith(ctx, secretName, func(secret *v1.Secret) error {
+		data := utils.MapStringToBytes(secrets)
+		KeepPreviousValues(secret, data)
+		secret.Data = data
+		return nil
+	})
+}
+
+// ReplaceSecretData replaces the data of a secret, only if it has not changed
+// since it was read. Unlike UpdateSecret, a conflict is returned rather than
+// retried and the data is written as given: previous values of keys being
+// rotated that data does not hold are removed, ending those rotations.
+//
+// Parameters:
+// - ctx: Context for the API requests.
+// - secret: The secret as returned by the API server.
+// - data: The new data of the secret.
+//
+// Returns:
+// - The updated secret as returned by the API server.
+// - A conflict error if the secret was modified since it was read.
+// - An error if the update fails.
+//
+// Example usage:
+// secret, err := k8sClient.GetSecret(ctx, "my-secret")
+// updated, err := k8sClient.ReplaceSecretData(ctx, secret, edited)
+func (c *K8sClient) ReplaceSecretData(ctx context.Context, secret *v1.Secret, data map[string][]byte) (*v1.Secret, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no secrets provided")
+	}
+
+	secret = secret.DeepCopy()
+	secret.Data = data
+	secret.StringData = nil
+	var rotating []string
+	for _, key := range RotatingKeys(secret) {
+		if _, ok := data[PreviousKey(key)]; ok {
+			rotating = append(rotating, key)
+		}
+	}
+	setRotatingKeys(secret, rotating)
+	if err := c.stampOwner(secret); err != nil {
+		return nil, err
+	}
+	stampContentHash(secret)
+	c.stampManaged(secret)
+
+	var updated *v1.Secret
+	err := c.withRetry(ctx, IsTransient, func() error {
+		var err error
+		updated, err = c.client.CoreV1().Secrets(c.namespace).Update(ctx, secret, metav1.UpdateOptions{})
+		return err
+	})
 	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// updateSecretWith applies mutate to the latest version of a secret and
+// writes it back, stamping the owner, content hash and managed-by metadata.
+//
+// The secret is re-read on every attempt, so updates racing with other
+// writers are re-applied on top of the latest version.
+func (c *K8sClient) updateSecretWith(ctx context.Context, secretName string, mutate func(secret *v1.Secret) error) (*v1.Secret, error) {
+	var secret *v1.Secret
+	err := c.withRetry(ctx, isConflictOrTransient, func() error {
+		current, err := c.GetSecret(ctx, secretName)
+		if err != nil {
+			return err
+		}
+
+		if err := mutate(current); err != nil {
+			return err
+		}
+		current.StringData = nil
+		if err := c.stampOwner(current); err != nil {
+			return err
+		}
+		stampContentHash(current)
+		c.stampManaged(current)
+
+		secret, err = c.client.CoreV1().Secrets(c.namespace).Update(ctx, current, metav1.UpdateOptions{})
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("\nSecret %s created successfully.", secretName)
-	return nil
+	return secret, nil
+}
+
+// DeleteSecret deletes a Kubernetes secret, only if it has not changed since
+// it was read. Revisions of the secret are garbage collected along with it.
+//
+// Parameters:
+// - ctx: Context for the API requests.
+// - secret: The secret as returned by the API server.
+//
+// Returns:
+// - A conflict error if the secret was modified or replaced since it was read.
+// - An error if the deletion fails.
+//
+// Example usage:
+// secret, err := k8sClient.GetSecret(ctx, "my-secret")
+// err = k8sClient.DeleteSecret(ctx, secret)
+func (c *K8sClient) DeleteSecret(ctx context.Context, secret *v1.Secret) error {
+	preconditions := metav1.Preconditions{UID: &secret.UID, ResourceVersion: &secret.ResourceVersion}
+	return c.withRetry(ctx, IsTransient, func() error {
+		return c.client.CoreV1().Secrets(c.namespace).Delete(ctx, secret.Name, metav1.DeleteOptions{Preconditions: &preconditions})
+	})
 }
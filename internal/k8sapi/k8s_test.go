@@ -1,28 +1,91 @@
 package k8sapi_test
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
 	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestK8sCreateSecret(t *testing.T) {
+	ctx := context.Background()
 	fakeClient := fake.NewSimpleClientset()
 
 	k := k8sapi.NewK8sClient(fakeClient, "test")
 
 	t.Run("test CreateSecret returns expected results", func(t *testing.T) {
-		err := k.CreateSecret("test", mockSecretData())
+		secret, err := k.CreateSecret(ctx, "test", mockSecretData())
 		assert.Nil(t, err)
+		assert.Equal(t, "line", string(secret.Data["bar"]))
 	})
 	t.Run("test CreateSecret fails with alreadyExists", func(t *testing.T) {
-		err := k.CreateSecret("test", mockSecretData())
+		_, err := k.CreateSecret(ctx, "test", mockSecretData())
 		assert.NotNil(t, err)
 		assert.True(t, kerr.IsAlreadyExists(err))
 	})
+	t.Run("test CreateSecret fails without data", func(t *testing.T) {
+		_, err := k.CreateSecret(ctx, "empty", map[string]string{})
+		assert.EqualError(t, err, "no secrets provided")
+	})
+}
+
+func TestK8sCreateSecretForbidden(t *testing.T) {
+	ctx := context.Background()
+	fakeClient := fake.NewSimpleClientset()
+	calls := failTimes(fakeClient, "create", 1, kerr.NewForbidden(secretsResource, "test", errors.New("denied by admission webhook")))
+
+	k := k8sapi.NewK8sClient(fakeClient, "test").WithBackoff(fastBackoff)
+
+	_, err := k.CreateSecret(ctx, "test", mockSecretData())
+	assert.True(t, kerr.IsForbidden(err))
+	assert.Equal(t, 1, *calls, "forbidden requests must not be retried")
+
+	_, err = k.GetSecret(ctx, "test")
+	assert.True(t, kerr.IsNotFound(err))
+}
+
+func TestK8sCreateSecretFromObject(t *testing.T) {
+	ctx := context.Background()
+	k := k8sapi.NewK8sClient(fake.NewSimpleClientset(), "test")
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "other"},
+		Data:       map[string][]byte{"foo": []byte("bar")},
+	}
+	created, err := k.CreateSecretFromObject(ctx, secret)
+	require.NoError(t, err)
+	assert.Equal(t, "test", created.Namespace)
+	assert.Equal(t, "other", secret.Namespace, "the object passed in must not be modified")
+	assert.Equal(t, "kubectl-envsecret", created.Labels["app.kubernetes.io/managed-by"])
+	assert.NotEmpty(t, created.Annotations[k8sapi.AnnotationContentHash])
+}
+
+func TestK8sUpdateSecret(t *testing.T) {
+	ctx := context.Background()
+	fakeClient := fake.NewSimpleClientset()
+
+	k := k8sapi.NewK8sClient(fakeClient, "test")
+
+	t.Run("test UpdateSecret fails with notFound", func(t *testing.T) {
+		_, err := k.UpdateSecret(ctx, "test", mockSecretData())
+		assert.NotNil(t, err)
+		assert.True(t, kerr.IsNotFound(err))
+	})
+	t.Run("test UpdateSecret replaces the secret data", func(t *testing.T) {
+		_, err := k.CreateSecret(ctx, "test", mockSecretData())
+		assert.Nil(t, err)
+
+		secret, err := k.UpdateSecret(ctx, "test", map[string]string{"baz": "new"})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string][]byte{"baz": []byte("new")}, secret.Data)
+	})
 }
 
 func mockSecretData() map[string]string {
@@ -34,3 +97,48 @@ func mockSecretData() map[string]string {
 	secret["bar"] = "line"
 	return secret
 }
+
+func TestK8sDeleteSecret(t *testing.T) {
+	ctx := context.Background()
+	k := k8sapi.NewK8sClient(fake.NewSimpleClientset(), "test")
+
+	secret, err := k.CreateSecret(ctx, "test", mockSecretData())
+	assert.Nil(t, err)
+
+	assert.Nil(t, k.DeleteSecret(ctx, secret))
+	_, err = k.GetSecret(ctx, "test")
+	assert.True(t, kerr.IsNotFound(err))
+
+	err = k.DeleteSecret(ctx, secret)
+	assert.True(t, kerr.IsNotFound(err))
+}
+
+func TestK8sReplaceSecretData(t *testing.T) {
+	ctx := context.Background()
+	fakeClient := fake.NewSimpleClientset(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Namespace:   "test",
+			Annotations: map[string]string{k8sapi.AnnotationRotatingKeys: "API_TOKEN"},
+		},
+		Data: map[string][]byte{"API_TOKEN": []byte("new"), "API_TOKEN_PREVIOUS": []byte("old")},
+	})
+	k := k8sapi.NewK8sClient(fakeClient, "test").WithBackoff(fastBackoff)
+
+	secret, err := k.GetSecret(ctx, "test")
+	require.Nil(t, err)
+
+	t.Run("test ReplaceSecretData does not retry conflicts", func(t *testing.T) {
+		calls := failTimes(fakeClient, "update", 1, kerr.NewConflict(secretsResource, "test", errors.New("the object has been modified")))
+
+		_, err := k.ReplaceSecretData(ctx, secret, map[string][]byte{"API_TOKEN": []byte("edited")})
+		assert.True(t, kerr.IsConflict(err))
+		assert.Equal(t, 1, *calls)
+	})
+	t.Run("test ReplaceSecretData keeps removed previous values out", func(t *testing.T) {
+		updated, err := k.ReplaceSecretData(ctx, secret, map[string][]byte{"API_TOKEN": []byte("edited")})
+		require.Nil(t, err)
+		assert.Equal(t, map[string][]byte{"API_TOKEN": []byte("edited")}, updated.Data)
+		assert.Empty(t, k8sapi.RotatingKeys(updated))
+	})
+}
@@ -0,0 +1,122 @@
+package k8sapi
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+const (
+	// LabelManagedBy is set on the secrets written by kubectl-envsecret, following
+	// the recommended labels of Kubernetes.
+	LabelManagedBy = "app.kubernetes.io/managed-by"
+	// ManagedByValue is the value of LabelManagedBy on secrets written by kubectl-envsecret.
+	ManagedByValue = "kubectl-envsecret"
+	// AnnotationSourceFiles holds the comma separated paths of the files a secret was written from.
+	AnnotationSourceFiles = "envsecret.ogticrd.io/source-files"
+	// AnnotationUpdatedAt holds the last time kubectl-envsecret wrote a secret, in RFC 3339 format.
+	AnnotationUpdatedAt = "envsecret.ogticrd.io/updated-at"
+)
+
+// WithSourceFiles sets the paths of the files the secrets written by the
+// client come from. They are recorded in the AnnotationSourceFiles annotation.
+//
+// Parameters:
+// - paths: Paths of the source files. When empty, writes keep the annotation as it is.
+//
+// Returns:
+// - The same K8sClient instance, to allow chaining.
+//
+// Example usage:
+// k8sClient := NewK8sClient(client, "default").WithSourceFiles(".env", ".env.production")
+func (c *K8sClient) WithSourceFiles(paths ...string) *K8sClient {
+	c.sourceFiles = paths
+	return c
+}
+
+// stampManaged marks the secret as managed by kubectl-envsecret and records
+// when and from which files it was written.
+func (c *K8sClient) stampManaged(secret *v1.Secret) {
+	if secret.Labels == nil {
+		secret.Labels = make(map[string]string)
+	}
+	secret.Labels[LabelManagedBy] = ManagedByValue
+
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	secret.Annotations[AnnotationUpdatedAt] = time.Now().UTC().Format(time.RFC3339)
+	if len(c.sourceFiles) > 0 {
+		secret.Annotations[AnnotationSourceFiles] = strings.Join(c.sourceFiles, ",")
+	}
+}
+
+// IsManaged reports whether the secret was written by kubectl-envsecret.
+//
+// Example usage:
+//
+//	if !IsManaged(secret) {
+//	    return fmt.Errorf("secret %s is not managed by kubectl-envsecret", secret.Name)
+//	}
+func IsManaged(secret *v1.Secret) bool {
+	return secret.Labels[LabelManagedBy] == ManagedByValue
+}
+
+// SourceFiles returns the paths of the files the secret was written from, as
+// recorded in its annotations.
+//
+// Example usage:
+// files := SourceFiles(secret) // Output: [.env .env.production]
+func SourceFiles(secret *v1.Secret) []string {
+	value := secret.Annotations[AnnotationSourceFiles]
+	if len(value) == 0 {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// ListManagedSecrets returns the secrets written by kubectl-envsecret in the
+// client namespace, or in every namespace if the client namespace is empty.
+// Revisions are left out.
+//
+// Parameters:
+// - ctx: Context for the API requests.
+//
+// Returns:
+// - The secrets, sorted by namespace and name.
+// - An error if the secrets cannot be listed.
+//
+// Example usage:
+// secrets, err := NewK8sClient(client, metav1.NamespaceAll).ListManagedSecrets(ctx)
+func (c *K8sClient) ListManagedSecrets(ctx context.Context) ([]v1.Secret, error) {
+	managed, err := labels.NewRequirement(LabelManagedBy, selection.Equals, []string{ManagedByValue})
+	if err != nil {
+		return nil, err
+	}
+	notRevision, err := labels.NewRequirement(LabelRevisionOf, selection.DoesNotExist, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := c.client.CoreV1().Secrets(c.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.NewSelector().Add(*managed, *notRevision).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := list.Items
+	sort.Slice(secrets, func(i, j int) bool {
+		if secrets[i].Namespace != secrets[j].Namespace {
+			return secrets[i].Namespace < secrets[j].Namespace
+		}
+		return secrets[i].Name < secrets[j].Name
+	})
+	return secrets, nil
+}
@@ -0,0 +1,64 @@
+package k8sapi_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestK8sStampManaged(t *testing.T) {
+	ctx := context.Background()
+	k := k8sapi.NewK8sClient(fake.NewSimpleClientset(), "test").WithSourceFiles(".env", ".env.production")
+
+	secret, err := k.CreateSecret(ctx, "test", map[string]string{"A": "1"})
+	require.NoError(t, err)
+	assert.True(t, k8sapi.IsManaged(secret))
+	assert.Equal(t, []string{".env", ".env.production"}, k8sapi.SourceFiles(secret))
+	updatedAt, err := time.Parse(time.RFC3339, secret.Annotations[k8sapi.AnnotationUpdatedAt])
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), updatedAt, time.Minute)
+
+	// Writes without source files keep the recorded ones.
+	secret, err = k.WithSourceFiles().UpdateSecret(ctx, "test", map[string]string{"A": "2"})
+	require.NoError(t, err)
+	assert.True(t, k8sapi.IsManaged(secret))
+	assert.Equal(t, []string{".env", ".env.production"}, k8sapi.SourceFiles(secret))
+}
+
+func TestK8sListManagedSecrets(t *testing.T) {
+	ctx := context.Background()
+	managed := map[string]string{k8sapi.LabelManagedBy: k8sapi.ManagedByValue}
+	client := fake.NewSimpleClientset(
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "production", Labels: managed}},
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "production", Labels: managed}},
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "staging", Labels: managed}},
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "production"}},
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "api-rev-1", Namespace: "production", Labels: map[string]string{
+			k8sapi.LabelManagedBy:  k8sapi.ManagedByValue,
+			k8sapi.LabelRevisionOf: "api",
+		}}},
+	)
+
+	names := func(secrets []v1.Secret) []string {
+		var result []string
+		for _, secret := range secrets {
+			result = append(result, secret.Namespace+"/"+secret.Name)
+		}
+		return result
+	}
+
+	secrets, err := k8sapi.NewK8sClient(client, "production").ListManagedSecrets(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"production/api", "production/web"}, names(secrets))
+
+	secrets, err = k8sapi.NewK8sClient(client, metav1.NamespaceAll).ListManagedSecrets(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"production/api", "production/web", "staging/api"}, names(secrets))
+}
@@ -0,0 +1,175 @@
+package k8sapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ownerKinds maps the names accepted for the kinds of owners, as kubectl
+// accepts them, to their kind.
+var ownerKinds = map[string]string{
+	"deployment":   "Deployment",
+	"deployments":  "Deployment",
+	"deploy":       "Deployment",
+	"statefulset":  "StatefulSet",
+	"statefulsets": "StatefulSet",
+	"sts":          "StatefulSet",
+	"daemonset":    "DaemonSet",
+	"daemonsets":   "DaemonSet",
+	"ds":           "DaemonSet",
+	"replicaset":   "ReplicaSet",
+	"replicasets":  "ReplicaSet",
+	"rs":           "ReplicaSet",
+	"job":          "Job",
+	"jobs":         "Job",
+	"cronjob":      "CronJob",
+	"cronjobs":     "CronJob",
+	"cj":           "CronJob",
+	"pod":          "Pod",
+	"pods":         "Pod",
+	"po":           "Pod",
+}
+
+// ResolveOwner looks up a workload in the client namespace and returns an
+// owner reference to it, so that secrets owned by it are garbage collected
+// when it is deleted.
+//
+// Owner references cannot cross namespaces, so the owner must be in the
+// namespace of the secret.
+//
+// Parameters:
+// - ctx: Context for the API requests.
+// - owner: The owner as KIND/NAME, e.g. deployment/api. Kinds are
+// Deployments, StatefulSets, DaemonSets, ReplicaSets, Jobs, CronJobs and
+// Pods, with the names and short names kubectl accepts.
+// - controller: Whether the owner is the managing controller of the secret.
+//
+// Returns:
+// - The owner reference, with the current UID of the owner.
+// - An error if the owner is malformed, of an unsupported kind or not found in the namespace.
+//
+// Example usage:
+// ref, err := k8sClient.ResolveOwner(ctx, "deployment/api", false)
+func (c *K8sClient) ResolveOwner(ctx context.Context, owner string, controller bool) (*metav1.OwnerReference, error) {
+	resource, name, ok := strings.Cut(owner, "/")
+	if !ok || len(resource) == 0 || len(name) == 0 || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("invalid owner %q, must be KIND/NAME, e.g. deployment/api", owner)
+	}
+	kind, ok := ownerKinds[strings.ToLower(resource)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported owner kind %q, must be one of: deployment, statefulset, daemonset, replicaset, job, cronjob, pod", resource)
+	}
+
+	var meta metav1.Object
+	var apiVersion string
+	var err error
+	switch kind {
+	case "Deployment":
+		apiVersion = "apps/v1"
+		meta, err = c.client.AppsV1().Deployments(c.namespace).Get(ctx, name, metav1.GetOptions{})
+	case "StatefulSet":
+		apiVersion = "apps/v1"
+		meta, err = c.client.AppsV1().StatefulSets(c.namespace).Get(ctx, name, metav1.GetOptions{})
+	case "DaemonSet":
+		apiVersion = "apps/v1"
+		meta, err = c.client.AppsV1().DaemonSets(c.namespace).Get(ctx, name, metav1.GetOptions{})
+	case "ReplicaSet":
+		apiVersion = "apps/v1"
+		meta, err = c.client.AppsV1().ReplicaSets(c.namespace).Get(ctx, name, metav1.GetOptions{})
+	case "Job":
+		apiVersion = "batch/v1"
+		meta, err = c.client.BatchV1().Jobs(c.namespace).Get(ctx, name, metav1.GetOptions{})
+	case "CronJob":
+		apiVersion = "batch/v1"
+		meta, err = c.client.BatchV1().CronJobs(c.namespace).Get(ctx, name, metav1.GetOptions{})
+	case "Pod":
+		apiVersion = "v1"
+		meta, err = c.client.CoreV1().Pods(c.namespace).Get(ctx, name, metav1.GetOptions{})
+	}
+	if kerr.IsNotFound(err) {
+		return nil, fmt.Errorf("owner %s not found in namespace %s, owners must be in the same namespace as the secret", owner, c.namespace)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if meta.GetNamespace() != c.namespace {
+		return nil, fmt.Errorf("owner %s is in namespace %s, owners must be in namespace %s of the secret", owner, meta.GetNamespace(), c.namespace)
+	}
+
+	return &metav1.OwnerReference{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Name:       meta.GetName(),
+		UID:        meta.GetUID(),
+		Controller: &controller,
+	}, nil
+}
+
+// WithOwner sets the owner of the secrets written by the client. Its
+// reference is added to the ownerReferences of the secrets when they are
+// created or updated.
+//
+// Parameters:
+// - owner: The owner reference, as returned by ResolveOwner. Nil writes secrets without adding an owner.
+//
+// Returns:
+// - The same K8sClient instance, to allow chaining.
+//
+// Example usage:
+// ref, err := k8sClient.ResolveOwner(ctx, "deployment/api", false)
+// k8sClient.WithOwner(ref)
+func (c *K8sClient) WithOwner(owner *metav1.OwnerReference) *K8sClient {
+	c.owner = owner
+	return c
+}
+
+// stampOwner adds the owner of the client to the owner references of the
+// secret, replacing an older reference to the same owner.
+func (c *K8sClient) stampOwner(secret *v1.Secret) error {
+	if c.owner == nil {
+		return nil
+	}
+
+	refs := make([]metav1.OwnerReference, 0, len(secret.OwnerReferences)+1)
+	for _, ref := range secret.OwnerReferences {
+		if ref.UID == c.owner.UID {
+			continue
+		}
+		// The API server rejects objects with more than one controller.
+		if isController(ref) && isController(*c.owner) {
+			return fmt.Errorf("secret %s is already controlled by %s/%s", secret.Name, ref.Kind, ref.Name)
+		}
+		refs = append(refs, ref)
+	}
+	secret.OwnerReferences = append(refs, *c.owner)
+	return nil
+}
+
+// HasOwner reports whether the secret already carries the owner reference,
+// marked as the controller or not as in owner. A nil owner is always carried.
+//
+// Example usage:
+// if !k8sapi.HasOwner(existing, owner) {
+// // update the secret to add the owner
+// }
+func HasOwner(secret *v1.Secret, owner *metav1.OwnerReference) bool {
+	if owner == nil {
+		return true
+	}
+	for _, ref := range secret.OwnerReferences {
+		if ref.UID == owner.UID && isController(ref) == isController(*owner) {
+			return true
+		}
+	}
+	return false
+}
+
+// isController reports whether an owner reference points to the managing controller.
+func isController(ref metav1.OwnerReference) bool {
+	return ref.Controller != nil && *ref.Controller
+}
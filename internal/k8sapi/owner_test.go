@@ -0,0 +1,84 @@
+package k8sapi_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestK8sResolveOwner(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "test", UID: "deploy-uid"}},
+		&batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{Name: "report", Namespace: "test", UID: "cron-uid"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "other", UID: "web-uid"}},
+	)
+	k := k8sapi.NewK8sClient(client, "test")
+
+	ref, err := k.ResolveOwner(ctx, "deployment/api", false)
+	require.NoError(t, err)
+	assert.Equal(t, "apps/v1", ref.APIVersion)
+	assert.Equal(t, "Deployment", ref.Kind)
+	assert.Equal(t, "api", ref.Name)
+	assert.Equal(t, "deploy-uid", string(ref.UID))
+	assert.False(t, *ref.Controller)
+
+	ref, err = k.ResolveOwner(ctx, "cj/report", true)
+	require.NoError(t, err)
+	assert.Equal(t, "batch/v1", ref.APIVersion)
+	assert.Equal(t, "CronJob", ref.Kind)
+	assert.True(t, *ref.Controller)
+
+	_, err = k.ResolveOwner(ctx, "deployment/web", false)
+	assert.ErrorContains(t, err, "owner deployment/web not found in namespace test, owners must be in the same namespace as the secret")
+
+	_, err = k.ResolveOwner(ctx, "api", false)
+	assert.ErrorContains(t, err, "invalid owner")
+	_, err = k.ResolveOwner(ctx, "service/api", false)
+	assert.ErrorContains(t, err, "unsupported owner kind")
+}
+
+func TestK8sWithOwner(t *testing.T) {
+	ctx := context.Background()
+	controller := true
+	other := metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "other", UID: "other-uid", Controller: &controller}
+	client := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "test", UID: "deploy-uid"}},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "controlled", Namespace: "test", OwnerReferences: []metav1.OwnerReference{other}},
+			Data:       map[string][]byte{"A": []byte("1")},
+		},
+	)
+	k := k8sapi.NewK8sClient(client, "test")
+
+	ref, err := k.ResolveOwner(ctx, "deployment/api", false)
+	require.NoError(t, err)
+	k.WithOwner(ref)
+
+	secret, err := k.CreateSecret(ctx, "api", map[string]string{"A": "1"})
+	require.NoError(t, err)
+	assert.Equal(t, []metav1.OwnerReference{*ref}, secret.OwnerReferences)
+
+	// Updates keep a single reference to the owner.
+	secret, err = k.UpdateSecret(ctx, "api", map[string]string{"A": "2"})
+	require.NoError(t, err)
+	assert.Equal(t, []metav1.OwnerReference{*ref}, secret.OwnerReferences)
+
+	// Owners that are not controllers are added next to existing controllers.
+	secret, err = k.UpdateSecret(ctx, "controlled", map[string]string{"A": "2"})
+	require.NoError(t, err)
+	assert.Equal(t, []metav1.OwnerReference{other, *ref}, secret.OwnerReferences)
+
+	ref, err = k.ResolveOwner(ctx, "deployment/api", true)
+	require.NoError(t, err)
+	_, err = k.WithOwner(ref).UpdateSecret(ctx, "controlled", map[string]string{"A": "3"})
+	assert.ErrorContains(t, err, "secret controlled is already controlled by Deployment/other")
+}
@@ -0,0 +1,105 @@
+package k8sapi
+
+import (
+	"context"
+	"time"
+
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// DefaultRetryAttempts is the number of attempts made for write operations by default.
+const DefaultRetryAttempts = 5
+
+// DefaultBackoff is the backoff used between attempts of write operations.
+//
+// It waits 200ms before the second attempt and doubles the wait after every
+// failure, up to 5s, with some jitter so concurrent writers spread out.
+var DefaultBackoff = wait.Backoff{
+	Steps:    DefaultRetryAttempts,
+	Duration: 200 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.1,
+	Cap:      5 * time.Second,
+}
+
+// WithRetryAttempts sets the number of attempts made for write operations.
+//
+// Parameters:
+// - attempts: Number of attempts. Values lower than 1 are treated as 1, which disables retries.
+//
+// Returns:
+// - The same K8sClient instance, to allow chaining.
+//
+// Example usage:
+// k8sClient := NewK8sClient(client, "default").WithRetryAttempts(3)
+func (c *K8sClient) WithRetryAttempts(attempts int) *K8sClient {
+	if attempts < 1 {
+		attempts = 1
+	}
+	c.backoff.Steps = attempts
+	return c
+}
+
+// WithBackoff sets the backoff used between attempts of write operations.
+//
+// Parameters:
+// - backoff: The backoff. Its Steps field is the number of attempts.
+//
+// Returns:
+// - The same K8sClient instance, to allow chaining.
+//
+// Example usage:
+// k8sClient := NewK8sClient(client, "default").WithBackoff(wait.Backoff{Steps: 3, Duration: time.Second, Factor: 2})
+func (c *K8sClient) WithBackoff(backoff wait.Backoff) *K8sClient {
+	c.backoff = backoff
+	return c
+}
+
+// IsTransient reports whether an API error is likely to go away when the
+// request is retried: throttling (429), server errors (5xx), timeouts and
+// dropped connections.
+//
+// Example usage:
+// if IsTransient(err) {
+// // retry the request
+// }
+func IsTransient(err error) bool {
+	return kerr.IsTooManyRequests(err) ||
+		kerr.IsServerTimeout(err) ||
+		kerr.IsTimeout(err) ||
+		kerr.IsInternalError(err) ||
+		kerr.IsServiceUnavailable(err) ||
+		kerr.IsUnexpectedServerError(err) ||
+		utilnet.IsConnectionReset(err) ||
+		utilnet.IsProbableEOF(err)
+}
+
+// isConflictOrTransient reports whether a read-modify-write operation should
+// be retried from scratch.
+func isConflictOrTransient(err error) bool {
+	return kerr.IsConflict(err) || IsTransient(err)
+}
+
+// withRetry runs fn until it succeeds, fails with an error that is not
+// retriable, the attempts are exhausted or the context is done, waiting with
+// exponential backoff between attempts. The last error of fn is returned.
+func (c *K8sClient) withRetry(ctx context.Context, retriable func(error) bool, fn func() error) error {
+	var lastErr error
+	err := wait.ExponentialBackoffWithContext(ctx, c.backoff, func(context.Context) (bool, error) {
+		lastErr = fn()
+		switch {
+		case lastErr == nil:
+			return true, nil
+		case retriable(lastErr):
+			return false, nil
+		default:
+			return false, lastErr
+		}
+	})
+	if wait.Interrupted(err) && lastErr != nil {
+		return lastErr
+	}
+	return err
+}
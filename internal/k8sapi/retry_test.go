@@ -0,0 +1,198 @@
+package k8sapi_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var secretsResource = schema.GroupResource{Resource: "secrets"}
+
+// fastBackoff keeps tests quick while still exercising the retry loop.
+var fastBackoff = wait.Backoff{Steps: 3, Duration: time.Millisecond, Factor: 1}
+
+// failTimes makes the first n calls of verb on secrets fail with err.
+func failTimes(client *fake.Clientset, verb string, n int, err error) *int {
+	calls := 0
+	client.PrependReactor(verb, "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		calls++
+		if calls <= n {
+			return true, nil, err
+		}
+		return false, nil, nil
+	})
+	return &calls
+}
+
+// createdThenTimeout makes the first create of a secret store it but fail
+// with a timeout, as when the response of the API server is lost.
+func createdThenTimeout(client *fake.Clientset) {
+	calls := 0
+	client.PrependReactor("create", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		calls++
+		if calls > 1 {
+			return false, nil, nil
+		}
+		object := action.(k8stesting.CreateAction).GetObject()
+		if err := client.Tracker().Create(action.GetResource(), object, action.GetNamespace()); err != nil {
+			return true, nil, err
+		}
+		return true, nil, kerr.NewServerTimeout(secretsResource, "create", 1)
+	})
+}
+
+func TestK8sUpdateSecretRetries(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		err           error
+		name          string
+		failures      int
+		expectedCalls int
+		expectSuccess bool
+	}{
+		{
+			name:          "Conflict is retried",
+			err:           kerr.NewConflict(secretsResource, "test", errors.New("the object has been modified")),
+			failures:      2,
+			expectedCalls: 3,
+			expectSuccess: true,
+		},
+		{
+			name:          "Too many requests is retried",
+			err:           kerr.NewTooManyRequests("slow down", 0),
+			failures:      1,
+			expectedCalls: 2,
+			expectSuccess: true,
+		},
+		{
+			name:          "Server errors are retried until attempts are exhausted",
+			err:           kerr.NewInternalError(errors.New("etcd unavailable")),
+			failures:      5,
+			expectedCalls: 3,
+			expectSuccess: false,
+		},
+		{
+			name:          "Forbidden is not retried",
+			err:           kerr.NewForbidden(secretsResource, "test", errors.New("denied")),
+			failures:      1,
+			expectedCalls: 1,
+			expectSuccess: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewSimpleClientset()
+			k := k8sapi.NewK8sClient(fakeClient, "test").WithBackoff(fastBackoff)
+
+			_, err := k.CreateSecret(ctx, "test", map[string]string{"A": "1"})
+			require.Nil(t, err)
+
+			calls := failTimes(fakeClient, "update", tt.failures, tt.err)
+
+			secret, err := k.UpdateSecret(ctx, "test", map[string]string{"A": "2"})
+			assert.Equal(t, tt.expectedCalls, *calls)
+			if tt.expectSuccess {
+				require.Nil(t, err)
+				assert.Equal(t, "2", string(secret.Data["A"]))
+			} else {
+				assert.NotNil(t, err)
+				assert.Equal(t, kerr.ReasonForError(tt.err), kerr.ReasonForError(err))
+			}
+		})
+	}
+}
+
+func TestK8sCreateSecretRetries(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("test CreateSecret retries unavailable servers", func(t *testing.T) {
+		fakeClient := fake.NewSimpleClientset()
+		k := k8sapi.NewK8sClient(fakeClient, "test").WithBackoff(fastBackoff)
+		calls := failTimes(fakeClient, "create", 1, kerr.NewServiceUnavailable("restarting"))
+
+		_, err := k.CreateSecret(ctx, "test", map[string]string{"A": "1"})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, *calls)
+	})
+	t.Run("test CreateSecret does not retry alreadyExists", func(t *testing.T) {
+		fakeClient := fake.NewSimpleClientset()
+		k := k8sapi.NewK8sClient(fakeClient, "test").WithBackoff(fastBackoff)
+		calls := failTimes(fakeClient, "create", 1, kerr.NewAlreadyExists(secretsResource, "test"))
+
+		_, err := k.CreateSecret(ctx, "test", map[string]string{"A": "1"})
+		assert.True(t, kerr.IsAlreadyExists(err))
+		assert.Equal(t, 1, *calls)
+	})
+	t.Run("test CreateSecret accepts a secret created by a timed out attempt", func(t *testing.T) {
+		fakeClient := fake.NewSimpleClientset()
+		k := k8sapi.NewK8sClient(fakeClient, "test").WithBackoff(fastBackoff)
+		createdThenTimeout(fakeClient)
+
+		secret, err := k.CreateSecret(ctx, "test", map[string]string{"A": "1"})
+		require.Nil(t, err)
+		assert.Equal(t, "1", string(secret.Data["A"]))
+	})
+	t.Run("test CreateSecret reports a secret created with other data after a timeout", func(t *testing.T) {
+		fakeClient := fake.NewSimpleClientset()
+		k := k8sapi.NewK8sClient(fakeClient, "test").WithBackoff(fastBackoff)
+		failTimes(fakeClient, "create", 1, kerr.NewServerTimeout(secretsResource, "create", 1))
+		require.Nil(t, fakeClient.Tracker().Add(&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+			Data:       map[string][]byte{"A": []byte("other")},
+		}))
+
+		_, err := k.CreateSecret(ctx, "test", map[string]string{"A": "1"})
+		assert.True(t, kerr.IsAlreadyExists(err))
+	})
+	t.Run("test WithRetryAttempts disables retries", func(t *testing.T) {
+		fakeClient := fake.NewSimpleClientset()
+		k := k8sapi.NewK8sClient(fakeClient, "test").WithBackoff(fastBackoff).WithRetryAttempts(0)
+		calls := failTimes(fakeClient, "create", 1, kerr.NewServiceUnavailable("restarting"))
+
+		_, err := k.CreateSecret(ctx, "test", map[string]string{"A": "1"})
+		assert.True(t, kerr.IsServiceUnavailable(err))
+		assert.Equal(t, 1, *calls)
+	})
+}
+
+func TestK8sRecordRevisionRetries(t *testing.T) {
+	ctx := context.Background()
+	fakeClient := fake.NewSimpleClientset()
+	k := k8sapi.NewK8sClient(fakeClient, "test").WithBackoff(fastBackoff)
+
+	secret, err := k.CreateSecret(ctx, "test", map[string]string{"A": "1"})
+	require.Nil(t, err)
+
+	// Simulate another writer recording revision 1 first.
+	_, err = k.RecordRevision(ctx, secret, k8sapi.DefaultHistoryLimit)
+	require.Nil(t, err)
+	failTimes(fakeClient, "create", 1, kerr.NewAlreadyExists(secretsResource, "test-rev-2"))
+
+	revision, err := k.RecordRevision(ctx, secret, k8sapi.DefaultHistoryLimit)
+	require.Nil(t, err)
+	assert.Equal(t, 2, revision.Number)
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, k8sapi.IsTransient(kerr.NewTooManyRequests("slow down", 1)))
+	assert.True(t, k8sapi.IsTransient(kerr.NewServerTimeout(secretsResource, "update", 1)))
+	assert.True(t, k8sapi.IsTransient(kerr.NewTimeoutError("timeout", 1)))
+	assert.True(t, k8sapi.IsTransient(kerr.NewInternalError(errors.New("boom"))))
+	assert.False(t, k8sapi.IsTransient(kerr.NewNotFound(secretsResource, "test")))
+	assert.False(t, k8sapi.IsTransient(kerr.NewConflict(secretsResource, "test", errors.New("conflict"))))
+}
@@ -0,0 +1,280 @@
+package k8sapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// AnnotationRotatingKeys holds the comma separated keys of a secret whose
+	// previous value is still kept under PreviousKey.
+	AnnotationRotatingKeys = "envsecret.ogticrd.io/rotating-keys"
+	// AnnotationRotationStartedAt holds the last time a rotation was started on a secret, in RFC 3339 format.
+	AnnotationRotationStartedAt = "envsecret.ogticrd.io/rotation-started-at"
+	// AnnotationRestartedAt is the pod template annotation kubectl rollout restart sets to restart a workload.
+	AnnotationRestartedAt = "kubectl.kubernetes.io/restartedAt"
+	// PreviousKeySuffix is appended to a key to keep its previous value during a rotation.
+	PreviousKeySuffix = "_PREVIOUS"
+)
+
+// PreviousKey returns the key holding the previous value of a key during a rotation.
+//
+// Example usage:
+// key := PreviousKey("API_TOKEN") // Output: API_TOKEN_PREVIOUS
+func PreviousKey(key string) string {
+	return key + PreviousKeySuffix
+}
+
+// RotatingKeys returns the keys of the secret with a rotation in progress, as
+// recorded in its annotations.
+//
+// Example usage:
+// keys := RotatingKeys(secret) // Output: [API_TOKEN]
+func RotatingKeys(secret *v1.Secret) []string {
+	value := secret.Annotations[AnnotationRotatingKeys]
+	if len(value) == 0 {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// KeepPreviousValues copies the previous values of the keys being rotated in
+// the secret into data, unless data already sets them, so that replacing the
+// data of a secret during a rotation keeps both values valid.
+//
+// Parameters:
+// - secret: The secret as currently stored.
+// - data: The new data of the secret, modified in place.
+//
+// Example usage:
+// KeepPreviousValues(current, data) // data now holds API_TOKEN_PREVIOUS
+func KeepPreviousValues(secret *v1.Secret, data map[string][]byte) {
+	for _, key := range RotatingKeys(secret) {
+		previous := PreviousKey(key)
+		value, ok := secret.Data[previous]
+		if _, set := data[previous]; ok && !set {
+			data[previous] = value
+		}
+	}
+}
+
+// setRotatingKeys records the keys with a rotation in progress, removing the
+// rotation annotations when there are none left.
+func setRotatingKeys(secret *v1.Secret, keys []string) {
+	if len(keys) == 0 {
+		delete(secret.Annotations, AnnotationRotatingKeys)
+		delete(secret.Annotations, AnnotationRotationStartedAt)
+		return
+	}
+	sort.Strings(keys)
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	secret.Annotations[AnnotationRotatingKeys] = strings.Join(keys, ",")
+}
+
+// StartRotation replaces the values of keys of a secret, keeping their
+// current values under PreviousKey until the rotation is finalized, so that
+// both values are valid while consumers pick up the new one.
+//
+// Parameters:
+// - ctx: Context for the API requests.
+// - secretName: Name of the secret.
+// - values: The new values by key. Every key must already exist in the secret
+// and must not be in the middle of another rotation.
+//
+// Returns:
+// - The updated Secret object.
+// - An error if a key cannot be rotated or the secret cannot be updated.
+//
+// Example usage:
+// secret, err := k8sClient.StartRotation(ctx, "my-secret", map[string]string{"API_TOKEN": "new-token"})
+func (c *K8sClient) StartRotation(ctx context.Context, secretName string, values map[string]string) (*v1.Secret, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no keys to rotate")
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return c.updateSecretWith(ctx, secretName, func(secret *v1.Secret) error {
+		rotating := RotatingKeys(secret)
+		for _, key := range keys {
+			current, ok := secret.Data[key]
+			switch {
+			case slices.Contains(rotating, key):
+				return fmt.Errorf("key %s of secret %s is already being rotated, finalize the rotation first", key, secretName)
+			case !ok:
+				return fmt.Errorf("key %s not found in secret %s", key, secretName)
+			case bytes.Equal(current, []byte(values[key])):
+				return fmt.Errorf("the new value of key %s is the same as the current one", key)
+			}
+			if _, ok := secret.Data[PreviousKey(key)]; ok {
+				return fmt.Errorf("secret %s already has a %s key", secretName, PreviousKey(key))
+			}
+		}
+
+		for _, key := range keys {
+			secret.Data[PreviousKey(key)] = secret.Data[key]
+			secret.Data[key] = []byte(values[key])
+		}
+		setRotatingKeys(secret, append(rotating, keys...))
+		secret.Annotations[AnnotationRotationStartedAt] = time.Now().UTC().Format(time.RFC3339)
+		return nil
+	})
+}
+
+// FinalizeRotation removes the previous values of keys of a secret once every
+// consumer uses the new ones.
+//
+// Parameters:
+// - ctx: Context for the API requests.
+// - secretName: Name of the secret.
+// - keys: The keys whose rotation is finalized. Every key must have a rotation in progress.
+//
+// Returns:
+// - The updated Secret object.
+// - An error if a key has no rotation in progress or the secret cannot be updated.
+//
+// Example usage:
+// secret, err := k8sClient.FinalizeRotation(ctx, "my-secret", []string{"API_TOKEN"})
+func (c *K8sClient) FinalizeRotation(ctx context.Context, secretName string, keys []string) (*v1.Secret, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keys to finalize")
+	}
+
+	return c.updateSecretWith(ctx, secretName, func(secret *v1.Secret) error {
+		rotating := RotatingKeys(secret)
+		for _, key := range keys {
+			if !slices.Contains(rotating, key) {
+				return fmt.Errorf("key %s of secret %s is not being rotated", key, secretName)
+			}
+		}
+
+		left := make([]string, 0, len(rotating))
+		for _, key := range rotating {
+			if slices.Contains(keys, key) {
+				delete(secret.Data, PreviousKey(key))
+			} else {
+				left = append(left, key)
+			}
+		}
+		setRotatingKeys(secret, left)
+		return nil
+	})
+}
+
+// Workload resources listed by FindConsumers and patched by RestartConsumers.
+var (
+	ConsumerResources = []Resource{
+		{Group: "apps", Name: "deployments"},
+		{Group: "apps", Name: "statefulsets"},
+		{Group: "apps", Name: "daemonsets"},
+		{Group: "batch", Name: "cronjobs"},
+		{Group: "batch", Name: "jobs"},
+		{Name: "pods"},
+	}
+	RestartableResources = ConsumerResources[:3]
+)
+
+// PreflightRestart checks that the current user may find the consumers of a
+// secret and restart them, so a rotation does not stop halfway after writing
+// the new values.
+//
+// Returns:
+// - An error naming the first resource with missing permissions, or if the access cannot be checked.
+//
+// Example usage:
+// if err := k8sClient.PreflightRestart(ctx); err != nil {
+// return err
+// }
+func (c *K8sClient) PreflightRestart(ctx context.Context) error {
+	for _, resource := range ConsumerResources {
+		verbs := []string{"list"}
+		if slices.Contains(RestartableResources, resource) {
+			verbs = append(verbs, "patch")
+		}
+		if err := c.PreflightResource(ctx, resource, verbs...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestartConsumers restarts the Deployments, StatefulSets and DaemonSets
+// among the consumers of a secret, the same way kubectl rollout restart does,
+// so their pods pick up the new values.
+//
+// Jobs, CronJobs and bare Pods cannot be restarted and are returned as skipped.
+//
+// Parameters:
+// - ctx: Context for the API requests.
+// - consumers: The consumers of the secret, as returned by FindConsumers.
+//
+// Returns:
+// - The consumers that were restarted.
+// - The consumers that were skipped.
+// - An error if a workload cannot be patched. Workloads restarted before it are returned.
+//
+// Example usage:
+// restarted, skipped, err := k8sClient.RestartConsumers(ctx, consumers)
+func (c *K8sClient) RestartConsumers(ctx context.Context, consumers []Consumer) ([]Consumer, []Consumer, error) {
+	patch, err := json.Marshal(map[string]any{
+		"spec": map[string]any{
+			"template": map[string]any{
+				"metadata": map[string]any{
+					"annotations": map[string]string{
+						AnnotationRestartedAt: time.Now().Format(time.RFC3339),
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var restarted, skipped []Consumer
+	for _, consumer := range consumers {
+		var restart func() error
+		switch consumer.Kind {
+		case "Deployment":
+			restart = func() error {
+				_, err := c.client.AppsV1().Deployments(c.namespace).Patch(ctx, consumer.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+				return err
+			}
+		case "StatefulSet":
+			restart = func() error {
+				_, err := c.client.AppsV1().StatefulSets(c.namespace).Patch(ctx, consumer.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+				return err
+			}
+		case "DaemonSet":
+			restart = func() error {
+				_, err := c.client.AppsV1().DaemonSets(c.namespace).Patch(ctx, consumer.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+				return err
+			}
+		default:
+			skipped = append(skipped, consumer)
+			continue
+		}
+
+		if err := c.withRetry(ctx, IsTransient, restart); err != nil {
+			return restarted, skipped, fmt.Errorf("error restarting %s: %w", consumer, err)
+		}
+		restarted = append(restarted, consumer)
+	}
+	return restarted, skipped, nil
+}
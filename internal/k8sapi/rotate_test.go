@@ -0,0 +1,118 @@
+package k8sapi_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestK8sRotation(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "test"},
+		Data:       map[string][]byte{"API_TOKEN": []byte("old"), "DB_PASSWORD": []byte("db"), "OTHER": []byte("x")},
+	})
+	k := k8sapi.NewK8sClient(client, "test")
+
+	secret, err := k.StartRotation(ctx, "api", map[string]string{"API_TOKEN": "new"})
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(secret.Data["API_TOKEN"]))
+	assert.Equal(t, "old", string(secret.Data["API_TOKEN_PREVIOUS"]))
+	assert.Equal(t, []string{"API_TOKEN"}, k8sapi.RotatingKeys(secret))
+	assert.NotEmpty(t, secret.Annotations[k8sapi.AnnotationRotationStartedAt])
+	assert.True(t, k8sapi.IsManaged(secret))
+
+	_, err = k.StartRotation(ctx, "api", map[string]string{"API_TOKEN": "newer"})
+	assert.ErrorContains(t, err, "already being rotated")
+	_, err = k.StartRotation(ctx, "api", map[string]string{"MISSING": "1"})
+	assert.ErrorContains(t, err, "key MISSING not found")
+	_, err = k.StartRotation(ctx, "api", map[string]string{"OTHER": "x"})
+	assert.ErrorContains(t, err, "same as the current one")
+
+	secret, err = k.StartRotation(ctx, "api", map[string]string{"DB_PASSWORD": "db2"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"API_TOKEN", "DB_PASSWORD"}, k8sapi.RotatingKeys(secret))
+
+	_, err = k.FinalizeRotation(ctx, "api", []string{"OTHER"})
+	assert.ErrorContains(t, err, "key OTHER of secret api is not being rotated")
+
+	secret, err = k.FinalizeRotation(ctx, "api", []string{"API_TOKEN"})
+	require.NoError(t, err)
+	assert.NotContains(t, secret.Data, "API_TOKEN_PREVIOUS")
+	assert.Equal(t, "db", string(secret.Data["DB_PASSWORD_PREVIOUS"]))
+	assert.Equal(t, []string{"DB_PASSWORD"}, k8sapi.RotatingKeys(secret))
+
+	secret, err = k.FinalizeRotation(ctx, "api", []string{"DB_PASSWORD"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"API_TOKEN": []byte("new"), "DB_PASSWORD": []byte("db2"), "OTHER": []byte("x")}, secret.Data)
+	assert.NotContains(t, secret.Annotations, k8sapi.AnnotationRotatingKeys)
+	assert.NotContains(t, secret.Annotations, k8sapi.AnnotationRotationStartedAt)
+}
+
+func TestK8sStartRotationExistingPreviousKey(t *testing.T) {
+	client := fake.NewSimpleClientset(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "test"},
+		Data:       map[string][]byte{"API_TOKEN": []byte("old"), "API_TOKEN_PREVIOUS": []byte("older")},
+	})
+
+	_, err := k8sapi.NewK8sClient(client, "test").StartRotation(context.Background(), "api", map[string]string{"API_TOKEN": "new"})
+	assert.ErrorContains(t, err, "secret api already has a API_TOKEN_PREVIOUS key")
+}
+
+func TestK8sRestartConsumers(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "test"}},
+		&appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "test"}},
+	)
+	k := k8sapi.NewK8sClient(client, "test")
+
+	restarted, skipped, err := k.RestartConsumers(ctx, []k8sapi.Consumer{
+		{Kind: "Deployment", Name: "api"},
+		{Kind: "Job", Name: "migrate"},
+		{Kind: "StatefulSet", Name: "db"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []k8sapi.Consumer{{Kind: "Deployment", Name: "api"}, {Kind: "StatefulSet", Name: "db"}}, restarted)
+	assert.Equal(t, []k8sapi.Consumer{{Kind: "Job", Name: "migrate"}}, skipped)
+
+	deployment, err := client.AppsV1().Deployments("test").Get(ctx, "api", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, deployment.Spec.Template.Annotations[k8sapi.AnnotationRestartedAt])
+
+	_, _, err = k.RestartConsumers(ctx, []k8sapi.Consumer{{Kind: "DaemonSet", Name: "missing"}})
+	assert.ErrorContains(t, err, "error restarting DaemonSet/missing")
+}
+
+func TestK8sUpdateSecretDuringRotation(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "test"},
+		Data:       map[string][]byte{"API_TOKEN": []byte("old"), "PORT": []byte("8080")},
+	})
+	k := k8sapi.NewK8sClient(client, "test")
+
+	_, err := k.StartRotation(ctx, "api", map[string]string{"API_TOKEN": "new"})
+	require.NoError(t, err)
+
+	secret, err := k.UpdateSecret(ctx, "api", map[string]string{"API_TOKEN": "new", "PORT": "9090"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"API_TOKEN":          []byte("new"),
+		"API_TOKEN_PREVIOUS": []byte("old"),
+		"PORT":               []byte("9090"),
+	}, secret.Data)
+	assert.Equal(t, []string{"API_TOKEN"}, k8sapi.RotatingKeys(secret))
+
+	// Values given explicitly win over the kept ones.
+	secret, err = k.UpdateSecret(ctx, "api", map[string]string{"API_TOKEN": "new", "API_TOKEN_PREVIOUS": "older"})
+	require.NoError(t, err)
+	assert.Equal(t, "older", string(secret.Data["API_TOKEN_PREVIOUS"]))
+}
@@ -0,0 +1,319 @@
+// Package lint checks .env files for mistakes that make them behave
+// differently than their authors expect once loaded into a Kubernetes secret.
+package lint
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/parser"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Severity tells how serious an issue is.
+type Severity string
+
+const (
+	// SeverityError marks issues that produce wrong or unusable secrets.
+	SeverityError Severity = "error"
+	// SeverityWarning marks issues that are likely mistakes.
+	SeverityWarning Severity = "warning"
+)
+
+// Rule identifiers.
+const (
+	RuleSyntax             = "syntax"
+	RuleUnbalancedQuotes   = "unbalanced-quotes"
+	RuleDuplicateKey       = "duplicate-key"
+	RuleInvalidKey         = "invalid-key"
+	RuleKeyCase            = "key-case"
+	RuleEmptyValue         = "empty-value"
+	RuleUnquotedHash       = "unquoted-hash"
+	RuleTrailingWhitespace = "trailing-whitespace"
+	RuleMixedLineEndings   = "mixed-line-endings"
+	RuleBOM                = "bom"
+)
+
+// Rule describes a check made by the linter.
+type Rule struct {
+	ID          string   // Identifier of the rule, e.g. duplicate-key.
+	Description string   // Short description of what the rule checks.
+	Severity    Severity // Severity of the issues reported by the rule.
+	Fixable     bool     // Whether Fix can solve the issues reported by the rule.
+}
+
+// Rules lists every check made by the linter.
+var Rules = []Rule{
+	{ID: RuleSyntax, Description: "The file cannot be parsed.", Severity: SeverityError},
+	{ID: RuleUnbalancedQuotes, Description: "A quoted value is never closed.", Severity: SeverityError},
+	{ID: RuleDuplicateKey, Description: "A key is defined more than once; only the last value is used.", Severity: SeverityError},
+	{ID: RuleInvalidKey, Description: "A key is not a valid Kubernetes secret key.", Severity: SeverityError},
+	{ID: RuleKeyCase, Description: "A key does not follow the configured case convention.", Severity: SeverityWarning},
+	{ID: RuleEmptyValue, Description: "A key has an empty value.", Severity: SeverityWarning},
+	{ID: RuleUnquotedHash, Description: "An unquoted value contains '#', which other tools may read as a comment.", Severity: SeverityWarning},
+	{ID: RuleTrailingWhitespace, Description: "A line ends with spaces or tabs.", Severity: SeverityWarning, Fixable: true},
+	{ID: RuleMixedLineEndings, Description: "The file mixes CRLF and LF line endings.", Severity: SeverityWarning, Fixable: true},
+	{ID: RuleBOM, Description: "The file starts with a UTF-8 byte order mark, which makes it fail to load.", Severity: SeverityError, Fixable: true},
+}
+
+// Issue is a problem found in a .env file.
+type Issue struct {
+	File     string   `json:"file"`
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Fixable  bool     `json:"fixable"`
+}
+
+// String formats the issue as FILE:LINE:COLUMN: SEVERITY: MESSAGE (RULE).
+func (i Issue) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s (%s)", i.File, i.Line, i.Column, i.Severity, i.Message, i.Rule)
+}
+
+// KeyCase is the naming convention enforced on keys.
+type KeyCase string
+
+const (
+	// KeyCaseUpper requires keys such as DATABASE_URL.
+	KeyCaseUpper KeyCase = "upper"
+	// KeyCaseLower requires keys such as database_url.
+	KeyCaseLower KeyCase = "lower"
+	// KeyCaseAny accepts keys in any case.
+	KeyCaseAny KeyCase = "any"
+)
+
+// ParseKeyCase validates the name of a key case convention.
+//
+// Example usage:
+// keyCase, err := lint.ParseKeyCase("upper")
+func ParseKeyCase(s string) (KeyCase, error) {
+	switch KeyCase(s) {
+	case KeyCaseUpper, KeyCaseLower, KeyCaseAny:
+		return KeyCase(s), nil
+	}
+	return "", fmt.Errorf("unknown key case %q, must be one of: upper, lower, any", s)
+}
+
+// Options configures the linter.
+type Options struct {
+	KeyCase KeyCase // Case convention enforced on keys. Empty means KeyCaseUpper.
+}
+
+var bom = []byte("\uFEFF")
+
+// Lint checks the content of a .env file.
+//
+// Parameters:
+// - file: Name of the file, used to label the issues.
+// - content: The content of the file.
+// - opts: Options of the linter.
+//
+// Returns:
+// - The issues found, in order of appearance.
+//
+// Example usage:
+// content, _ := os.ReadFile(".env")
+//
+//	for _, issue := range lint.Lint(".env", content, lint.Options{}) {
+//	    fmt.Println(issue)
+//	}
+func Lint(file string, content []byte, opts Options) []Issue {
+	l := &linter{file: file, opts: opts}
+	if len(l.opts.KeyCase) == 0 {
+		l.opts.KeyCase = KeyCaseUpper
+	}
+
+	if bytes.HasPrefix(content, bom) {
+		l.report(RuleBOM, 1, 1, "file starts with a UTF-8 byte order mark")
+	}
+	if line, ok := mixedLineEnding(content); ok {
+		l.report(RuleMixedLineEndings, line, 1, "line ending differs from the first line, the file mixes CRLF and LF")
+	}
+
+	entries, err := parser.ParseEntries(content)
+	l.trailingWhitespace(content, entries)
+
+	seen := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		if first, ok := seen[entry.Key]; ok {
+			l.report(RuleDuplicateKey, entry.Line, entry.Column, "duplicate key %s, first defined at line %d; only the last value is used", entry.Key, first)
+		} else {
+			seen[entry.Key] = entry.Line
+		}
+		l.checkKey(entry)
+		l.checkValue(entry)
+	}
+
+	if err != nil {
+		var syntaxErr *parser.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			rule := RuleSyntax
+			if errors.Is(err, parser.ErrUnbalancedQuotes) {
+				rule = RuleUnbalancedQuotes
+			}
+			l.report(rule, syntaxErr.Line, syntaxErr.Column, "%s", syntaxErr.Err)
+		} else {
+			l.report(RuleSyntax, 1, 1, "%s", err)
+		}
+	}
+
+	sortIssues(l.issues)
+	return l.issues
+}
+
+// Fix applies the safe fixes to the content of a .env file: it removes the
+// byte order mark, converts mixed line endings to LF and strips trailing
+// whitespace outside quoted values. The values loaded from the file are not
+// changed.
+//
+// Parameters:
+// - content: The content of the file.
+//
+// Returns:
+// - The fixed content, or the same content if there is nothing to fix.
+//
+// Example usage:
+// fixed := lint.Fix(content)
+func Fix(content []byte) []byte {
+	fixed := bytes.TrimPrefix(content, bom)
+	if _, ok := mixedLineEnding(fixed); ok {
+		fixed = bytes.ReplaceAll(fixed, []byte("\r\n"), []byte("\n"))
+	}
+
+	entries, _ := parser.ParseEntries(fixed)
+	quoted := quotedLines(entries)
+	lines := bytes.Split(fixed, []byte("\n"))
+	for i, line := range lines {
+		if quoted[i+1] {
+			continue
+		}
+		body := bytes.TrimSuffix(line, []byte("\r"))
+		trimmed := bytes.TrimRight(body, " \t")
+		if len(trimmed) < len(body) {
+			lines[i] = append(trimmed[:len(trimmed):len(trimmed)], line[len(body):]...)
+		}
+	}
+	fixed = bytes.Join(lines, []byte("\n"))
+
+	if bytes.Equal(fixed, content) {
+		return content
+	}
+	return fixed
+}
+
+type linter struct {
+	file   string
+	opts   Options
+	issues []Issue
+}
+
+func (l *linter) report(ruleID string, line, column int, format string, args ...interface{}) {
+	rule := findRule(ruleID)
+	l.issues = append(l.issues, Issue{
+		File:     l.file,
+		Rule:     rule.ID,
+		Severity: rule.Severity,
+		Message:  fmt.Sprintf(format, args...),
+		Line:     line,
+		Column:   column,
+		Fixable:  rule.Fixable,
+	})
+}
+
+func (l *linter) checkKey(entry parser.Entry) {
+	if errs := validation.IsConfigMapKey(entry.Key); len(errs) > 0 {
+		l.report(RuleInvalidKey, entry.Line, entry.Column, "invalid key %s: %s", entry.Key, strings.Join(errs, "; "))
+		return
+	}
+
+	switch {
+	case l.opts.KeyCase == KeyCaseUpper && entry.Key != strings.ToUpper(entry.Key):
+		l.report(RuleKeyCase, entry.Line, entry.Column, "key %s is not uppercase", entry.Key)
+	case l.opts.KeyCase == KeyCaseLower && entry.Key != strings.ToLower(entry.Key):
+		l.report(RuleKeyCase, entry.Line, entry.Column, "key %s is not lowercase", entry.Key)
+	}
+}
+
+func (l *linter) checkValue(entry parser.Entry) {
+	if len(entry.Value) == 0 {
+		l.report(RuleEmptyValue, entry.Line, entry.Column, "key %s has an empty value", entry.Key)
+	}
+
+	if entry.Quote == 0 {
+		value := entry.Raw[:parser.InlineCommentIndex(entry.Raw)]
+		if i := strings.IndexByte(value, '#'); i >= 0 {
+			l.report(RuleUnquotedHash, entry.ValueLine, entry.ValueColumn+i, "unquoted value of %s contains '#', quote the value to keep it as is", entry.Key)
+		}
+	}
+}
+
+// trailingWhitespace reports lines ending with spaces or tabs, skipping the
+// lines inside quoted values, where whitespace is part of the value.
+func (l *linter) trailingWhitespace(content []byte, entries []parser.Entry) {
+	quoted := quotedLines(entries)
+	for i, line := range bytes.Split(bytes.TrimPrefix(content, bom), []byte("\n")) {
+		if quoted[i+1] {
+			continue
+		}
+		body := bytes.TrimSuffix(line, []byte("\r"))
+		if trimmed := bytes.TrimRight(body, " \t"); len(trimmed) < len(body) {
+			l.report(RuleTrailingWhitespace, i+1, len(trimmed)+1, "trailing whitespace")
+		}
+	}
+}
+
+// quotedLines returns the lines that end inside a quoted value.
+func quotedLines(entries []parser.Entry) map[int]bool {
+	lines := map[int]bool{}
+	for _, entry := range entries {
+		if entry.Quote == 0 {
+			continue
+		}
+		for i := 0; i < strings.Count(entry.Raw, "\n"); i++ {
+			lines[entry.ValueLine+i] = true
+		}
+	}
+	return lines
+}
+
+// mixedLineEnding returns the first line whose ending differs from the
+// ending of the first line, if the content mixes CRLF and LF.
+func mixedLineEnding(content []byte) (int, bool) {
+	var crlf bool
+	line := 0
+	for i, c := range content {
+		if c != '\n' {
+			continue
+		}
+		line++
+		isCRLF := i > 0 && content[i-1] == '\r'
+		if line == 1 {
+			crlf = isCRLF
+		} else if isCRLF != crlf {
+			return line, true
+		}
+	}
+	return 0, false
+}
+
+func findRule(id string) Rule {
+	for _, rule := range Rules {
+		if rule.ID == id {
+			return rule
+		}
+	}
+	return Rule{ID: id, Severity: SeverityError}
+}
+
+// sortIssues orders issues by line, keeping the order in which they were found
+// for issues on the same line.
+func sortIssues(issues []Issue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Line < issues[j].Line
+	})
+}
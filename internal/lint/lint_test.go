@@ -0,0 +1,212 @@
+package lint_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/joho/godotenv"
+	"github.com/ogticrd/kubectl-envsecret/internal/lint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type position struct {
+	rule   string
+	line   int
+	column int
+}
+
+func positions(issues []lint.Issue) []position {
+	var result []position
+	for _, issue := range issues {
+		result = append(result, position{rule: issue.Rule, line: issue.Line, column: issue.Column})
+	}
+	return result
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		keyCase  lint.KeyCase
+		expected []position
+	}{
+		{
+			name:    "Clean file",
+			content: "# comment\nA=1\nB=\"two words\"\nC='multi\nline'\n",
+		},
+		{
+			name:     "Duplicate key",
+			content:  "A=1\nB=2\nA=3\n",
+			expected: []position{{lint.RuleDuplicateKey, 3, 1}},
+		},
+		{
+			name:     "Invalid key",
+			content:  "MY/KEY=1\n",
+			expected: []position{{lint.RuleInvalidKey, 1, 1}},
+		},
+		{
+			name:     "Lowercase key",
+			content:  "A=1\ndb_url=x\n",
+			expected: []position{{lint.RuleKeyCase, 2, 1}},
+		},
+		{
+			name:     "Uppercase key with lower convention",
+			content:  "db_url=x\nA=1\n",
+			keyCase:  lint.KeyCaseLower,
+			expected: []position{{lint.RuleKeyCase, 2, 1}},
+		},
+		{
+			name:    "Any key case",
+			content: "db_url=x\nA=1\n",
+			keyCase: lint.KeyCaseAny,
+		},
+		{
+			name:     "Empty value",
+			content:  "A=\nB=''\n",
+			expected: []position{{lint.RuleEmptyValue, 1, 1}, {lint.RuleEmptyValue, 2, 1}},
+		},
+		{
+			name:     "Unquoted hash",
+			content:  "PASSWORD=abc#123 # comment\nCOLOR='#fff'\n",
+			expected: []position{{lint.RuleUnquotedHash, 1, 13}},
+		},
+		{
+			name:     "Trailing whitespace outside quotes",
+			content:  "A=1  \nB=\"keep  \nthis\"\t\n",
+			expected: []position{{lint.RuleTrailingWhitespace, 1, 4}, {lint.RuleTrailingWhitespace, 3, 6}},
+		},
+		{
+			name:     "Mixed line endings",
+			content:  "A=1\r\nB=2\nC=3\r\n",
+			expected: []position{{lint.RuleMixedLineEndings, 2, 1}},
+		},
+		{
+			name:    "Only CRLF line endings",
+			content: "A=1\r\nB=2\r\n",
+		},
+		{
+			name:     "Byte order mark",
+			content:  "\uFEFFA=1\n",
+			expected: []position{{lint.RuleBOM, 1, 1}},
+		},
+		{
+			name:     "Unbalanced quotes",
+			content:  "A=1\nB=\"open\nC=2\n",
+			expected: []position{{lint.RuleUnbalancedQuotes, 2, 3}},
+		},
+		{
+			name:     "Syntax error",
+			content:  "A=1\nJUSTAKEY\n",
+			expected: []position{{lint.RuleSyntax, 2, 9}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := lint.Lint(".env", []byte(tt.content), lint.Options{KeyCase: tt.keyCase})
+			assert.Equal(t, tt.expected, positions(issues))
+			for _, issue := range issues {
+				assert.Equal(t, ".env", issue.File)
+				assert.NotEmpty(t, issue.Message)
+			}
+		})
+	}
+}
+
+func TestFix(t *testing.T) {
+	content := "\uFEFFA=1  \r\nB=\"keep  \nthis\"\t\nC=3\r\n"
+
+	fixed := lint.Fix([]byte(content))
+	assert.Equal(t, "A=1\nB=\"keep  \nthis\"\nC=3\n", string(fixed))
+	assert.Empty(t, lint.Lint(".env", fixed, lint.Options{}))
+
+	before, err := godotenv.UnmarshalBytes([]byte(content[len("\uFEFF"):]))
+	require.NoError(t, err)
+	after, err := godotenv.UnmarshalBytes(fixed)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	clean := []byte("A=1\r\nB=2\r\n")
+	assert.Equal(t, clean, lint.Fix(clean))
+}
+
+func TestParseKeyCase(t *testing.T) {
+	keyCase, err := lint.ParseKeyCase("lower")
+	require.NoError(t, err)
+	assert.Equal(t, lint.KeyCaseLower, keyCase)
+
+	_, err = lint.ParseKeyCase("camel")
+	assert.Error(t, err)
+}
+
+func TestReport(t *testing.T) {
+	report := &lint.Report{Issues: lint.Lint("config/.env", []byte("A=1\nA=2\nb=3\n"), lint.Options{})}
+	require.Len(t, report.Issues, 2)
+	assert.Equal(t, 1, report.Errors())
+
+	t.Run("Text", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, report.Write(&out, "text"))
+		assert.Equal(t, "config/.env:2:1: error: duplicate key A, first defined at line 1; only the last value is used (duplicate-key)\n"+
+			"config/.env:3:1: warning: key b is not uppercase (key-case)\n", out.String())
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, report.Write(&out, "json"))
+
+		var decoded lint.Report
+		require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+		assert.Equal(t, *report, decoded)
+	})
+
+	t.Run("SARIF", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, report.Write(&out, "sarif"))
+
+		var decoded struct {
+			Version string `json:"version"`
+			Runs    []struct {
+				Tool struct {
+					Driver struct {
+						Rules []struct {
+							ID string `json:"id"`
+						} `json:"rules"`
+					} `json:"driver"`
+				} `json:"tool"`
+				Results []struct {
+					RuleID    string `json:"ruleId"`
+					Level     string `json:"level"`
+					Locations []struct {
+						PhysicalLocation struct {
+							ArtifactLocation struct {
+								URI string `json:"uri"`
+							} `json:"artifactLocation"`
+							Region struct {
+								StartLine int `json:"startLine"`
+							} `json:"region"`
+						} `json:"physicalLocation"`
+					} `json:"locations"`
+					RuleIndex int `json:"ruleIndex"`
+				} `json:"results"`
+			} `json:"runs"`
+		}
+		require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+		assert.Equal(t, "2.1.0", decoded.Version)
+		require.Len(t, decoded.Runs, 1)
+		run := decoded.Runs[0]
+		assert.Len(t, run.Tool.Driver.Rules, len(lint.Rules))
+		require.Len(t, run.Results, 2)
+
+		result := run.Results[0]
+		assert.Equal(t, lint.RuleDuplicateKey, result.RuleID)
+		assert.Equal(t, "error", result.Level)
+		assert.Equal(t, lint.RuleDuplicateKey, run.Tool.Driver.Rules[result.RuleIndex].ID)
+		assert.Equal(t, "config/.env", result.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+		assert.Equal(t, 2, result.Locations[0].PhysicalLocation.Region.StartLine)
+	})
+
+	assert.Error(t, report.Write(&bytes.Buffer{}, "xml"))
+}
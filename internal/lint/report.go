@@ -0,0 +1,155 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// Report groups the issues found in several files.
+type Report struct {
+	Issues []Issue `json:"issues"`
+}
+
+// Errors returns the number of issues with error severity.
+func (r *Report) Errors() int {
+	errors := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			errors++
+		}
+	}
+	return errors
+}
+
+// Write renders the report in the given format: text, json or sarif.
+//
+// Example usage:
+// err := report.Write(os.Stdout, "sarif")
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case "", "text":
+		return r.writeText(w)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	case "sarif":
+		return r.writeSARIF(w)
+	}
+	return fmt.Errorf("unknown report format %q, must be one of: text, json, sarif", format)
+}
+
+func (r *Report) writeText(w io.Writer) error {
+	for _, issue := range r.Issues {
+		if _, err := fmt.Fprintln(w, issue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The types below model the subset of SARIF 2.1.0 needed by code scanning
+// tools to show the issues next to the offending lines.
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string       `json:"id"`
+	ShortDescription     sarifMessage `json:"shortDescription"`
+	DefaultConfiguration sarifConfig  `json:"defaultConfiguration"`
+}
+
+type sarifConfig struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+	RuleIndex int             `json:"ruleIndex"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           sarifRegion   `json:"region"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+func (r *Report) writeSARIF(w io.Writer) error {
+	driver := sarifDriver{
+		Name:           "kubectl-envsecret",
+		InformationURI: "https://github.com/ogticrd/kubectl-envsecret",
+	}
+	ruleIndex := make(map[string]int, len(Rules))
+	for i, rule := range Rules {
+		ruleIndex[rule.ID] = i
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   rule.ID,
+			ShortDescription:     sarifMessage{Text: rule.Description},
+			DefaultConfiguration: sarifConfig{Level: string(rule.Severity)},
+		})
+	}
+
+	results := make([]sarifResult, 0, len(r.Issues))
+	for _, issue := range r.Issues {
+		results = append(results, sarifResult{
+			RuleID:  issue.Rule,
+			Level:   string(issue.Severity),
+			Message: sarifMessage{Text: issue.Message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifact{URI: filepath.ToSlash(issue.File)},
+					Region:           sarifRegion{StartLine: issue.Line, StartColumn: issue.Column},
+				},
+			}},
+			RuleIndex: ruleIndex[issue.Rule],
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
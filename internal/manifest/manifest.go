@@ -0,0 +1,113 @@
+// Package manifest provides utilities for reading .envsecret.yaml files.
+//
+// A manifest declares the secrets managed by kubectl-envsecret for a project,
+// with the namespace they live in and the .env files they are built from, so
+// commands can operate on all of them at once.
+//
+// Example manifest:
+//
+//	secrets:
+//	  - name: api
+//	    namespace: production
+//	    envFiles:
+//	      - .env
+//	      - .env.production
+//	    overlay: production
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultFile is the manifest file name used when none is provided.
+const DefaultFile = ".envsecret.yaml"
+
+// Manifest is the content of a .envsecret.yaml file.
+type Manifest struct {
+	Secrets []Target `json:"secrets"` // Secrets declared in the manifest.
+}
+
+// Target declares a secret and the .env files it is built from, along with
+// the options of the create command used to build it.
+type Target struct {
+	Name        string   `json:"name"`                  // Name of the Kubernetes secret.
+	Namespace   string   `json:"namespace,omitempty"`   // Namespace of the secret. Empty means the current namespace.
+	Overlay     string   `json:"overlay,omitempty"`     // Overlay of the .env files, as with --overlay.
+	Schema      string   `json:"schema,omitempty"`      // Path of the schema file, relative to the manifest.
+	EnvFiles    []string `json:"envFiles"`              // Paths of the .env files, relative to the manifest.
+	ValuesFiles []string `json:"valuesFiles,omitempty"` // Paths of the values files of templates, relative to the manifest.
+	Template    bool     `json:"template,omitempty"`    // Whether the .env files are templates, as with --template.
+}
+
+// Load reads and validates a manifest file.
+//
+// Relative .env file paths are resolved against the directory containing the
+// manifest, and empty namespaces are set to defaultNamespace.
+//
+// Parameters:
+// - path: Path of the manifest file.
+// - defaultNamespace: Namespace used for targets that do not declare one.
+//
+// Returns:
+// - The loaded manifest.
+// - An error if the file cannot be read or is invalid.
+//
+// Example usage:
+// m, err := manifest.Load(".envsecret.yaml", "default")
+func Load(path string, defaultNamespace string) (*Manifest, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := yaml.UnmarshalStrict(content, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+
+	if len(m.Secrets) == 0 {
+		return nil, fmt.Errorf("invalid manifest %s: no secrets declared", path)
+	}
+
+	dir := filepath.Dir(path)
+	seen := make(map[string]struct{}, len(m.Secrets))
+	for i := range m.Secrets {
+		target := &m.Secrets[i]
+		if len(target.Name) == 0 {
+			return nil, fmt.Errorf("invalid manifest %s: secret #%d has no name", path, i+1)
+		}
+		if len(target.EnvFiles) == 0 {
+			return nil, fmt.Errorf("invalid manifest %s: secret %s has no envFiles", path, target.Name)
+		}
+		if len(target.Namespace) == 0 {
+			target.Namespace = defaultNamespace
+		}
+
+		id := target.Namespace + "/" + target.Name
+		if _, found := seen[id]; found {
+			return nil, fmt.Errorf("invalid manifest %s: secret %s is declared more than once", path, id)
+		}
+		seen[id] = struct{}{}
+
+		resolve(dir, target.EnvFiles)
+		resolve(dir, target.ValuesFiles)
+		if len(target.Schema) > 0 && !filepath.IsAbs(target.Schema) {
+			target.Schema = filepath.Join(dir, target.Schema)
+		}
+	}
+
+	return &m, nil
+}
+
+// resolve makes relative paths relative to dir, in place.
+func resolve(dir string, paths []string) {
+	for i, path := range paths {
+		if !filepath.IsAbs(path) {
+			paths[i] = filepath.Join(dir, path)
+		}
+	}
+}
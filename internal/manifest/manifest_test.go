@@ -0,0 +1,93 @@
+package manifest_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		expected *manifest.Manifest
+		name     string
+		content  string
+		wantErr  bool
+	}{
+		{
+			name: "Valid manifest",
+			content: `secrets:
+  - name: api
+    envFiles: [.env, /etc/app/.env.production]
+  - name: worker
+    namespace: jobs
+    envFiles: [worker/.env]
+`,
+			expected: &manifest.Manifest{Secrets: []manifest.Target{
+				{Name: "api", Namespace: "default", EnvFiles: []string{"testdata/.env", "/etc/app/.env.production"}},
+				{Name: "worker", Namespace: "jobs", EnvFiles: []string{"testdata/worker/.env"}},
+			}},
+		},
+		{
+			name: "Build options",
+			content: `secrets:
+  - name: api
+    envFiles: [.env]
+    overlay: production
+    schema: .env.schema
+    template: true
+    valuesFiles: [values.yaml]
+`,
+			expected: &manifest.Manifest{Secrets: []manifest.Target{{
+				Name:        "api",
+				Namespace:   "default",
+				Overlay:     "production",
+				Schema:      "testdata/.env.schema",
+				EnvFiles:    []string{"testdata/.env"},
+				ValuesFiles: []string{"testdata/values.yaml"},
+				Template:    true,
+			}}},
+		},
+		{
+			name:    "No secrets",
+			content: "secrets: []",
+			wantErr: true,
+		},
+		{
+			name:    "Missing env files",
+			content: "secrets:\n  - name: api\n",
+			wantErr: true,
+		},
+		{
+			name:    "Duplicated secret",
+			content: "secrets:\n  - name: api\n    envFiles: [.env]\n  - name: api\n    namespace: default\n    envFiles: [.env]\n",
+			wantErr: true,
+		},
+		{
+			name:    "Unknown field",
+			content: "secrets:\n  - name: api\n    envFile: .env\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.MkdirAll("testdata", 0755)
+			defer os.RemoveAll("testdata")
+
+			path := filepath.Join("testdata", manifest.DefaultFile)
+			require.Nil(t, os.WriteFile(path, []byte(tt.content), 0644))
+
+			m, err := manifest.Load(path, "default")
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.expected, m)
+		})
+	}
+}
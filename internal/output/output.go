@@ -0,0 +1,217 @@
+// Package output provides utilities for reporting the result of operations.
+//
+// Commands describe what they did as a Result (which object, which action,
+// how many keys and the content hash) and this package renders it as human
+// readable text, JSON or YAML. Secret values are never part of a Result.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Supported output formats.
+const (
+	FormatText = ""
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// Actions reported by commands.
+const (
+	ActionCreated    = "created"
+	ActionUpdated    = "updated"
+	ActionUnchanged  = "unchanged"
+	ActionRolledBack = "rolled back"
+	ActionDeleted    = "deleted"
+	ActionRotated    = "rotated"
+	ActionFinalized  = "rotation finalized"
+	ActionExported   = "exported"
+)
+
+// Result describes the outcome of an operation on a secret.
+type Result struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Action    string `json:"action"`
+	Hash      string `json:"hash,omitempty"`
+	Changes   string `json:"changes,omitempty"` // Redacted summary of the changed keys.
+	Target    string `json:"target,omitempty"`  // Where the secret was exported, e.g. vault secret/api.
+	Keys      int    `json:"keys"`
+	Revision  int    `json:"revision,omitempty"`
+	Version   int    `json:"version,omitempty"` // Version of the target written or matching the secret.
+	DryRun    bool   `json:"dryRun,omitempty"`
+}
+
+// NewResult creates a Result for an action performed on a secret.
+//
+// Parameters:
+// - secret: The secret as returned by the API server or as it would be written.
+// - action: What happened to the secret, e.g. ActionCreated.
+//
+// Returns:
+// - A Result with the secret name, namespace, number of keys and content hash.
+//
+// Example usage:
+// secret, err := k8sClient.CreateSecret(ctx, "my-secret", secrets)
+// err = output.Print(os.Stdout, output.FormatJSON, output.NewResult(secret, output.ActionCreated))
+func NewResult(secret *v1.Secret, action string) Result {
+	hash := secret.Annotations[k8sapi.AnnotationContentHash]
+	if len(hash) == 0 {
+		hash = k8sapi.ContentHash(secret.Data)
+	}
+
+	return Result{
+		Kind:      "Secret",
+		Name:      secret.Name,
+		Namespace: secret.Namespace,
+		Action:    action,
+		Hash:      hash,
+		Keys:      len(secret.Data),
+	}
+}
+
+// ValidateFormat checks that the output format is supported.
+//
+// Example usage:
+// if err := output.ValidateFormat(o.output); err != nil {
+// return err
+// }
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatText, FormatJSON, FormatYAML:
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q, must be one of: json, yaml (or empty for text)", format)
+}
+
+// Print renders a result in the given format.
+//
+// Text is meant for humans. JSON results are written one per line, so the
+// output of commands reporting several results (e.g. in watch mode) can be
+// consumed as JSON Lines. YAML results are written as separate documents.
+//
+// Parameters:
+// - w: Writer to print to, usually the command output stream.
+// - format: One of FormatText, FormatJSON or FormatYAML.
+// - result: The result to print.
+//
+// Returns:
+// - An error if the format is unknown or the result cannot be written.
+//
+// Example usage:
+// err := output.Print(o.Out, o.output, result)
+func Print(w io.Writer, format string, result Result) error {
+	switch format {
+	case FormatText:
+		_, err := fmt.Fprintln(w, text(result))
+		return err
+	}
+	return Encode(w, format, result)
+}
+
+// Encode renders a value in a machine readable format, the same way as Print
+// renders results. It lets commands reporting more than a Result, such as
+// who-uses, share the output formats of the other commands.
+//
+// Parameters:
+// - w: Writer to print to, usually the command output stream.
+// - format: FormatJSON or FormatYAML.
+// - v: The value to encode.
+//
+// Returns:
+// - An error if the format is not machine readable or the value cannot be written.
+//
+// Example usage:
+// err := output.Encode(o.Out, output.FormatJSON, report)
+func Encode(w io.Writer, format string, v any) error {
+	switch format {
+	case FormatJSON:
+		return json.NewEncoder(w).Encode(v)
+	case FormatYAML:
+		content, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "---\n%s", content)
+		return err
+	}
+	if err := ValidateFormat(format); err != nil {
+		return err
+	}
+	return fmt.Errorf("output format %q is not machine readable", format)
+}
+
+// text renders a result as a single human readable line.
+func text(result Result) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s/%s", strings.ToLower(result.Kind), result.Name)
+	if result.DryRun {
+		fmt.Fprintf(&b, " would be %s", result.Action)
+	} else {
+		fmt.Fprintf(&b, " %s", result.Action)
+	}
+	if result.Revision > 0 {
+		fmt.Fprintf(&b, " to revision %d", result.Revision)
+	}
+	if len(result.Target) > 0 {
+		preposition := "to"
+		if result.Action == ActionUnchanged {
+			preposition = "in"
+		}
+		fmt.Fprintf(&b, " %s %s", preposition, result.Target)
+		if result.Version > 0 {
+			fmt.Fprintf(&b, " version %d", result.Version)
+		}
+	}
+	fmt.Fprintf(&b, " in namespace %s (%d keys", result.Namespace, result.Keys)
+	if len(result.Hash) > 0 {
+		fmt.Fprintf(&b, ", %s", ShortHash(result.Hash))
+	}
+	b.WriteString(")")
+	if result.DryRun {
+		b.WriteString(" (dry run)")
+	}
+	if len(result.Changes) > 0 {
+		fmt.Fprintf(&b, ": %s", result.Changes)
+	}
+
+	return b.String()
+}
+
+// ShortHash shortens a "sha256:<hex>" hash for display.
+//
+// Example usage:
+// fmt.Println(output.ShortHash(secret.Annotations[k8sapi.AnnotationContentHash])) // Output: sha256:2c26b46b68ff
+func ShortHash(hash string) string {
+	const length = len("sha256:") + 12
+	if len(hash) > length {
+		return hash[:length]
+	}
+	return hash
+}
+
+// Size formats a number of bytes for display, using binary units.
+//
+// Example usage:
+// fmt.Println(output.Size(1536)) // Output: 1.5KiB
+func Size(n int) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	value, exp := float64(n)/unit, 0
+	for value >= unit && exp < 3 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", value, "KMGT"[exp])
+}
@@ -0,0 +1,158 @@
+package output_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/ogticrd/kubectl-envsecret/internal/output"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func mockSecret() *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "api",
+			Namespace: "production",
+			Annotations: map[string]string{
+				k8sapi.AnnotationContentHash: "sha256:0123456789abcdef0123456789abcdef",
+			},
+		},
+		Data: map[string][]byte{"A": []byte("1"), "B": []byte("2")},
+	}
+}
+
+func TestNewResult(t *testing.T) {
+	result := output.NewResult(mockSecret(), output.ActionCreated)
+	assert.Equal(t, output.Result{
+		Kind:      "Secret",
+		Name:      "api",
+		Namespace: "production",
+		Action:    output.ActionCreated,
+		Hash:      "sha256:0123456789abcdef0123456789abcdef",
+		Keys:      2,
+	}, result)
+
+	secret := mockSecret()
+	secret.Annotations = nil
+	assert.Equal(t, k8sapi.ContentHash(secret.Data), output.NewResult(secret, output.ActionCreated).Hash)
+}
+
+func TestPrint(t *testing.T) {
+	result := output.NewResult(mockSecret(), output.ActionUpdated)
+	result.Changes = "+B ~A"
+
+	tests := []struct {
+		name     string
+		format   string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "Text",
+			format:   output.FormatText,
+			expected: "secret/api updated in namespace production (2 keys, sha256:0123456789ab): +B ~A\n",
+		},
+		{
+			name:     "JSON",
+			format:   output.FormatJSON,
+			expected: `{"kind":"Secret","name":"api","namespace":"production","action":"updated","hash":"sha256:0123456789abcdef0123456789abcdef","changes":"+B ~A","keys":2}` + "\n",
+		},
+		{
+			name:   "YAML",
+			format: output.FormatYAML,
+			expected: `---
+action: updated
+changes: +B ~A
+hash: sha256:0123456789abcdef0123456789abcdef
+keys: 2
+kind: Secret
+name: api
+namespace: production
+`,
+		},
+		{
+			name:    "Unknown format",
+			format:  "xml",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := output.Print(&out, tt.format, result)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.expected, out.String())
+		})
+	}
+}
+
+func TestPrintDryRun(t *testing.T) {
+	result := output.NewResult(mockSecret(), output.ActionCreated)
+	result.DryRun = true
+
+	var out bytes.Buffer
+	require.Nil(t, output.Print(&out, output.FormatText, result))
+	assert.Equal(t, "secret/api would be created in namespace production (2 keys, sha256:0123456789ab) (dry run)\n", out.String())
+
+	out.Reset()
+	require.Nil(t, output.Print(&out, output.FormatJSON, result))
+	var decoded map[string]interface{}
+	require.Nil(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, true, decoded["dryRun"])
+}
+
+func TestPrintTarget(t *testing.T) {
+	result := output.NewResult(mockSecret(), output.ActionExported)
+	result.Target = "vault secret/api"
+	result.Version = 3
+
+	var out bytes.Buffer
+	require.Nil(t, output.Print(&out, output.FormatText, result))
+	assert.Equal(t, "secret/api exported to vault secret/api version 3 in namespace production (2 keys, sha256:0123456789ab)\n", out.String())
+
+	result.Action = output.ActionUnchanged
+	out.Reset()
+	require.Nil(t, output.Print(&out, output.FormatText, result))
+	assert.Equal(t, "secret/api unchanged in vault secret/api version 3 in namespace production (2 keys, sha256:0123456789ab)\n", out.String())
+
+	out.Reset()
+	require.Nil(t, output.Print(&out, output.FormatJSON, result))
+	assert.Contains(t, out.String(), `"target":"vault secret/api","keys":2,"version":3`)
+}
+
+func TestEncode(t *testing.T) {
+	report := map[string][]string{"unused": {"DEBUG"}}
+
+	var out bytes.Buffer
+	require.Nil(t, output.Encode(&out, output.FormatJSON, report))
+	assert.Equal(t, `{"unused":["DEBUG"]}`+"\n", out.String())
+
+	out.Reset()
+	require.Nil(t, output.Encode(&out, output.FormatYAML, report))
+	assert.Equal(t, "---\nunused:\n- DEBUG\n", out.String())
+
+	assert.ErrorContains(t, output.Encode(&out, output.FormatText, report), "not machine readable")
+	assert.ErrorContains(t, output.Encode(&out, "xml", report), "unknown output format")
+}
+
+func TestSize(t *testing.T) {
+	assert.Equal(t, "0B", output.Size(0))
+	assert.Equal(t, "1023B", output.Size(1023))
+	assert.Equal(t, "1.5KiB", output.Size(1536))
+	assert.Equal(t, "2.0MiB", output.Size(2*1024*1024))
+}
+
+func TestShortHash(t *testing.T) {
+	assert.Equal(t, "sha256:0123456789ab", output.ShortHash("sha256:0123456789abcdef"))
+	assert.Equal(t, "sha256:01", output.ShortHash("sha256:01"))
+}
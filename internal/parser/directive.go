@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DirectivePrefix starts the values that ask for a generated value instead of
+// holding one, e.g. DB_PASSWORD=!generate:32:alnum.
+const DirectivePrefix = "!generate:"
+
+// Charsets available to random string directives.
+var charsets = map[string]string{
+	"alnum":   "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789",
+	"alpha":   "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+	"numeric": "0123456789",
+	"hex":     "0123456789abcdef",
+	"ascii":   "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789!#%&()*+,-./:;<=>?@[]^_{|}~",
+}
+
+// rsaSizes are the supported sizes of generated RSA keys, in bits.
+var rsaSizes = map[int]bool{2048: true, 3072: true, 4096: true}
+
+// maxLength is the longest random string a directive can ask for.
+const maxLength = 4096
+
+// Directive describes a value to generate.
+type Directive struct {
+	Charset string // Characters of a random string, e.g. "alnum". Empty for keys.
+	Length  int    // Length of a random string.
+	RSABits int    // Size of an RSA private key, in bits. Zero for random strings.
+}
+
+// ParseDirective parses a generate directive.
+//
+// Two forms are supported:
+//   - !generate:LENGTH[:CHARSET] generates a random string of LENGTH
+//     characters from CHARSET, one of alnum (default), alpha, numeric, hex or
+//     ascii.
+//   - !generate:rsa-BITS generates a PEM encoded PKCS #8 RSA private key of
+//     2048, 3072 or 4096 bits.
+//
+// Parameters:
+// - value: The value of a key.
+//
+// Returns:
+// - The directive, or nil if the value is not a directive.
+// - An error if the value starts with DirectivePrefix but is not a valid directive.
+//
+// Example usage:
+// directive, err := parser.ParseDirective("!generate:32:alnum")
+func ParseDirective(value string) (*Directive, error) {
+	spec, ok := strings.CutPrefix(value, DirectivePrefix)
+	if !ok {
+		return nil, nil
+	}
+
+	if bits, ok := strings.CutPrefix(spec, "rsa-"); ok {
+		n, err := strconv.Atoi(bits)
+		if err != nil || !rsaSizes[n] {
+			return nil, fmt.Errorf("invalid directive %q: RSA keys must have 2048, 3072 or 4096 bits", value)
+		}
+		return &Directive{RSABits: n}, nil
+	}
+
+	length, charset, _ := strings.Cut(spec, ":")
+	if len(charset) == 0 {
+		charset = "alnum"
+	}
+	n, err := strconv.Atoi(length)
+	if err != nil || n < 1 || n > maxLength {
+		return nil, fmt.Errorf("invalid directive %q: length must be a number between 1 and %d", value, maxLength)
+	}
+	if _, ok := charsets[charset]; !ok {
+		return nil, fmt.Errorf("invalid directive %q: unknown charset %q, must be one of: alnum, alpha, numeric, hex, ascii", value, charset)
+	}
+	return &Directive{Charset: charset, Length: n}, nil
+}
+
+// Generate returns a new value for the directive, read from crypto/rand.
+//
+// Example usage:
+// directive, _ := parser.ParseDirective("!generate:rsa-2048")
+// pemKey, err := directive.Generate()
+func (d *Directive) Generate() (string, error) {
+	if d.RSABits > 0 {
+		key, err := rsa.GenerateKey(rand.Reader, d.RSABits)
+		if err != nil {
+			return "", err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return "", err
+		}
+		return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+	}
+
+	chars := charsets[d.Charset]
+	max := big.NewInt(int64(len(chars)))
+	b := make([]byte, d.Length)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = chars[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// Directives returns the keys whose values are generate directives.
+//
+// Parameters:
+// - values: The parsed values.
+//
+// Returns:
+// - The directives by key.
+// - An error naming the key of the first invalid directive, by key order.
+//
+// Example usage:
+// directives, err := parser.Directives(envVars)
+func Directives(values map[string]string) (map[string]*Directive, error) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	directives := make(map[string]*Directive)
+	for _, key := range keys {
+		directive, err := ParseDirective(values[key])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		if directive != nil {
+			directives[key] = directive
+		}
+	}
+	return directives, nil
+}
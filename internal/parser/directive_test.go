@@ -0,0 +1,84 @@
+package parser_test
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"regexp"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDirective(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected *parser.Directive
+		err      string
+	}{
+		{value: "plain"},
+		{value: "generate:32"},
+		{value: "!generate:32", expected: &parser.Directive{Charset: "alnum", Length: 32}},
+		{value: "!generate:16:hex", expected: &parser.Directive{Charset: "hex", Length: 16}},
+		{value: "!generate:rsa-2048", expected: &parser.Directive{RSABits: 2048}},
+		{value: "!generate:rsa-1024", err: "2048, 3072 or 4096 bits"},
+		{value: "!generate:0", err: "length must be a number"},
+		{value: "!generate:abc", err: "length must be a number"},
+		{value: "!generate:8:emoji", err: `unknown charset "emoji"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			directive, err := parser.ParseDirective(tt.value)
+			if len(tt.err) > 0 {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, directive)
+		})
+	}
+}
+
+func TestDirectiveGenerate(t *testing.T) {
+	for charset, pattern := range map[string]string{
+		"alnum":   `^[A-Za-z0-9]{40}$`,
+		"alpha":   `^[A-Za-z]{40}$`,
+		"numeric": `^[0-9]{40}$`,
+		"hex":     `^[0-9a-f]{40}$`,
+		"ascii":   `^[!-~]{40}$`,
+	} {
+		value, err := (&parser.Directive{Charset: charset, Length: 40}).Generate()
+		require.NoError(t, err)
+		assert.Regexp(t, regexp.MustCompile(pattern), value, charset)
+
+		other, err := (&parser.Directive{Charset: charset, Length: 40}).Generate()
+		require.NoError(t, err)
+		assert.NotEqual(t, value, other, charset)
+	}
+
+	value, err := (&parser.Directive{RSABits: 2048}).Generate()
+	require.NoError(t, err)
+	block, _ := pem.Decode([]byte(value))
+	require.NotNil(t, block)
+	assert.Equal(t, "PRIVATE KEY", block.Type)
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	require.NoError(t, err)
+	assert.Equal(t, 2048, key.(*rsa.PrivateKey).N.BitLen())
+}
+
+func TestDirectives(t *testing.T) {
+	directives, err := parser.Directives(map[string]string{
+		"DB_PASSWORD": "!generate:32:alnum",
+		"JWT_KEY":     "!generate:rsa-2048",
+		"HOST":        "db",
+	})
+	require.NoError(t, err)
+	assert.Len(t, directives, 2)
+	assert.Contains(t, directives, "DB_PASSWORD")
+	assert.Contains(t, directives, "JWT_KEY")
+
+	_, err = parser.Directives(map[string]string{"B": "!generate:x", "A": "!generate:rsa-1"})
+	assert.ErrorContains(t, err, "A: invalid directive")
+}
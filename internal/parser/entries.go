@@ -0,0 +1,259 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnbalancedQuotes is wrapped by the SyntaxError returned when a quoted
+// value is never closed.
+var ErrUnbalancedQuotes = errors.New("unbalanced quotes")
+
+// Entry is a key-value pair of a .env file along with its position.
+type Entry struct {
+	Key         string // Name of the variable.
+	Value       string // Value after removing quotes and inline comments.
+	Raw         string // Value as written in the file, including quotes and inline comments.
+	Line        int    // Line of the key, starting at 1.
+	Column      int    // Column of the key, starting at 1.
+	ValueLine   int    // Line where the value starts.
+	ValueColumn int    // Column where the value starts.
+	Quote       byte   // Quote character surrounding the value, or 0 if unquoted.
+}
+
+// SyntaxError describes a line of a .env file that cannot be parsed.
+type SyntaxError struct {
+	Err    error // Description of the problem.
+	Line   int   // Line of the problem, starting at 1.
+	Column int   // Column of the problem, starting at 1.
+}
+
+// Error implements the error interface.
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Err)
+}
+
+// Unwrap returns the underlying error, so errors.Is can match ErrUnbalancedQuotes.
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// ParseEntries parses the content of a .env file keeping track of where each
+// entry is defined.
+//
+// Unlike Load, which only returns the resulting variables, ParseEntries keeps
+// every entry in order, including duplicated keys, and reports the line and
+// column of keys and values, so tools like linters can point at problems. It
+// accepts the same syntax as Load: comments, "export" prefixes, KEY=VALUE and
+// KEY: VALUE pairs, and single or double quoted values spanning several lines.
+//
+// Parsing stops at the first syntax error. Both LF and CRLF line endings are
+// accepted. A leading UTF-8 BOM, which Load rejects, is skipped so the rest of
+// the file can still be checked.
+//
+// Parameters:
+// - content: The content of the .env file.
+//
+// Returns:
+// - The entries found before the first syntax error, in order.
+// - A *SyntaxError if the content cannot be parsed completely.
+//
+// Example usage:
+// content, _ := os.ReadFile(".env")
+// entries, err := parser.ParseEntries(content)
+//
+//	for _, entry := range entries {
+//	    fmt.Printf("%d:%d %s\n", entry.Line, entry.Column, entry.Key)
+//	}
+func ParseEntries(content []byte) ([]Entry, error) {
+	s := &scanner{src: string(bytes.TrimPrefix(content, []byte("\uFEFF"))), line: 1, col: 1}
+
+	var entries []Entry
+	for !s.done() {
+		s.skipBlank()
+		if s.done() {
+			break
+		}
+		if s.peek() == '#' || s.peek() == '\n' || s.peek() == '\r' {
+			s.skipLine()
+			continue
+		}
+
+		entry, err := s.entry()
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// scanner walks the content of a .env file tracking line and column.
+type scanner struct {
+	src  string
+	pos  int
+	line int
+	col  int
+}
+
+func (s *scanner) done() bool {
+	return s.pos >= len(s.src)
+}
+
+func (s *scanner) peek() byte {
+	return s.src[s.pos]
+}
+
+func (s *scanner) next() byte {
+	c := s.src[s.pos]
+	s.pos++
+	if c == '\n' {
+		s.line++
+		s.col = 1
+	} else {
+		s.col++
+	}
+	return c
+}
+
+// skipBlank skips spaces and tabs, but not line breaks.
+func (s *scanner) skipBlank() {
+	for !s.done() && (s.peek() == ' ' || s.peek() == '\t') {
+		s.next()
+	}
+}
+
+// skipLine skips everything up to and including the next line break.
+func (s *scanner) skipLine() {
+	for !s.done() {
+		if s.next() == '\n' {
+			return
+		}
+	}
+}
+
+// restOfLine returns the text up to the next line break, excluding it and any
+// carriage return before it.
+func (s *scanner) restOfLine() string {
+	start := s.pos
+	for !s.done() && s.peek() != '\n' {
+		s.next()
+	}
+	return strings.TrimSuffix(s.src[start:s.pos], "\r")
+}
+
+func (s *scanner) errorf(line, col int, format string, args ...interface{}) error {
+	return &SyntaxError{Err: fmt.Errorf(format, args...), Line: line, Column: col}
+}
+
+func (s *scanner) entry() (Entry, error) {
+	entry := Entry{Line: s.line, Column: s.col}
+
+	key := s.key()
+	if key == "export" {
+		s.skipBlank()
+		if !s.done() && s.peek() != '=' && s.peek() != ':' {
+			entry.Line, entry.Column = s.line, s.col
+			key = s.key()
+		}
+	}
+	if len(key) == 0 {
+		return entry, s.errorf(s.line, s.col, "expected a variable name")
+	}
+	entry.Key = key
+
+	s.skipBlank()
+	if s.done() || (s.peek() != '=' && s.peek() != ':') {
+		return entry, s.errorf(s.line, s.col, "expected '=' after %s", key)
+	}
+	s.next()
+	s.skipBlank()
+
+	entry.ValueLine, entry.ValueColumn = s.line, s.col
+	start := s.pos
+	if !s.done() && (s.peek() == '"' || s.peek() == '\'') {
+		entry.Quote = s.next()
+		value, err := s.quoted(entry.Quote, entry.ValueLine, entry.ValueColumn)
+		if err != nil {
+			return entry, err
+		}
+		entry.Value = value
+		entry.Raw = s.src[start:s.pos] + s.restOfLine()
+		s.skipLine()
+		return entry, nil
+	}
+
+	entry.Raw = s.restOfLine()
+	s.skipLine()
+	entry.Value = unquotedValue(entry.Raw)
+	return entry, nil
+}
+
+// key reads a variable name: everything up to a separator or whitespace.
+func (s *scanner) key() string {
+	start := s.pos
+	for !s.done() {
+		switch s.peek() {
+		case '=', ':', ' ', '\t', '\n', '\r':
+			return s.src[start:s.pos]
+		}
+		s.next()
+	}
+	return s.src[start:s.pos]
+}
+
+// quoted reads a quoted value after its opening quote, up to the closing one.
+func (s *scanner) quoted(quote byte, line, col int) (string, error) {
+	var b strings.Builder
+	for !s.done() {
+		c := s.next()
+		switch {
+		case c == quote:
+			return b.String(), nil
+		case c == '\\' && quote == '\'' && !s.done() && s.peek() == quote:
+			// Single quoted values are kept verbatim, but an escaped quote does not close them.
+			b.WriteByte(c)
+			b.WriteByte(s.next())
+		case c == '\\' && quote == '"' && !s.done():
+			escaped := s.next()
+			switch escaped {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(escaped)
+			}
+		case c == '\r' && !s.done() && s.peek() == '\n':
+			// Values keep LF line breaks regardless of the file line endings.
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", s.errorf(line, col, "%w: value opened with %c is never closed", ErrUnbalancedQuotes, quote)
+}
+
+// unquotedValue removes inline comments and surrounding spaces from an unquoted
+// value. Like godotenv, the comment starts at the last '#' preceded by a space.
+func unquotedValue(raw string) string {
+	return strings.TrimSpace(raw[:InlineCommentIndex(raw)])
+}
+
+// InlineCommentIndex returns the index where the inline comment of an unquoted
+// value starts, or len(raw) if it has none.
+//
+// Example usage:
+// i := InlineCommentIndex("value # comment") // Output: 6
+func InlineCommentIndex(raw string) int {
+	for i := len(raw) - 1; i > 0; i-- {
+		if raw[i] == '#' && (raw[i-1] == ' ' || raw[i-1] == '\t') {
+			return i
+		}
+	}
+	return len(raw)
+}
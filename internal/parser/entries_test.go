@@ -0,0 +1,110 @@
+package parser_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/joho/godotenv"
+	"github.com/ogticrd/kubectl-envsecret/internal/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEntries(t *testing.T) {
+	content := "# comment\n" +
+		"\n" +
+		"export A=1\n" +
+		"  B = two words # comment\n" +
+		"C: 'single'\n" +
+		"D=\"multi\nline\"\n" +
+		"E=a#b\n" +
+		"A=3\r\n"
+
+	entries, err := parser.ParseEntries([]byte(content))
+	require.NoError(t, err)
+	require.Len(t, entries, 6)
+
+	assert.Equal(t, parser.Entry{Key: "A", Value: "1", Raw: "1", Line: 3, Column: 8, ValueLine: 3, ValueColumn: 10}, entries[0])
+	assert.Equal(t, parser.Entry{Key: "B", Value: "two words", Raw: "two words # comment", Line: 4, Column: 3, ValueLine: 4, ValueColumn: 7}, entries[1])
+	assert.Equal(t, parser.Entry{Key: "C", Value: "single", Raw: "'single'", Line: 5, Column: 1, ValueLine: 5, ValueColumn: 4, Quote: '\''}, entries[2])
+	assert.Equal(t, parser.Entry{Key: "D", Value: "multi\nline", Raw: "\"multi\nline\"", Line: 6, Column: 1, ValueLine: 6, ValueColumn: 3, Quote: '"'}, entries[3])
+	assert.Equal(t, "a#b", entries[4].Value)
+	assert.Equal(t, parser.Entry{Key: "A", Value: "3", Raw: "3", Line: 9, Column: 1, ValueLine: 9, ValueColumn: 3}, entries[5])
+}
+
+func TestParseEntriesMatchesGodotenv(t *testing.T) {
+	contents := []string{
+		"A=1\nB=2",
+		"A=value # comment\nB=a#b\nC=a # b # c",
+		"A=1\r\nB=\"x\\ny\"\r\n",
+		"export A='single quoted'\nB=\"say \\\"hi\\\" now\"",
+		"A=\"multi\nline\nvalue\"\nB=",
+		"A: 1\nB : 2",
+	}
+
+	for _, content := range contents {
+		expected, err := godotenv.UnmarshalBytes([]byte(content))
+		require.NoError(t, err, content)
+
+		entries, err := parser.ParseEntries([]byte(content))
+		require.NoError(t, err, content)
+
+		actual := make(map[string]string, len(entries))
+		for _, entry := range entries {
+			actual[entry.Key] = entry.Value
+		}
+		assert.Equal(t, expected, actual, content)
+	}
+}
+
+func TestParseEntriesErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		content        string
+		expectedLine   int
+		expectedColumn int
+		unbalanced     bool
+	}{
+		{
+			name:           "Unbalanced double quotes",
+			content:        "A=1\nB=\"open\nC=2\n",
+			expectedLine:   2,
+			expectedColumn: 3,
+			unbalanced:     true,
+		},
+		{
+			name:           "Unbalanced single quotes",
+			content:        "A='open",
+			expectedLine:   1,
+			expectedColumn: 3,
+			unbalanced:     true,
+		},
+		{
+			name:           "Missing separator",
+			content:        "A=1\nJUSTAKEY\n",
+			expectedLine:   2,
+			expectedColumn: 9,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := parser.ParseEntries([]byte(tt.content))
+
+			var syntaxErr *parser.SyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			assert.Equal(t, tt.expectedLine, syntaxErr.Line)
+			assert.Equal(t, tt.expectedColumn, syntaxErr.Column)
+			assert.Equal(t, tt.unbalanced, errors.Is(err, parser.ErrUnbalancedQuotes))
+			if tt.expectedLine > 1 {
+				assert.Len(t, entries, 1)
+			}
+		})
+	}
+}
+
+func TestInlineCommentIndex(t *testing.T) {
+	assert.Equal(t, 6, parser.InlineCommentIndex("value # comment"))
+	assert.Equal(t, 3, parser.InlineCommentIndex("a#b"))
+	assert.Equal(t, 6, parser.InlineCommentIndex("a # b # c"))
+}
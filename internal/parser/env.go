@@ -5,7 +5,7 @@
 package parser
 
 import (
-	"log"
+	"fmt"
 
 	"github.com/joho/godotenv"
 )
@@ -15,8 +15,8 @@ import (
 // This function takes one or more filenames as input and reads the environment
 // variables from these files. It returns a map where the keys are the variable
 // names and the values are the corresponding values from the .env files. If an
-// error occurs while reading any of the files, the function logs the error and
-// returns the error along with the partially loaded map.
+// error occurs while reading any of the files, the function returns the error
+// and a nil map, so callers re-reading files that are being edited can retry.
 //
 // Parameters:
 // - filenames: A variadic parameter specifying the .env files to be loaded.
@@ -36,8 +36,29 @@ import (
 func Load(filenames ...string) (map[string]string, error) {
 	envConfig, err := godotenv.Read(filenames...)
 	if err != nil {
-		log.Panic("Error loading file(s):", filenames, "\n Error:", err)
-		return envConfig, err
+		return nil, fmt.Errorf("error loading file(s) %v: %w", filenames, err)
+	}
+	return envConfig, nil
+}
+
+// Parse reads .env content from memory and returns it as a map.
+//
+// It accepts the same syntax as Load and is meant for content that does not
+// come straight from a file, such as rendered templates.
+//
+// Parameters:
+// - content: The .env content.
+//
+// Returns:
+// - A map containing the environment variables and their values.
+// - An error if the content cannot be parsed.
+//
+// Example usage:
+// envVars, err := parser.Parse([]byte("KEY=value\n"))
+func Parse(content []byte) (map[string]string, error) {
+	envConfig, err := godotenv.UnmarshalBytes(content)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing env content: %w", err)
 	}
 	return envConfig, nil
 }
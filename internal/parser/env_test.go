@@ -105,3 +105,18 @@ func TestLoad(t *testing.T) {
 		})
 	}
 }
+
+func TestParse(t *testing.T) {
+	result, err := parser.Parse([]byte("KEY1=VALUE1\nKEY2=\"multi\nline\"\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{"KEY1": "VALUE1", "KEY2": "multi\nline"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+
+	if _, err := parser.Parse([]byte("KEY=\"unterminated\n")); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
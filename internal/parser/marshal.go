@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// plainValue matches values that can be written without quotes.
+var plainValue = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,=-]*$`)
+
+// Marshal writes the data of a secret as the content of a .env file, sorted by
+// key.
+//
+// Values are written without quotes when possible, and otherwise single or
+// double quoted, keeping their line breaks. Every value is checked to parse
+// back to itself. Values that cannot be written as text, such as binary data,
+// are left out.
+//
+// Parameters:
+// - data: The data of the secret.
+//
+// Returns:
+// - The content of the file.
+// - The keys left out, sorted.
+//
+// Example usage:
+// content, left := parser.Marshal(secret.Data)
+func Marshal(data map[string][]byte) ([]byte, []string) {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b bytes.Buffer
+	var left []string
+	for _, key := range keys {
+		value, ok := encode(key, data[key])
+		if !ok {
+			left = append(left, key)
+			continue
+		}
+		fmt.Fprintf(&b, "%s=%s\n", key, value)
+	}
+	return b.Bytes(), left
+}
+
+// encode returns the value as written in a .env file, trying the plainest
+// form first. It reports false if no form parses back to the value.
+func encode(key string, value []byte) (string, bool) {
+	if !utf8.Valid(value) || bytes.IndexByte(value, 0) >= 0 {
+		return "", false
+	}
+
+	s := string(value)
+	var candidates []string
+	if plainValue.MatchString(s) {
+		candidates = append(candidates, s)
+	}
+	candidates = append(candidates, "'"+s+"'")
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\r", `\r`)
+	candidates = append(candidates, `"`+escaper.Replace(s)+`"`)
+
+	for _, candidate := range candidates {
+		parsed, err := Parse([]byte(key + "=" + candidate + "\n"))
+		if err == nil && len(parsed) == 1 && parsed[key] == s {
+			return candidate, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,34 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshal(t *testing.T) {
+	data := map[string][]byte{
+		"API_TOKEN": []byte("tok_123"),
+		"EMPTY":     []byte(""),
+		"GREETING":  []byte("hello world # not a comment"),
+		"PRICE":     []byte("$5 or \"five\""),
+		"TLS_KEY":   []byte("-----BEGIN KEY-----\nabc\n-----END KEY-----\n"),
+		"WINDOWS":   []byte("a\r\nb'c"),
+		"BINARY":    {0xff, 0x00, 0x01},
+		"ENDS":      []byte(`it's a "quote"`),
+	}
+
+	content, left := parser.Marshal(data)
+	assert.Equal(t, []string{"BINARY", "ENDS"}, left)
+	assert.Contains(t, string(content), "API_TOKEN=tok_123\n")
+	assert.Contains(t, string(content), "TLS_KEY='-----BEGIN KEY-----\nabc\n-----END KEY-----\n'\n")
+
+	values, err := parser.Parse(content)
+	require.NoError(t, err)
+	for key, value := range values {
+		assert.Equal(t, string(data[key]), value, key)
+	}
+	assert.Len(t, values, len(data)-len(left))
+}
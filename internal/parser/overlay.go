@@ -0,0 +1,40 @@
+package parser
+
+import "os"
+
+// Overlay expands .env file paths following the dotenv-flow convention.
+//
+// Each path is followed by its local, overlay and local overlay variants, e.g.
+// .env, .env.local, .env.production and .env.production.local, so later
+// files, which are more specific, take precedence when loaded in order. The
+// .env.local variant is skipped for the test overlay, so tests give the same
+// results on every machine. Variants that do not exist are left out, while
+// the given paths are always kept.
+//
+// Parameters:
+// - paths: Paths of the base .env files.
+// - overlay: Name of the overlay, usually an environment such as production.
+//
+// Returns:
+// - The paths to load, in order of precedence from lowest to highest.
+//
+// Example usage:
+// paths := parser.Overlay([]string{".env"}, "production")
+// envVars, err := parser.Load(paths...)
+func Overlay(paths []string, overlay string) []string {
+	var result []string
+	for _, path := range paths {
+		result = append(result, path)
+
+		variants := []string{path + ".local", path + "." + overlay, path + "." + overlay + ".local"}
+		if overlay == "test" {
+			variants = variants[1:]
+		}
+		for _, variant := range variants {
+			if info, err := os.Stat(variant); err == nil && !info.IsDir() {
+				result = append(result, variant)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,80 @@
+package parser_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverlay(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{".env", ".env.local", ".env.production", ".env.production.local", ".env.test", "api.env", "api.env.production"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("A=1\n"), 0644))
+	}
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	tests := []struct {
+		name     string
+		overlay  string
+		paths    []string
+		expected []string
+	}{
+		{
+			name:     "Every variant",
+			overlay:  "production",
+			paths:    []string{path(".env")},
+			expected: []string{path(".env"), path(".env.local"), path(".env.production"), path(".env.production.local")},
+		},
+		{
+			name:     "Missing variants are skipped",
+			overlay:  "staging",
+			paths:    []string{path(".env")},
+			expected: []string{path(".env"), path(".env.local")},
+		},
+		{
+			name:     "Local file is skipped for tests",
+			overlay:  "test",
+			paths:    []string{path(".env")},
+			expected: []string{path(".env"), path(".env.test")},
+		},
+		{
+			name:     "Several base files",
+			overlay:  "production",
+			paths:    []string{path("api.env"), path(".env")},
+			expected: []string{path("api.env"), path("api.env.production"), path(".env"), path(".env.local"), path(".env.production"), path(".env.production.local")},
+		},
+		{
+			name:     "Missing base files are kept",
+			overlay:  "production",
+			paths:    []string{path("missing.env")},
+			expected: []string{path("missing.env")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parser.Overlay(tt.paths, tt.overlay))
+		})
+	}
+}
+
+func TestOverlayPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".env":                  "A=base\nB=base\nC=base\nD=base\n",
+		".env.local":            "B=local\n",
+		".env.production":       "B=production\nC=production\n",
+		".env.production.local": "D=production-local\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	values, err := parser.Load(parser.Overlay([]string{filepath.Join(dir, ".env")}, "production")...)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"A": "base", "B": "production", "C": "production", "D": "production-local"}, values)
+}
@@ -0,0 +1,236 @@
+package parser
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Password manager exports hold one item per credential. Their values are
+// turned into keys named after the item, such as STRIPE_API for the password
+// of the "Stripe API" item, STRIPE_API_USERNAME for its username and
+// STRIPE_API_SECRET_KEY for its "secret key" custom field.
+
+// OnePasswordSource reads a 1Password export (.1pux).
+//
+// Archived items are left out.
+type OnePasswordSource struct {
+	Path string
+}
+
+// onePasswordExport is the part of the export.data file of a .1pux archive
+// holding the items.
+type onePasswordExport struct {
+	Accounts []struct {
+		Vaults []struct {
+			Items []onePasswordItem `json:"items"`
+		} `json:"vaults"`
+	} `json:"accounts"`
+}
+
+// onePasswordItem is an item of a 1Password export.
+type onePasswordItem struct {
+	State    string `json:"state"`
+	Overview struct {
+		Title string `json:"title"`
+	} `json:"overview"`
+	Details struct {
+		Password    string `json:"password"`
+		LoginFields []struct {
+			Value       string `json:"value"`
+			Designation string `json:"designation"`
+		} `json:"loginFields"`
+		Sections []struct {
+			Fields []struct {
+				Value map[string]any `json:"value"`
+				Title string         `json:"title"`
+			} `json:"fields"`
+		} `json:"sections"`
+	} `json:"details"`
+}
+
+// Load reads the items of the export.
+func (s *OnePasswordSource) Load(_ context.Context) (map[string]string, error) {
+	archive, err := zip.OpenReader(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("error loading export %s: %w", s.Path, err)
+	}
+	defer archive.Close()
+
+	file, err := archive.Open("export.data")
+	if err != nil {
+		return nil, fmt.Errorf("error loading export %s: %w", s.Path, err)
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("error loading export %s: %w", s.Path, err)
+	}
+
+	var export onePasswordExport
+	if err := json.Unmarshal(content, &export); err != nil {
+		return nil, fmt.Errorf("error loading export %s: %w", s.Path, err)
+	}
+
+	values := make(map[string]string)
+	for _, account := range export.Accounts {
+		for _, vault := range account.Vaults {
+			for _, item := range vault.Items {
+				if item.State == "archived" {
+					continue
+				}
+				if err := addOnePasswordItem(values, s.Path, item); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return values, nil
+}
+
+// File returns the path of the export.
+func (s *OnePasswordSource) File() string {
+	return s.Path
+}
+
+// addOnePasswordItem adds the password, username and custom fields of an item.
+func addOnePasswordItem(values map[string]string, path string, item onePasswordItem) error {
+	name := item.Overview.Title
+	password := item.Details.Password
+	var username string
+	for _, field := range item.Details.LoginFields {
+		switch field.Designation {
+		case "password":
+			if len(password) == 0 {
+				password = field.Value
+			}
+		case "username":
+			username = field.Value
+		}
+	}
+
+	if err := addExportValue(values, path, exportKey(name), password); err != nil {
+		return err
+	}
+	if err := addExportValue(values, path, exportKey(name, "username"), username); err != nil {
+		return err
+	}
+	for _, section := range item.Details.Sections {
+		for _, field := range section.Fields {
+			// Values are keyed by their type, e.g. {"concealed": "..."}.
+			var value string
+			for _, v := range field.Value {
+				if s, ok := v.(string); ok {
+					value = s
+					break
+				}
+			}
+			if err := addExportValue(values, path, exportKey(name, field.Title), value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// BitwardenSource reads an unencrypted Bitwarden JSON export.
+type BitwardenSource struct {
+	Path string
+}
+
+// bitwardenExport is a Bitwarden JSON export.
+type bitwardenExport struct {
+	Items []struct {
+		Login *struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"login"`
+		Name   string `json:"name"`
+		Fields []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"fields"`
+	} `json:"items"`
+	Encrypted bool `json:"encrypted"`
+}
+
+// Load reads the items of the export.
+func (s *BitwardenSource) Load(_ context.Context) (map[string]string, error) {
+	content, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("error loading export %s: %w", s.Path, err)
+	}
+
+	var export bitwardenExport
+	if err := json.Unmarshal(content, &export); err != nil {
+		return nil, fmt.Errorf("error loading export %s: %w", s.Path, err)
+	}
+	if export.Encrypted {
+		return nil, fmt.Errorf("error loading export %s: encrypted exports are not supported, export the vault as unencrypted JSON", s.Path)
+	}
+
+	values := make(map[string]string)
+	for _, item := range export.Items {
+		if item.Login != nil {
+			if err := addExportValue(values, s.Path, exportKey(item.Name), item.Login.Password); err != nil {
+				return nil, err
+			}
+			if err := addExportValue(values, s.Path, exportKey(item.Name, "username"), item.Login.Username); err != nil {
+				return nil, err
+			}
+		}
+		for _, field := range item.Fields {
+			if err := addExportValue(values, s.Path, exportKey(item.Name, field.Name), field.Value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return values, nil
+}
+
+// File returns the path of the export.
+func (s *BitwardenSource) File() string {
+	return s.Path
+}
+
+// addExportValue adds a value read from a password manager export, refusing
+// keys defined twice, which would silently drop one of the values.
+func addExportValue(values map[string]string, path string, key string, value string) error {
+	if len(key) == 0 || len(value) == 0 {
+		return nil
+	}
+	if _, ok := values[key]; ok {
+		return fmt.Errorf("error loading export %s: key %s is defined by more than one item", path, key)
+	}
+	values[key] = value
+	return nil
+}
+
+// exportKey turns the names of an item and a field of a password manager
+// export into an environment variable name, e.g. "Stripe API" and "secret key"
+// into STRIPE_API_SECRET_KEY.
+func exportKey(names ...string) string {
+	var b strings.Builder
+	for _, name := range names {
+		for _, r := range strings.ToUpper(name) {
+			switch {
+			case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+				b.WriteRune(r)
+			case b.Len() > 0 && !strings.HasSuffix(b.String(), "_"):
+				b.WriteByte('_')
+			}
+		}
+		if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+			b.WriteByte('_')
+		}
+	}
+	key := strings.TrimSuffix(b.String(), "_")
+	if len(key) > 0 && key[0] >= '0' && key[0] <= '9' {
+		key = "_" + key
+	}
+	return key
+}
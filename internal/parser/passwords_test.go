@@ -0,0 +1,110 @@
+package parser_test
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const onePasswordData = `{
+  "accounts": [{
+    "vaults": [{
+      "attrs": {"name": "Production"},
+      "items": [
+        {
+          "state": "active",
+          "overview": {"title": "Stripe API"},
+          "details": {
+            "loginFields": [
+              {"value": "acct", "designation": "username", "name": "username"},
+              {"value": "sk_live_123", "designation": "password", "name": "password"}
+            ],
+            "sections": [{"fields": [
+              {"title": "webhook secret", "value": {"concealed": "whsec_456"}}
+            ]}]
+          }
+        },
+        {
+          "state": "active",
+          "overview": {"title": "Database"},
+          "details": {"password": "db-pass"}
+        },
+        {
+          "state": "archived",
+          "overview": {"title": "Old"},
+          "details": {"password": "old-pass"}
+        }
+      ]
+    }]
+  }]
+}`
+
+func TestOnePasswordSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.1pux")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	archive := zip.NewWriter(file)
+	entry, err := archive.Create("export.data")
+	require.NoError(t, err)
+	_, err = entry.Write([]byte(onePasswordData))
+	require.NoError(t, err)
+	require.NoError(t, archive.Close())
+	require.NoError(t, file.Close())
+
+	source, err := parser.NewSource(path)
+	require.NoError(t, err)
+	values, err := source.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"STRIPE_API":                "sk_live_123",
+		"STRIPE_API_USERNAME":       "acct",
+		"STRIPE_API_WEBHOOK_SECRET": "whsec_456",
+		"DATABASE":                  "db-pass",
+	}, values)
+
+	_, err = (&parser.OnePasswordSource{Path: filepath.Join(t.TempDir(), "missing.1pux")}).Load(context.Background())
+	assert.ErrorContains(t, err, "error loading export")
+}
+
+func TestBitwardenSource(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	path := write("vault.json", `{
+  "encrypted": false,
+  "items": [
+    {"name": "smtp", "login": {"username": "mailer", "password": "p@ss"}, "fields": [{"name": "host", "value": "mail.example.com"}]},
+    {"name": "2fa backup", "type": 2, "notes": "ignored"}
+  ]
+}`)
+	values, err := (&parser.BitwardenSource{Path: path}).Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"SMTP":          "p@ss",
+		"SMTP_USERNAME": "mailer",
+		"SMTP_HOST":     "mail.example.com",
+	}, values)
+
+	path = write("encrypted.json", `{"encrypted": true, "items": []}`)
+	_, err = (&parser.BitwardenSource{Path: path}).Load(context.Background())
+	assert.ErrorContains(t, err, "encrypted exports are not supported")
+
+	path = write("duplicated.json", `{"items": [{"name": "api key", "login": {"password": "1"}}, {"name": "API-KEY", "login": {"password": "2"}}]}`)
+	_, err = (&parser.BitwardenSource{Path: path}).Load(context.Background())
+	assert.ErrorContains(t, err, "key API_KEY is defined by more than one item")
+
+	path = write("numeric.json", `{"items": [{"name": "1st token", "login": {"password": "1"}}]}`)
+	values, err = (&parser.BitwardenSource{Path: path}).Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"_1ST_TOKEN": "1"}, values)
+}
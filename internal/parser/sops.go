@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// sopsWaitDelay is how long to wait for the output of sops to be closed once
+// it was killed.
+const sopsWaitDelay = time.Second
+
+// SopsSource decrypts a file encrypted with SOPS (https://github.com/getsops/sops).
+//
+// Decryption is done by the sops binary, which must be in the PATH and have
+// access to the keys the file was encrypted with. The file may be in any
+// format sops supports, as long as its values are not nested.
+type SopsSource struct {
+	Path string
+}
+
+// Load decrypts the file and parses the decrypted values.
+// sops is killed when ctx is cancelled, e.g. while it waits for a GPG
+// passphrase or a KMS that does not answer.
+func (s *SopsSource) Load(ctx context.Context) (map[string]string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sops", "--decrypt", "--output-type", "dotenv", s.Path)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Processes started by sops, such as gpg-agent, may keep the output open.
+	cmd.WaitDelay = sopsWaitDelay
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("error decrypting %s with sops: %w", s.Path, ctx.Err())
+		}
+		if message := strings.TrimSpace(stderr.String()); len(message) > 0 {
+			return nil, fmt.Errorf("error decrypting %s with sops: %w: %s", s.Path, err, message)
+		}
+		return nil, fmt.Errorf("error decrypting %s with sops: %w", s.Path, err)
+	}
+
+	values, err := Parse(stdout.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("error loading decrypted file %s: %w", s.Path, err)
+	}
+	return values, nil
+}
+
+// File returns the path of the encrypted file.
+func (s *SopsSource) File() string {
+	return s.Path
+}
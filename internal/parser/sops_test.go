@@ -0,0 +1,56 @@
+package parser_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSops puts a sops script running the given shell code first in the PATH.
+func fakeSops(t *testing.T, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake sops binary is a shell script")
+	}
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sops"), []byte("#!/bin/sh\n"+script), 0755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestSopsSource(t *testing.T) {
+	fakeSops(t, `[ "$*" = "--decrypt --output-type dotenv secrets.enc.env" ] || exit 2
+printf 'DB_PASSWORD=s3cret\nAPI_TOKEN=abc\n'
+`)
+
+	source := &parser.SopsSource{Path: "secrets.enc.env"}
+	values, err := source.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"DB_PASSWORD": "s3cret", "API_TOKEN": "abc"}, values)
+	assert.Equal(t, "secrets.enc.env", source.File())
+}
+
+func TestSopsSourceError(t *testing.T) {
+	fakeSops(t, "echo 'Failed to get the data key' >&2\nexit 128\n")
+
+	_, err := (&parser.SopsSource{Path: "secrets.enc.env"}).Load(context.Background())
+	assert.ErrorContains(t, err, "error decrypting secrets.enc.env with sops")
+	assert.ErrorContains(t, err, "Failed to get the data key")
+}
+
+func TestSopsSourceCancelled(t *testing.T) {
+	fakeSops(t, "exec sleep 10\n")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := (&parser.SopsSource{Path: "secrets.enc.env"}).Load(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
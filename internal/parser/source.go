@@ -0,0 +1,173 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// URI schemes of the sources accepted by NewSource.
+const (
+	SchemeFile        = "file"
+	SchemeEnv         = "env"
+	SchemeSops        = "sops"
+	SchemeOnePassword = "onepassword"
+	SchemeBitwarden   = "bitwarden"
+)
+
+// Source is an origin of key=value pairs, such as a .env file or the
+// environment of the current process.
+type Source interface {
+	// Load reads the values of the source. Sources reading them over the
+	// network or from other programs stop when ctx is cancelled.
+	Load(ctx context.Context) (map[string]string, error)
+	// File returns the local file the values are read from, or an empty
+	// string if they do not come from a file.
+	File() string
+}
+
+// NewSource returns the source a URI refers to, selected by its scheme:
+//   - file://PATH, or a plain path, reads a .env file. Plain paths ending in
+//     .1pux are read as 1Password exports.
+//   - env://PREFIX takes the variables of the current process whose names
+//     start with PREFIX, keeping their names.
+//   - sops://PATH decrypts a file encrypted with SOPS, using the sops binary.
+//   - onepassword://PATH reads a 1Password export (.1pux).
+//   - bitwarden://PATH reads an unencrypted Bitwarden JSON export.
+//
+// Parameters:
+// - uri: The URI of the source.
+//
+// Returns:
+// - The source.
+// - An error if the scheme is unknown or the URI has no path or prefix.
+//
+// Example usage:
+// source, err := parser.NewSource("env://APP_")
+func NewSource(uri string) (Source, error) {
+	scheme, rest, ok := strings.Cut(uri, "://")
+	if !ok {
+		if strings.HasSuffix(strings.ToLower(uri), ".1pux") {
+			return &OnePasswordSource{Path: uri}, nil
+		}
+		return &FileSource{Path: uri}, nil
+	}
+
+	if len(rest) == 0 {
+		if scheme == SchemeEnv {
+			return nil, fmt.Errorf("invalid source %q: a prefix is required, e.g. env://APP_", uri)
+		}
+		return nil, fmt.Errorf("invalid source %q: a path is required", uri)
+	}
+
+	switch scheme {
+	case SchemeFile:
+		return &FileSource{Path: rest}, nil
+	case SchemeEnv:
+		return &EnvSource{Prefix: rest}, nil
+	case SchemeSops:
+		return &SopsSource{Path: rest}, nil
+	case SchemeOnePassword:
+		return &OnePasswordSource{Path: rest}, nil
+	case SchemeBitwarden:
+		return &BitwardenSource{Path: rest}, nil
+	}
+	return nil, fmt.Errorf("invalid source %q: unknown scheme %q, must be one of: file, env, sops, onepassword, bitwarden", uri, scheme)
+}
+
+// NewSources returns the sources of several URIs, as NewSource does.
+//
+// Example usage:
+// sources, err := parser.NewSources([]string{".env", "env://APP_"})
+func NewSources(uris []string) ([]Source, error) {
+	sources := make([]Source, 0, len(uris))
+	for _, uri := range uris {
+		source, err := NewSource(uri)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// LoadSources reads the sources in order and merges their values. Later
+// sources take precedence, as with Load.
+//
+// Parameters:
+// - ctx: Context for sources reading values over the network or from other programs.
+// - sources: The sources to read.
+//
+// Returns:
+// - A map containing the merged values.
+// - An error if any of the sources cannot be read.
+//
+// Example usage:
+// sources, _ := parser.NewSources([]string{".env", "env://APP_"})
+// envVars, err := parser.LoadSources(ctx, sources...)
+func LoadSources(ctx context.Context, sources ...Source) (map[string]string, error) {
+	merged := make(map[string]string)
+	for _, source := range sources {
+		values, err := source.Load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range values {
+			merged[key] = value
+		}
+	}
+	return merged, nil
+}
+
+// FileSource reads a .env file.
+type FileSource struct {
+	Path string
+}
+
+// Load reads the .env file, as Load does.
+func (s *FileSource) Load(_ context.Context) (map[string]string, error) {
+	return Load(s.Path)
+}
+
+// File returns the path of the .env file.
+func (s *FileSource) File() string {
+	return s.Path
+}
+
+// EnvSource takes a snapshot of the environment of the current process.
+type EnvSource struct {
+	Prefix string // Only variables whose names start with Prefix are taken.
+}
+
+// Load returns the variables of the environment whose names start with the prefix.
+func (s *EnvSource) Load(_ context.Context) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, entry := range os.Environ() {
+		key, value, _ := strings.Cut(entry, "=")
+		if len(key) > 0 && strings.HasPrefix(key, s.Prefix) {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+// File returns an empty string, the values do not come from a file.
+func (s *EnvSource) File() string {
+	return ""
+}
+
+// SourceFiles returns the local files the sources are read from, leaving out
+// sources that do not read files.
+//
+// Example usage:
+// paths := parser.SourceFiles(sources) // Output: [.env secrets.enc.env]
+func SourceFiles(sources []Source) []string {
+	var paths []string
+	for _, source := range sources {
+		if path := source.File(); len(path) > 0 {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
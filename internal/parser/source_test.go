@@ -0,0 +1,65 @@
+package parser_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSource(t *testing.T) {
+	tests := []struct {
+		expected parser.Source
+		uri      string
+		err      string
+	}{
+		{uri: ".env", expected: &parser.FileSource{Path: ".env"}},
+		{uri: "file://config/.env", expected: &parser.FileSource{Path: "config/.env"}},
+		{uri: "env://APP_", expected: &parser.EnvSource{Prefix: "APP_"}},
+		{uri: "sops://secrets.enc.env", expected: &parser.SopsSource{Path: "secrets.enc.env"}},
+		{uri: "onepassword://export.1pux", expected: &parser.OnePasswordSource{Path: "export.1pux"}},
+		{uri: "backup.1PUX", expected: &parser.OnePasswordSource{Path: "backup.1PUX"}},
+		{uri: "bitwarden://vault.json", expected: &parser.BitwardenSource{Path: "vault.json"}},
+		{uri: "env://", err: "a prefix is required"},
+		{uri: "sops://", err: "a path is required"},
+		{uri: "vault://kv/api", err: `unknown scheme "vault"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			source, err := parser.NewSource(tt.uri)
+			if len(tt.err) > 0 {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, source)
+		})
+	}
+}
+
+func TestLoadSources(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	require.NoError(t, os.WriteFile(path, []byte("APP_NAME=file\nAPP_PORT=8080\n"), 0600))
+	t.Setenv("APP_NAME", "env")
+	t.Setenv("APPLICATION", "other")
+
+	sources, err := parser.NewSources([]string{"file://" + path, "env://APP_"})
+	require.NoError(t, err)
+
+	values, err := parser.LoadSources(context.Background(), sources...)
+	require.NoError(t, err)
+	assert.Equal(t, "env", values["APP_NAME"])
+	assert.Equal(t, "8080", values["APP_PORT"])
+	assert.NotContains(t, values, "APPLICATION")
+
+	assert.Equal(t, []string{path}, parser.SourceFiles(sources))
+
+	_, err = parser.LoadSources(context.Background(), &parser.FileSource{Path: filepath.Join(dir, "missing")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,162 @@
+// Package render provides utilities for rendering .env files written as Go
+// templates.
+//
+// Templates can read the environment of the process, values from YAML files
+// and other files, so near-identical .env files for several environments can
+// be generated from a single one.
+//
+// Example template:
+//
+//	REGION={{ .Env.REGION }}
+//	DATABASE_URL=postgres://{{ .Values.db.host }}:5432/app
+//	TLS_CERT={{ file "certs/tls.crt" | quote }}
+//	CA_BUNDLE={{ file "certs/ca.pem" | b64enc }}
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Data is the data available to templates.
+type Data struct {
+	Env    map[string]string      // Environment variables of the process, as .Env.
+	Values map[string]interface{} // Values read from YAML files, as .Values.
+}
+
+// NewData returns the data for rendering templates with the environment of the
+// current process and the values of the given YAML files.
+//
+// Values files are merged in order, so later files take precedence. Nested
+// maps are merged key by key.
+//
+// Parameters:
+// - valuesPaths: Paths of the YAML values files.
+//
+// Returns:
+// - The data for rendering templates.
+// - An error if a values file cannot be read or is not a YAML map.
+//
+// Example usage:
+// data, err := render.NewData("values.yaml", "values.production.yaml")
+func NewData(valuesPaths ...string) (*Data, error) {
+	data := &Data{
+		Env:    make(map[string]string),
+		Values: make(map[string]interface{}),
+	}
+
+	for _, entry := range os.Environ() {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			data.Env[key] = value
+		}
+	}
+
+	for _, path := range valuesPaths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var values map[string]interface{}
+		if err := yaml.Unmarshal(content, &values); err != nil {
+			return nil, fmt.Errorf("invalid values file %s: %w", path, err)
+		}
+		mergeValues(data.Values, values)
+	}
+
+	return data, nil
+}
+
+// mergeValues merges src into dst, key by key for nested maps.
+func mergeValues(dst, src map[string]interface{}) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergeValues(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// Render executes the template in the given file and returns the result.
+//
+// Missing environment variables and values are errors, instead of being
+// rendered as empty strings. Besides the standard functions, templates can use:
+//   - file PATH: the content of a file, relative to the template.
+//   - b64enc STRING: the base64 encoding of a string.
+//   - b64dec STRING: the decoding of a base64 string.
+//   - quote STRING: the string as a double quoted .env value, keeping line
+//     breaks, quotes, backslashes and dollar signs as they are. Strings
+//     ending with a quote or a backslash cannot be quoted.
+//
+// Parameters:
+// - path: Path of the template file.
+// - data: The data available to the template.
+//
+// Returns:
+// - The rendered content.
+// - An error if the template cannot be read, parsed or executed.
+//
+// Example usage:
+// data, _ := render.NewData("values.yaml")
+// content, err := render.Render(".env.tmpl", data)
+// envVars, err := parser.Parse(content)
+func Render(path string, data *Data) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(path)
+	funcs := template.FuncMap{
+		"file": func(name string) (string, error) {
+			if !filepath.IsAbs(name) {
+				name = filepath.Join(dir, name)
+			}
+			content, err := os.ReadFile(name)
+			return string(content), err
+		},
+		"b64enc": func(s string) string {
+			return base64.StdEncoding.EncodeToString([]byte(s))
+		},
+		"b64dec": func(s string) (string, error) {
+			decoded, err := base64.StdEncoding.DecodeString(s)
+			return string(decoded), err
+		},
+		"quote": quote,
+	}
+
+	tmpl, err := template.New(filepath.Base(path)).Funcs(funcs).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template %s: %w", path, err)
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return nil, fmt.Errorf("error rendering template %s: %w", path, err)
+	}
+
+	return out.Bytes(), nil
+}
+
+// quote returns s as a double quoted .env value. Characters with a special
+// meaning inside double quotes are escaped, so the parsed value is s.
+//
+// godotenv cannot read back double quoted values ending with a quote or a
+// backslash, so those are rejected instead of being silently changed.
+func quote(s string) (string, error) {
+	if strings.HasSuffix(s, `"`) || strings.HasSuffix(s, `\`) {
+		return "", fmt.Errorf("values ending with a quote or a backslash cannot be quoted, use b64enc instead")
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`)
+	return `"` + replacer.Replace(s) + `"`, nil
+}
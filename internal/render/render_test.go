@@ -0,0 +1,116 @@
+package render_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/parser"
+	"github.com/ogticrd/kubectl-envsecret/internal/render"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestNewData(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, "values.yaml", "db:\n  host: db.internal\n  port: 5432\nreplicas: 1\n")
+	prod := writeFile(t, dir, "values.prod.yaml", "db:\n  host: db.prod\nreplicas: 3\n")
+	t.Setenv("RENDER_TEST_REGION", "us-east-1")
+
+	data, err := render.NewData(base, prod)
+	require.NoError(t, err)
+	assert.Equal(t, "us-east-1", data.Env["RENDER_TEST_REGION"])
+	assert.Equal(t, map[string]interface{}{
+		"db":       map[string]interface{}{"host": "db.prod", "port": float64(5432)},
+		"replicas": float64(3),
+	}, data.Values)
+
+	_, err = render.NewData(writeFile(t, dir, "list.yaml", "- a\n- b\n"))
+	assert.Error(t, err)
+
+	_, err = render.NewData(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestRender(t *testing.T) {
+	dir := t.TempDir()
+	cert := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+	writeFile(t, dir, "certs/tls.crt", cert)
+	writeFile(t, dir, "weird.txt", "say \"hi\" to $HOME \\o/ ")
+
+	data := &render.Data{
+		Env:    map[string]string{"REGION": "us-east-1"},
+		Values: map[string]interface{}{"db": map[string]interface{}{"host": "db.prod"}},
+	}
+
+	tests := []struct {
+		expected map[string]string
+		name     string
+		template string
+		wantErr  bool
+	}{
+		{
+			name:     "Environment and values",
+			template: "REGION={{ .Env.REGION }}\nDATABASE_URL=postgres://{{ .Values.db.host }}:5432/app\n",
+			expected: map[string]string{"REGION": "us-east-1", "DATABASE_URL": "postgres://db.prod:5432/app"},
+		},
+		{
+			name:     "Files and functions",
+			template: "TLS_CERT={{ file \"certs/tls.crt\" | quote }}\nTLS_CERT_B64={{ file \"certs/tls.crt\" | b64enc }}\nDECODED={{ \"aGVsbG8=\" | b64dec }}\nWEIRD={{ file \"weird.txt\" | quote }}\n",
+			expected: map[string]string{
+				"TLS_CERT":     cert,
+				"TLS_CERT_B64": "LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0tCk1JSUIKLS0tLS1FTkQgQ0VSVElGSUNBVEUtLS0tLQo=",
+				"DECODED":      "hello",
+				"WEIRD":        "say \"hi\" to $HOME \\o/ ",
+			},
+		},
+		{
+			name:     "Missing environment variable",
+			template: "A={{ .Env.MISSING }}\n",
+			wantErr:  true,
+		},
+		{
+			name:     "Missing value",
+			template: "A={{ .Values.missing }}\n",
+			wantErr:  true,
+		},
+		{
+			name:     "Missing file",
+			template: "A={{ file \"missing.pem\" }}\n",
+			wantErr:  true,
+		},
+		{
+			name:     "Unquotable value",
+			template: "A={{ \"ends with a quote\\\"\" | quote }}\n",
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid template",
+			template: "A={{ .Env.REGION\n",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := render.Render(writeFile(t, dir, ".env.tmpl", tt.template), data)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			values, err := parser.Parse(content)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, values)
+		})
+	}
+}
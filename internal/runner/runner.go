@@ -0,0 +1,149 @@
+// Package runner provides utilities for running a command with the values of
+// a secret in its environment.
+//
+// Values are handed to the child process through its environment only, they
+// are never written to disk.
+package runner
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// forwardedSignals are relayed to the child process, so it can shut down
+// gracefully when the plugin is interrupted or terminated.
+var forwardedSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT}
+
+// Environ merges the data of a secret into an environment in the form of
+// os.Environ.
+//
+// Keys are prefixed with prefix before merging. Variables already set in the
+// environment win over the secret, unless override is true. Keys that are not
+// valid environment variable names are left out and returned, sorted.
+//
+// Parameters:
+// - environ: The base environment, as "KEY=value" entries.
+// - data: The data of the secret.
+// - prefix: Prefix added to every key of the secret.
+// - override: Whether the secret wins over variables already set.
+//
+// Returns:
+// - The merged environment, as "KEY=value" entries.
+// - The keys that were left out.
+//
+// Example usage:
+// env, skipped := runner.Environ(os.Environ(), secret.Data, "APP_", false)
+func Environ(environ []string, data map[string][]byte, prefix string, override bool) ([]string, []string) {
+	index := make(map[string]int, len(environ))
+	merged := make([]string, 0, len(environ)+len(data))
+	for _, entry := range environ {
+		key, _, _ := strings.Cut(entry, "=")
+		if i, ok := index[key]; ok {
+			// Duplicated entries: the last one wins, as with exec.Cmd.
+			merged[i] = entry
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, entry)
+	}
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var skipped []string
+	for _, key := range keys {
+		name := prefix + key
+		if len(validation.IsEnvVarName(name)) > 0 {
+			skipped = append(skipped, key)
+			continue
+		}
+
+		entry := name + "=" + string(data[key])
+		if i, ok := index[name]; ok {
+			if override {
+				merged[i] = entry
+			}
+			continue
+		}
+		index[name] = len(merged)
+		merged = append(merged, entry)
+	}
+
+	return merged, skipped
+}
+
+// Run runs a command with the given environment and waits for it to exit.
+//
+// The command is connected to the given streams. Interrupt, terminate, hangup
+// and quit signals received while it runs are forwarded to it.
+//
+// Parameters:
+// - name: The command to run, looked up in PATH when it has no separators.
+// - args: The arguments of the command.
+// - env: The environment of the command, as "KEY=value" entries.
+// - stdin, stdout, stderr: The streams of the command.
+//
+// Returns:
+// - The exit code of the command. Commands killed by a signal exit with 128
+// plus the signal number, as in shells.
+// - An error if the command cannot be started.
+//
+// Example usage:
+// code, err := runner.Run("./myservice", nil, env, os.Stdin, os.Stdout, os.Stderr)
+func Run(name string, args []string, env []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Env = env
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	// Start listening before starting the command, so no signal is lost.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
+
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				_ = cmd.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := cmd.Wait()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return 0, err
+	}
+
+	return exitCode(cmd.ProcessState), nil
+}
+
+// exitCode returns the exit code of a process, following the shell
+// convention of 128 plus the signal number for processes killed by a signal.
+func exitCode(state *os.ProcessState) int {
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return state.ExitCode()
+}
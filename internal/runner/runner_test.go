@@ -0,0 +1,93 @@
+package runner_test
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"runtime"
+	"syscall"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/runner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnviron(t *testing.T) {
+	environ := []string{"PATH=/usr/bin", "API_TOKEN=local", "HOME=/root", "PATH=/bin"}
+	data := map[string][]byte{
+		"API_TOKEN": []byte("remote"),
+		"DB_URL":    []byte("postgres://db"),
+		"tls.crt":   []byte("cert"),
+	}
+
+	env, skipped := runner.Environ(environ, data, "", false)
+	assert.Equal(t, []string{"PATH=/bin", "API_TOKEN=local", "HOME=/root", "DB_URL=postgres://db", "tls.crt=cert"}, env)
+	assert.Empty(t, skipped)
+
+	env, _ = runner.Environ(environ, data, "", true)
+	assert.Equal(t, []string{"PATH=/bin", "API_TOKEN=remote", "HOME=/root", "DB_URL=postgres://db", "tls.crt=cert"}, env)
+
+	env, _ = runner.Environ(environ, data, "APP_", false)
+	assert.Equal(t, []string{"PATH=/bin", "API_TOKEN=local", "HOME=/root", "APP_API_TOKEN=remote", "APP_DB_URL=postgres://db", "APP_tls.crt=cert"}, env)
+}
+
+func TestEnvironInvalidKeys(t *testing.T) {
+	data := map[string][]byte{"GOOD": []byte("1"), "1BAD=": []byte("2"), "": []byte("3")}
+
+	env, skipped := runner.Environ(nil, data, "", false)
+	assert.Equal(t, []string{"GOOD=1"}, env)
+	assert.Equal(t, []string{"", "1BAD="}, skipped)
+}
+
+func skipOnWindows(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+}
+
+func TestRun(t *testing.T) {
+	skipOnWindows(t)
+
+	var stdout, stderr bytes.Buffer
+	code, err := runner.Run("sh", []string{"-c", `read line; echo "$line $GREETING"; echo oops >&2; exit 3`}, []string{"GREETING=world"}, bytes.NewBufferString("hello\n"), &stdout, &stderr)
+	require.NoError(t, err)
+	assert.Equal(t, 3, code)
+	assert.Equal(t, "hello world\n", stdout.String())
+	assert.Equal(t, "oops\n", stderr.String())
+}
+
+func TestRunKilledBySignal(t *testing.T) {
+	skipOnWindows(t)
+
+	code, err := runner.Run("sh", []string{"-c", "kill -KILL $$"}, nil, nil, io.Discard, io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, 128+int(syscall.SIGKILL), code)
+}
+
+func TestRunForwardsSignals(t *testing.T) {
+	skipOnWindows(t)
+
+	reader, writer := io.Pipe()
+	go func() {
+		// Signal the plugin once the child has installed its trap.
+		line, _ := bufio.NewReader(reader).ReadString('\n')
+		if line == "ready\n" {
+			process, _ := os.FindProcess(os.Getpid())
+			process.Signal(syscall.SIGTERM)
+		}
+		io.Copy(io.Discard, reader)
+	}()
+
+	code, err := runner.Run("sh", []string{"-c", `trap "exit 7" TERM; echo ready; while :; do sleep 0.05; done`}, nil, nil, writer, io.Discard)
+	writer.Close()
+	require.NoError(t, err)
+	assert.Equal(t, 7, code)
+}
+
+func TestRunNotFound(t *testing.T) {
+	_, err := runner.Run("kubectl-envsecret-does-not-exist", nil, nil, nil, io.Discard, io.Discard)
+	assert.Error(t, err)
+}
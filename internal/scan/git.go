@@ -0,0 +1,217 @@
+package scan
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TrackedFiles returns the files, among paths, that are tracked by a git
+// repository.
+//
+// The repository is found by looking for a .git directory, or a .git file
+// pointing to one, in the parent directories of each path, and its index is
+// read directly, so git does not need to be installed. Files outside any
+// repository are not tracked.
+//
+// Parameters:
+// - paths: Paths of the files to check.
+//
+// Returns:
+// - One finding per tracked file, in the order of paths.
+// - An error if a repository index exists but cannot be read.
+//
+// Example usage:
+// findings, err := scan.TrackedFiles([]string{".env", ".env.production"})
+func TrackedFiles(paths []string) ([]Finding, error) {
+	// Indexes are cached per repository, as all the files usually live in
+	// the same one.
+	indexes := map[string]map[string]struct{}{}
+
+	var findings []Finding
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+			abs = resolved
+		}
+
+		root, gitDir, err := findRepository(filepath.Dir(abs))
+		if err != nil {
+			return nil, err
+		}
+		if len(root) == 0 {
+			continue
+		}
+
+		index, ok := indexes[gitDir]
+		if !ok {
+			index, err = readIndex(filepath.Join(gitDir, "index"))
+			if err != nil {
+				return nil, err
+			}
+			indexes[gitDir] = index
+		}
+
+		rel, err := filepath.Rel(root, abs)
+		if err != nil {
+			return nil, err
+		}
+		if _, tracked := index[filepath.ToSlash(rel)]; tracked {
+			findings = append(findings, Finding{
+				Rule:    RuleGitTracked,
+				File:    path,
+				Message: "file is tracked by git, remove it with git rm --cached and add it to .gitignore",
+			})
+		}
+	}
+
+	return findings, nil
+}
+
+// findRepository looks for the git repository containing dir and returns its
+// working tree root and git directory, or empty strings if there is none.
+func findRepository(dir string) (string, string, error) {
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		info, err := os.Stat(dotGit)
+		switch {
+		case err == nil && info.IsDir():
+			return dir, dotGit, nil
+		case err == nil:
+			// Worktrees and submodules use a file pointing to the git directory.
+			gitDir, err := readGitFile(dotGit)
+			return dir, gitDir, err
+		case !errors.Is(err, os.ErrNotExist):
+			return "", "", err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", nil
+		}
+		dir = parent
+	}
+}
+
+func readGitFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir:")
+	if !ok {
+		return "", fmt.Errorf("invalid git file %s", path)
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+	return gitDir, nil
+}
+
+// readIndex returns the paths stored in a git index file, relative to the
+// repository root and separated by slashes. A missing index, as in a new
+// repository, has no paths.
+//
+// See https://git-scm.com/docs/index-format for the format, versions 2 to 4
+// are supported.
+func readIndex(path string) (map[string]struct{}, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]struct{}{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	invalid := func(reason string) error {
+		return fmt.Errorf("invalid git index %s: %s", path, reason)
+	}
+
+	if len(content) < 12 || !bytes.Equal(content[:4], []byte("DIRC")) {
+		return nil, invalid("bad signature")
+	}
+	version := binary.BigEndian.Uint32(content[4:8])
+	if version < 2 || version > 4 {
+		return nil, invalid(fmt.Sprintf("unsupported version %d", version))
+	}
+	count := binary.BigEndian.Uint32(content[8:12])
+
+	// Each entry has 40 bytes of stat data, a 20 bytes object name and 2
+	// bytes of flags, followed by the path.
+	const fixedSize = 62
+	const extendedFlag = 0x4000
+
+	paths := make(map[string]struct{}, count)
+	offset := 12
+	var previous string
+	for i := uint32(0); i < count; i++ {
+		if offset+fixedSize > len(content) {
+			return nil, invalid("truncated entry")
+		}
+		flags := binary.BigEndian.Uint16(content[offset+60 : offset+62])
+		pathStart := offset + fixedSize
+		if version >= 3 && flags&extendedFlag != 0 {
+			pathStart += 2
+		}
+		if pathStart > len(content) {
+			return nil, invalid("truncated entry")
+		}
+
+		var name string
+		if version == 4 {
+			// Paths are prefix compressed: a varint with the number of bytes
+			// to remove from the previous path, then the rest of the path.
+			strip, n := readOffsetVarint(content[pathStart:])
+			if n <= 0 || strip > len(previous) {
+				return nil, invalid("bad path prefix")
+			}
+			end := bytes.IndexByte(content[pathStart+n:], 0)
+			if end < 0 {
+				return nil, invalid("unterminated path")
+			}
+			name = previous[:len(previous)-strip] + string(content[pathStart+n:pathStart+n+end])
+			offset = pathStart + n + end + 1
+		} else {
+			end := bytes.IndexByte(content[pathStart:], 0)
+			if end < 0 {
+				return nil, invalid("unterminated path")
+			}
+			name = string(content[pathStart : pathStart+end])
+			// Entries are padded with 1 to 8 NUL bytes to a multiple of 8.
+			entryLen := pathStart - offset + end
+			offset += (entryLen + 8) &^ 7
+		}
+
+		paths[name] = struct{}{}
+		previous = name
+	}
+
+	return paths, nil
+}
+
+// readOffsetVarint decodes the variable length integer used by git for
+// offsets, returning the value and the number of bytes read.
+func readOffsetVarint(b []byte) (int, int) {
+	if len(b) == 0 {
+		return 0, 0
+	}
+	value := int(b[0] & 0x7f)
+	n := 1
+	for b[n-1]&0x80 != 0 {
+		if n >= len(b) {
+			return 0, 0
+		}
+		value = ((value + 1) << 7) | int(b[n]&0x7f)
+		n++
+	}
+	return value, n
+}
@@ -0,0 +1,142 @@
+package scan_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/scan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeIndex writes a git index of the given version listing paths, which
+// must be sorted.
+func writeIndex(t *testing.T, gitDir string, version uint32, paths ...string) {
+	t.Helper()
+
+	var b bytes.Buffer
+	b.WriteString("DIRC")
+	binary.Write(&b, binary.BigEndian, version)
+	binary.Write(&b, binary.BigEndian, uint32(len(paths)))
+
+	previous := ""
+	for _, path := range paths {
+		start := b.Len()
+		b.Write(make([]byte, 60)) // stat data and object name
+		binary.Write(&b, binary.BigEndian, uint16(len(path)))
+
+		if version == 4 {
+			common := 0
+			for common < len(previous) && common < len(path) && previous[common] == path[common] {
+				common++
+			}
+			// Strip lengths in tests are below 128, so they fit in one byte.
+			b.WriteByte(byte(len(previous) - common))
+			b.WriteString(path[common:])
+			b.WriteByte(0)
+		} else {
+			b.WriteString(path)
+			padding := 8 - (b.Len()-start)%8
+			b.Write(make([]byte, padding))
+		}
+		previous = path
+	}
+	b.Write(make([]byte, 20)) // checksum
+
+	require.NoError(t, os.MkdirAll(gitDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(gitDir, "index"), b.Bytes(), 0644))
+}
+
+func touch(t *testing.T, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("A=1\n"), 0644))
+	}
+}
+
+func files(findings []scan.Finding) []string {
+	var result []string
+	for _, finding := range findings {
+		result = append(result, finding.File)
+	}
+	return result
+}
+
+func TestTrackedFiles(t *testing.T) {
+	for _, version := range []uint32{2, 3, 4} {
+		t.Run(fmt.Sprintf("Index version %d", version), func(t *testing.T) {
+			root := t.TempDir()
+			writeIndex(t, filepath.Join(root, ".git"), version, ".env", "README.md", "deploy/.env.production", "deploy/.env.staging")
+
+			tracked := filepath.Join(root, "deploy", ".env.production")
+			untracked := filepath.Join(root, "deploy", ".env.local")
+			touch(t, filepath.Join(root, ".env"), tracked, untracked)
+
+			findings, err := scan.TrackedFiles([]string{filepath.Join(root, ".env"), untracked, tracked})
+			require.NoError(t, err)
+			assert.Equal(t, []string{filepath.Join(root, ".env"), tracked}, files(findings))
+			for _, finding := range findings {
+				assert.Equal(t, scan.RuleGitTracked, finding.Rule)
+			}
+		})
+	}
+}
+
+func TestTrackedFilesGitFile(t *testing.T) {
+	root := t.TempDir()
+	worktree := filepath.Join(root, "worktree")
+	gitDir := filepath.Join(root, "repo.git", "worktrees", "main")
+	writeIndex(t, gitDir, 2, "app/.env")
+	touch(t, filepath.Join(worktree, "app", ".env"))
+	require.NoError(t, os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: ../repo.git/worktrees/main\n"), 0644))
+
+	findings, err := scan.TrackedFiles([]string{filepath.Join(worktree, "app", ".env")})
+	require.NoError(t, err)
+	assert.Len(t, findings, 1)
+}
+
+func TestTrackedFilesOutsideRepository(t *testing.T) {
+	root := t.TempDir()
+	touch(t, filepath.Join(root, ".env"))
+
+	// A repository without an index, e.g. right after git init.
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "new", ".git"), 0755))
+	touch(t, filepath.Join(root, "new", ".env"))
+
+	findings, err := scan.TrackedFiles([]string{filepath.Join(root, ".env"), filepath.Join(root, "new", ".env")})
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
+
+func TestTrackedFilesInvalidIndex(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".git", "index"), []byte("not an index"), 0644))
+	touch(t, filepath.Join(root, ".env"))
+
+	_, err := scan.TrackedFiles([]string{filepath.Join(root, ".env")})
+	assert.ErrorContains(t, err, "invalid git index")
+}
+
+func TestTrackedFilesTruncatedIndex(t *testing.T) {
+	// A version 3 entry with the extended flag set, cut right after its flags.
+	var b bytes.Buffer
+	b.WriteString("DIRC")
+	binary.Write(&b, binary.BigEndian, uint32(3))
+	binary.Write(&b, binary.BigEndian, uint32(1))
+	b.Write(make([]byte, 60))
+	binary.Write(&b, binary.BigEndian, uint16(0x4000|4))
+
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".git", "index"), b.Bytes(), 0644))
+	touch(t, filepath.Join(root, ".env"))
+
+	_, err := scan.TrackedFiles([]string{filepath.Join(root, ".env")})
+	assert.ErrorContains(t, err, "truncated entry")
+}
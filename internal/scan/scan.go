@@ -0,0 +1,129 @@
+// Package scan inspects .env values and files for content that should not
+// reach a cluster: placeholders left behind, secrets shared between
+// environments and env files committed to git.
+//
+// Findings never include the values themselves, so they are safe to print in
+// CI logs.
+package scan
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rule identifiers.
+const (
+	RulePlaceholder = "placeholder"
+	RuleSharedValue = "shared-value"
+	RuleGitTracked  = "git-tracked"
+)
+
+// Finding is a suspicious value or file.
+type Finding struct {
+	Rule    string // Identifier of the check that produced the finding.
+	File    string // File the finding refers to, if any.
+	Key     string // Key the finding refers to, if any.
+	Message string // Description of the problem.
+}
+
+// String formats the finding as FILE: KEY: MESSAGE, omitting empty parts.
+func (f Finding) String() string {
+	var parts []string
+	for _, part := range []string{f.File, f.Key, f.Message} {
+		if len(part) > 0 {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ": ") + " (" + f.Rule + ")"
+}
+
+// WarningsError is returned when findings must block an operation.
+type WarningsError struct {
+	Findings []Finding // Findings that caused the error.
+}
+
+// Error implements the error interface.
+func (e *WarningsError) Error() string {
+	return fmt.Sprintf("found %d warning(s) in the env files", len(e.Findings))
+}
+
+var (
+	// placeholderValue matches values that are placeholders as a whole.
+	placeholderValue = regexp.MustCompile(`(?i)^(change[-_ ]?me|todo|tbd|fixme|placeholder|replace[-_ ]?me|dummy|example|secret|password|x{3,}|\*{3,}|<[^>]+>|\$\{[^}]+\}|your[-_ ].+)$`)
+	// placeholderMarker matches markers left in otherwise real looking values.
+	placeholderMarker = regexp.MustCompile(`\b(CHANGEME|CHANGE_ME|TODO|FIXME|REPLACEME|REPLACE_ME)\b`)
+	// sensitiveKey matches keys whose values are expected to differ per environment.
+	sensitiveKey = regexp.MustCompile(`(?i)(pass(word|wd)?|secret|token|api[-_]?key|private[-_]?key|credential|auth|dsn|(^|_)key($|_))`)
+)
+
+// Placeholders returns the keys whose values look like placeholders, such as
+// changeme, TODO or <your-token>.
+//
+// Parameters:
+// - values: The key-value pairs parsed from .env files.
+//
+// Returns:
+// - One finding per key with a placeholder value, sorted by key.
+//
+// Example usage:
+// findings := scan.Placeholders(map[string]string{"API_TOKEN": "changeme"})
+func Placeholders(values map[string]string) []Finding {
+	var findings []Finding
+	for _, key := range sortedKeys(values) {
+		value := strings.TrimSpace(values[key])
+		if placeholderValue.MatchString(value) || placeholderMarker.MatchString(value) {
+			findings = append(findings, Finding{
+				Rule:    RulePlaceholder,
+				Key:     key,
+				Message: "value looks like a placeholder",
+			})
+		}
+	}
+	return findings
+}
+
+// SharedValues returns the sensitive keys, such as passwords, tokens and API
+// keys, whose values are identical in another environment.
+//
+// Non sensitive keys like LOG_LEVEL are expected to be shared and are not
+// reported.
+//
+// Parameters:
+// - values: The key-value pairs to upload.
+// - other: The key-value pairs of another environment.
+// - otherName: Name of the other environment, usually its file, used in messages.
+//
+// Returns:
+// - One finding per shared sensitive value, sorted by key.
+//
+// Example usage:
+// staging, _ := parser.Load(".env.staging")
+// findings := scan.SharedValues(production, staging, ".env.staging")
+func SharedValues(values, other map[string]string, otherName string) []Finding {
+	var findings []Finding
+	for _, key := range sortedKeys(values) {
+		value := values[key]
+		if len(value) == 0 || !sensitiveKey.MatchString(key) {
+			continue
+		}
+		if otherValue, ok := other[key]; ok && otherValue == value {
+			findings = append(findings, Finding{
+				Rule:    RuleSharedValue,
+				Key:     key,
+				Message: "value is identical in " + otherName,
+			})
+		}
+	}
+	return findings
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,66 @@
+package scan_test
+
+import (
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/scan"
+	"github.com/stretchr/testify/assert"
+)
+
+func keys(findings []scan.Finding) []string {
+	var result []string
+	for _, finding := range findings {
+		result = append(result, finding.Key)
+	}
+	return result
+}
+
+func TestPlaceholders(t *testing.T) {
+	values := map[string]string{
+		"A_CHANGEME":    "changeme",
+		"B_CHANGE_ME":   "Change-Me",
+		"C_TODO":        "todo",
+		"D_MARKER":      "postgres://user:TODO@db/app",
+		"E_ANGLE":       "<your-api-token>",
+		"F_XS":          "xxxxxx",
+		"G_YOUR":        "your_token_here",
+		"H_STARS":       "********",
+		"OK_URL":        "https://todo-api.example.com",
+		"OK_REAL":       "s3cr3t-Value",
+		"OK_EMPTY":      "",
+		"OK_LOWER_TODO": "fix the todo list",
+	}
+
+	findings := scan.Placeholders(values)
+	assert.Equal(t, []string{"A_CHANGEME", "B_CHANGE_ME", "C_TODO", "D_MARKER", "E_ANGLE", "F_XS", "G_YOUR", "H_STARS"}, keys(findings))
+	for _, finding := range findings {
+		assert.Equal(t, scan.RulePlaceholder, finding.Rule)
+		assert.NotContains(t, finding.String(), values[finding.Key], "values must not be printed")
+	}
+}
+
+func TestSharedValues(t *testing.T) {
+	production := map[string]string{
+		"DB_PASSWORD": "same",
+		"API_KEY":     "different",
+		"JWT_SECRET":  "same-secret",
+		"LOG_LEVEL":   "info",
+		"AUTH_TOKEN":  "",
+	}
+	staging := map[string]string{
+		"DB_PASSWORD": "same",
+		"API_KEY":     "other",
+		"JWT_SECRET":  "same-secret",
+		"LOG_LEVEL":   "info",
+		"AUTH_TOKEN":  "",
+	}
+
+	findings := scan.SharedValues(production, staging, ".env.staging")
+	assert.Equal(t, []string{"DB_PASSWORD", "JWT_SECRET"}, keys(findings))
+	assert.Equal(t, "DB_PASSWORD: value is identical in .env.staging (shared-value)", findings[0].String())
+}
+
+func TestFindingString(t *testing.T) {
+	finding := scan.Finding{Rule: scan.RuleGitTracked, File: ".env", Message: "file is tracked by git"}
+	assert.Equal(t, ".env: file is tracked by git (git-tracked)", finding.String())
+}
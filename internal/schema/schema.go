@@ -0,0 +1,341 @@
+// Package schema provides utilities for validating .env files against a
+// .env.schema file.
+//
+// A schema is a subset of JSON Schema, written in YAML or JSON, declaring the
+// keys a secret needs, their types, value constraints and defaults.
+//
+// Example schema:
+//
+//	required: [DATABASE_URL, API_TOKEN]
+//	properties:
+//	  DATABASE_URL:
+//	    type: url
+//	  PORT:
+//	    type: int
+//	    default: 8080
+//	  LOG_LEVEL:
+//	    enum: [debug, info, warn, error]
+//	    default: info
+//	  API_TOKEN:
+//	    pattern: "^tok_[A-Za-z0-9]{32}$"
+//	  TLS_KEY:
+//	    format: pem
+package schema
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultFile is the conventional name of a schema file.
+const DefaultFile = ".env.schema"
+
+// Schema is the content of a .env.schema file.
+type Schema struct {
+	Properties map[string]*Property `json:"properties,omitempty"` // Constraints of each key.
+	// AdditionalProperties set to false rejects keys not declared in Properties.
+	AdditionalProperties *bool    `json:"additionalProperties,omitempty"`
+	Required             []string `json:"required,omitempty"` // Keys that must be present and not empty.
+}
+
+// Property declares the constraints of a key.
+type Property struct {
+	Default     *Scalar        `json:"default,omitempty"` // Value used when an optional key is missing.
+	pattern     *regexp.Regexp // Compiled Pattern.
+	Type        string         `json:"type,omitempty"`        // One of: string, int, number, bool, url, email, pem, json.
+	Format      string         `json:"format,omitempty"`      // One of: url, email, pem, json. Checked on top of Type.
+	Pattern     string         `json:"pattern,omitempty"`     // Regular expression the value must match.
+	Description string         `json:"description,omitempty"` // Free text, ignored by the validation.
+	Enum        []Scalar       `json:"enum,omitempty"`        // Values allowed for the key.
+}
+
+// Scalar is a value of a schema that is compared with .env values, which are
+// always strings. Numbers and booleans are kept as written, so both 8080 and
+// "8080" mean the same value.
+type Scalar string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *Scalar) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*s = Scalar(str)
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch value.(type) {
+	case float64, bool:
+		*s = Scalar(data)
+		return nil
+	}
+	return fmt.Errorf("expected a string, number or boolean, got %s", data)
+}
+
+// checkers validate a value for each supported type and format. JSON Schema
+// names are accepted as aliases.
+var checkers = map[string]func(string) error{
+	"string":  func(string) error { return nil },
+	"int":     checkInt,
+	"integer": checkInt,
+	"number":  checkNumber,
+	"bool":    checkBool,
+	"boolean": checkBool,
+	"url":     checkURL,
+	"uri":     checkURL,
+	"email":   checkEmail,
+	"pem":     checkPEM,
+	"json":    checkJSON,
+}
+
+// Load reads and checks a schema file, in YAML or JSON.
+//
+// Parameters:
+// - path: Path of the schema file.
+//
+// Returns:
+// - The loaded schema.
+// - An error if the file cannot be read or declares unknown types or invalid patterns.
+//
+// Example usage:
+// s, err := schema.Load(".env.schema")
+func Load(path string) (*Schema, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unknown fields such as $schema or title are allowed, so regular JSON
+	// Schema files can be used.
+	var s Schema
+	if err := yaml.Unmarshal(content, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", path, err)
+	}
+
+	for key, property := range s.Properties {
+		if property == nil {
+			s.Properties[key] = &Property{}
+			continue
+		}
+		if err := property.compile(); err != nil {
+			return nil, fmt.Errorf("invalid schema %s: property %s: %w", path, key, err)
+		}
+	}
+
+	return &s, nil
+}
+
+func (p *Property) compile() error {
+	if _, ok := checkers[p.Type]; len(p.Type) > 0 && !ok {
+		return fmt.Errorf("unknown type %q", p.Type)
+	}
+	if _, ok := checkers[p.Format]; len(p.Format) > 0 && !ok {
+		return fmt.Errorf("unknown format %q", p.Format)
+	}
+
+	if len(p.Pattern) > 0 {
+		pattern, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+		p.pattern = pattern
+	}
+
+	if p.Default != nil {
+		if err := p.check(string(*p.Default)); err != nil {
+			return fmt.Errorf("invalid default: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// check validates a single value against the property constraints.
+func (p *Property) check(value string) error {
+	for _, name := range []string{p.Type, p.Format} {
+		if checker, ok := checkers[name]; ok {
+			if err := checker(value); err != nil {
+				return err
+			}
+		}
+	}
+
+	if p.pattern != nil && !p.pattern.MatchString(value) {
+		return fmt.Errorf("does not match pattern %s", p.Pattern)
+	}
+
+	if len(p.Enum) > 0 {
+		allowed := make([]string, 0, len(p.Enum))
+		for _, option := range p.Enum {
+			if string(option) == value {
+				return nil
+			}
+			allowed = append(allowed, string(option))
+		}
+		return fmt.Errorf("must be one of: %s", strings.Join(allowed, ", "))
+	}
+
+	return nil
+}
+
+// ValidationError lists every problem found while validating values.
+type ValidationError struct {
+	Problems []string // One entry per problem, prefixed with the key.
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d schema violation(s) found:", len(e.Problems))
+	for _, problem := range e.Problems {
+		b.WriteString("\n  - ")
+		b.WriteString(problem)
+	}
+	return b.String()
+}
+
+// Validate checks values against the schema and applies the defaults of
+// missing optional keys.
+//
+// Every problem is collected, so a single run reports all of them.
+//
+// Parameters:
+// - values: The key-value pairs parsed from .env files.
+//
+// Returns:
+// - A copy of values with defaults applied.
+// - A *ValidationError listing every problem found, or nil.
+//
+// Example usage:
+// s, _ := schema.Load(".env.schema")
+// values, err := s.Validate(parsedFile)
+func (s *Schema) Validate(values map[string]string) (map[string]string, error) {
+	result := make(map[string]string, len(values))
+	for key, value := range values {
+		result[key] = value
+	}
+
+	var problems []string
+	required := make(map[string]bool, len(s.Required))
+	for _, key := range s.Required {
+		required[key] = true
+		if len(values[key]) == 0 {
+			problems = append(problems, fmt.Sprintf("%s: is required", key))
+		}
+	}
+
+	for _, key := range sortedKeys(s.Properties) {
+		property := s.Properties[key]
+		value, ok := values[key]
+		if !ok {
+			if property.Default != nil && !required[key] {
+				result[key] = string(*property.Default)
+			}
+			continue
+		}
+		// Empty required keys are already reported, empty optional keys are allowed.
+		if len(value) == 0 {
+			continue
+		}
+		if err := property.check(value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+		}
+	}
+
+	if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+		for _, key := range sortedKeys(values) {
+			if _, ok := s.Properties[key]; !ok && !required[key] {
+				problems = append(problems, fmt.Sprintf("%s: is not declared in the schema", key))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	return result, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func checkInt(value string) error {
+	if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+		return fmt.Errorf("must be an integer")
+	}
+	return nil
+}
+
+func checkNumber(value string) error {
+	if _, err := strconv.ParseFloat(value, 64); err != nil {
+		return fmt.Errorf("must be a number")
+	}
+	return nil
+}
+
+func checkBool(value string) error {
+	if _, err := strconv.ParseBool(value); err != nil {
+		return fmt.Errorf("must be a boolean (true, false, 1 or 0)")
+	}
+	return nil
+}
+
+func checkURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || len(u.Scheme) == 0 || (len(u.Host) == 0 && len(u.Opaque) == 0) {
+		return fmt.Errorf("must be an absolute URL")
+	}
+	return nil
+}
+
+func checkEmail(value string) error {
+	address, err := mail.ParseAddress(value)
+	if err != nil || address.Address != value {
+		return fmt.Errorf("must be an email address")
+	}
+	return nil
+}
+
+func checkPEM(value string) error {
+	rest := []byte(value)
+	blocks := 0
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		blocks++
+	}
+	if blocks == 0 || len(strings.TrimSpace(string(rest))) > 0 {
+		return fmt.Errorf("must be PEM encoded")
+	}
+	return nil
+}
+
+func checkJSON(value string) error {
+	if !json.Valid([]byte(value)) {
+		return fmt.Errorf("must be valid JSON")
+	}
+	return nil
+}
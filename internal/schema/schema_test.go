@@ -0,0 +1,173 @@
+package schema_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPEM = `-----BEGIN PUBLIC KEY-----
+MCowBQYDK2VwAyEAGb9ECWmEzf6FQbrBZ9w7lshQhqowtrbLDFw4rXAxZuE=
+-----END PUBLIC KEY-----`
+
+func writeSchema(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".env.schema")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{
+			name:    "YAML schema",
+			content: "required: [A]\nproperties:\n  A:\n    type: int\n  B:\n    format: email\n    default: ops@example.com\n  C:\n",
+		},
+		{
+			name:    "JSON schema",
+			content: `{"$schema": "https://json-schema.org/draft/2020-12/schema", "title": "env", "properties": {"A": {"type": "integer", "enum": [1, 2]}}}`,
+		},
+		{
+			name:    "Unknown type",
+			content: "properties:\n  A:\n    type: date\n",
+			wantErr: true,
+		},
+		{
+			name:    "Unknown format",
+			content: "properties:\n  A:\n    format: ipv4\n",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid pattern",
+			content: "properties:\n  A:\n    pattern: '('\n",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid default",
+			content: "properties:\n  A:\n    type: bool\n    default: maybe\n",
+			wantErr: true,
+		},
+		{
+			name:    "Object enum",
+			content: "properties:\n  A:\n    enum: [{a: 1}]\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := schema.Load(writeSchema(t, tt.content))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, s)
+		})
+	}
+
+	_, err := schema.Load(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	s, err := schema.Load(writeSchema(t, `
+required: [DATABASE_URL, TOKEN]
+properties:
+  DATABASE_URL:
+    type: url
+  PORT:
+    type: int
+    default: 8080
+  DEBUG:
+    type: bool
+    default: false
+  LOG_LEVEL:
+    enum: [debug, info]
+    default: info
+  TOKEN:
+    pattern: "^tok_[a-z0-9]+$"
+  ADMIN:
+    type: email
+  TLS_CERT:
+    type: pem
+  FEATURES:
+    format: json
+  RATIO:
+    type: number
+`))
+	require.NoError(t, err)
+
+	t.Run("Valid values with defaults", func(t *testing.T) {
+		values := map[string]string{
+			"DATABASE_URL": "postgres://db:5432/app",
+			"TOKEN":        "tok_abc123",
+			"ADMIN":        "ops@example.com",
+			"TLS_CERT":     testPEM,
+			"FEATURES":     `{"beta": true}`,
+			"RATIO":        "0.5",
+			"LOG_LEVEL":    "debug",
+			"EXTRA":        "kept",
+		}
+
+		result, err := s.Validate(values)
+		require.NoError(t, err)
+		assert.Equal(t, "8080", result["PORT"])
+		assert.Equal(t, "false", result["DEBUG"])
+		assert.Equal(t, "debug", result["LOG_LEVEL"])
+		assert.Equal(t, "kept", result["EXTRA"])
+		assert.NotContains(t, values, "PORT", "input values must not be modified")
+	})
+
+	t.Run("Every problem is reported", func(t *testing.T) {
+		_, err := s.Validate(map[string]string{
+			"TOKEN":     "",
+			"PORT":      "eighty",
+			"DEBUG":     "yes please",
+			"LOG_LEVEL": "trace",
+			"ADMIN":     "Ops <ops@example.com>",
+			"TLS_CERT":  "not a certificate",
+			"FEATURES":  "{",
+			"RATIO":     "half",
+		})
+
+		var validationErr *schema.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []string{
+			"ADMIN: must be an email address",
+			"DATABASE_URL: is required",
+			"DEBUG: must be a boolean (true, false, 1 or 0)",
+			"FEATURES: must be valid JSON",
+			"LOG_LEVEL: must be one of: debug, info",
+			"PORT: must be an integer",
+			"RATIO: must be a number",
+			"TLS_CERT: must be PEM encoded",
+			"TOKEN: is required",
+		}, validationErr.Problems)
+		assert.Contains(t, err.Error(), "9 schema violation(s) found:\n  - ADMIN: must be an email address\n")
+	})
+
+	t.Run("Relative URL", func(t *testing.T) {
+		_, err := s.Validate(map[string]string{"DATABASE_URL": "/var/run/db.sock", "TOKEN": "tok_a"})
+		assert.EqualError(t, err, "1 schema violation(s) found:\n  - DATABASE_URL: must be an absolute URL")
+	})
+}
+
+func TestValidateAdditionalProperties(t *testing.T) {
+	s, err := schema.Load(writeSchema(t, "additionalProperties: false\nrequired: [A]\nproperties:\n  B: {}\n"))
+	require.NoError(t, err)
+
+	_, err = s.Validate(map[string]string{"A": "1", "B": "2"})
+	assert.NoError(t, err)
+
+	_, err = s.Validate(map[string]string{"A": "1", "C": "3"})
+	assert.EqualError(t, err, "1 schema violation(s) found:\n  - C: is not declared in the schema")
+}
@@ -0,0 +1,28 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+)
+
+// Source reads the values of a secret stored in Vault. It implements
+// parser.Source, so Vault secrets can be merged with .env files.
+type Source struct {
+	Client  *Client // Client used to read the secret.
+	Path    string  // Path of the secret, starting with the mount of the secrets engine.
+	Version int     // Version to read, or 0 for the latest one.
+}
+
+// Load reads the secret.
+func (s *Source) Load(ctx context.Context) (map[string]string, error) {
+	secret, err := s.Client.Read(ctx, s.Path, s.Version)
+	if err != nil {
+		return nil, fmt.Errorf("error reading vault secret %s: %w", s.Path, err)
+	}
+	return secret.Data, nil
+}
+
+// File returns an empty string, the values do not come from a file.
+func (s *Source) File() string {
+	return ""
+}
@@ -0,0 +1,41 @@
+package vault_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/parser"
+	"github.com/ogticrd/kubectl-envsecret/internal/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSource(t *testing.T) {
+	kv, server := newKVServer(t)
+	kv.secrets["api"] = []map[string]any{{"API_TOKEN": "one"}, {"API_TOKEN": "two"}}
+	client := vault.NewClient(server.Client(), server.URL, testToken)
+
+	var source parser.Source = &vault.Source{Client: client, Path: "secret/api"}
+	values, err := source.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_TOKEN": "two"}, values)
+	assert.Empty(t, source.File())
+
+	values, err = (&vault.Source{Client: client, Path: "secret/api", Version: 1}).Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_TOKEN": "one"}, values)
+
+	_, err = (&vault.Source{Client: client, Path: "secret/missing"}).Load(context.Background())
+	assert.ErrorContains(t, err, "error reading vault secret secret/missing")
+	assert.True(t, vault.IsNotFound(err))
+}
+
+func TestSourceCancelled(t *testing.T) {
+	_, server := newKVServer(t)
+	client := vault.NewClient(server.Client(), server.URL, testToken)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := (&vault.Source{Client: client, Path: "secret/api"}).Load(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,332 @@
+// Package vault provides a small client for the key/value secrets engine
+// version 2 of HashiCorp Vault.
+//
+// Only what is needed to move values between Vault and Kubernetes secrets is
+// covered: token authentication, reading a secret, optionally at a given
+// version, and writing a new version with check-and-set.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout is the timeout of the requests made by clients created with NewClientFromEnv.
+const DefaultTimeout = 30 * time.Second
+
+// Client is a client for the KV version 2 secrets engine of a Vault server.
+type Client struct {
+	httpClient *http.Client // HTTP client used for the requests.
+	address    string       // Address of the server, e.g. https://vault.example.com:8200.
+	token      string       // Token sent in the X-Vault-Token header.
+	namespace  string       // Vault Enterprise namespace, if any.
+}
+
+// Secret is a version of a secret stored in Vault.
+type Secret struct {
+	CreatedTime time.Time         // When the version was written.
+	Data        map[string]string // The values of the version.
+	Version     int               // Number of the version.
+}
+
+// Metadata describes the versions of a secret.
+type Metadata struct {
+	CurrentVersion int // Number of the latest version, even if it was deleted or destroyed.
+}
+
+// APIError is an error response of the Vault API.
+type APIError struct {
+	Errors     []string // Messages returned by the server.
+	StatusCode int      // HTTP status code of the response.
+}
+
+// Error returns the messages of the server along with the status code.
+func (e *APIError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("vault: status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("vault: status %d: %s", e.StatusCode, strings.Join(e.Errors, "; "))
+}
+
+// IsNotFound reports whether the error means that the secret or version does not exist.
+//
+// Example usage:
+//
+//	if vault.IsNotFound(err) {
+//	    // write the first version
+//	}
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// NewClient creates a new Client.
+//
+// Parameters:
+// - httpClient: HTTP client used for the requests.
+// - address: Address of the Vault server, e.g. https://vault.example.com:8200.
+// - token: Token used to authenticate the requests.
+//
+// Returns:
+// - A new Client instance.
+//
+// Example usage:
+// client := vault.NewClient(http.DefaultClient, "https://vault.example.com:8200", token)
+func NewClient(httpClient *http.Client, address string, token string) *Client {
+	return &Client{
+		httpClient: httpClient,
+		address:    strings.TrimSuffix(address, "/"),
+		token:      token,
+	}
+}
+
+// NewClientFromEnv creates a new Client configured like the vault CLI: the
+// address is read from VAULT_ADDR, the token from VAULT_TOKEN or the
+// ~/.vault-token file, and the namespace from VAULT_NAMESPACE.
+//
+// Returns:
+// - A new Client instance.
+// - An error if the address or the token are not set.
+//
+// Example usage:
+// client, err := vault.NewClientFromEnv()
+func NewClientFromEnv() (*Client, error) {
+	address := os.Getenv("VAULT_ADDR")
+	if len(address) == 0 {
+		return nil, fmt.Errorf("VAULT_ADDR is not set")
+	}
+
+	token := os.Getenv("VAULT_TOKEN")
+	if len(token) == 0 {
+		home, err := os.UserHomeDir()
+		if err == nil {
+			content, err := os.ReadFile(filepath.Join(home, ".vault-token"))
+			if err == nil {
+				token = strings.TrimSpace(string(content))
+			}
+		}
+	}
+	if len(token) == 0 {
+		return nil, fmt.Errorf("VAULT_TOKEN is not set and no token was found in ~/.vault-token, log in with vault login")
+	}
+
+	client := NewClient(&http.Client{Timeout: DefaultTimeout}, address, token)
+	return client.WithNamespace(os.Getenv("VAULT_NAMESPACE")), nil
+}
+
+// WithNamespace sets the Vault Enterprise namespace of the requests.
+//
+// Example usage:
+// client := vault.NewClient(http.DefaultClient, address, token).WithNamespace("team-a")
+func (c *Client) WithNamespace(namespace string) *Client {
+	c.namespace = namespace
+	return c
+}
+
+// SplitPath splits a path as the vault CLI takes it, e.g. secret/api/prod,
+// into the mount of the secrets engine and the path of the secret in it.
+//
+// Example usage:
+// mount, path, err := vault.SplitPath("secret/api/prod") // Output: secret api/prod
+func SplitPath(fullPath string) (string, string, error) {
+	mount, path, ok := strings.Cut(strings.Trim(fullPath, "/"), "/")
+	if !ok || len(mount) == 0 || len(path) == 0 {
+		return "", "", fmt.Errorf("invalid vault path %q, must be MOUNT/PATH, e.g. secret/api", fullPath)
+	}
+	return mount, path, nil
+}
+
+// Read reads a secret.
+//
+// Parameters:
+// - ctx: Context for the request.
+// - fullPath: Path of the secret, starting with the mount of the secrets engine, e.g. secret/api.
+// - version: Version to read, or 0 for the latest one.
+//
+// Returns:
+// - The secret. Values that are not strings are returned as JSON.
+// - An error if the secret cannot be read. IsNotFound reports whether it does not exist.
+//
+// Example usage:
+// secret, err := client.Read(ctx, "secret/api", 0)
+func (c *Client) Read(ctx context.Context, fullPath string, version int) (*Secret, error) {
+	mount, path, err := SplitPath(fullPath)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	if version > 0 {
+		query.Set("version", strconv.Itoa(version))
+	}
+
+	var response struct {
+		Data struct {
+			Data     map[string]json.RawMessage `json:"data"`
+			Metadata struct {
+				CreatedTime time.Time `json:"created_time"`
+				Version     int       `json:"version"`
+			} `json:"metadata"`
+		} `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, mount+"/data/"+path, query, nil, &response); err != nil {
+		return nil, err
+	}
+
+	// Values that are not strings are kept as JSON, as written, so that
+	// numbers do not lose precision through float64.
+	data := make(map[string]string, len(response.Data.Data))
+	for key, value := range response.Data.Data {
+		if len(value) > 0 && value[0] == '"' {
+			var s string
+			if err := json.Unmarshal(value, &s); err != nil {
+				return nil, err
+			}
+			data[key] = s
+			continue
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, value); err != nil {
+			return nil, err
+		}
+		data[key] = compact.String()
+	}
+
+	return &Secret{
+		CreatedTime: response.Data.Metadata.CreatedTime,
+		Data:        data,
+		Version:     response.Data.Metadata.Version,
+	}, nil
+}
+
+// ReadMetadata reads the metadata of a secret.
+//
+// Parameters:
+// - ctx: Context for the request.
+// - fullPath: Path of the secret, starting with the mount of the secrets engine, e.g. secret/api.
+//
+// Returns:
+// - The metadata. Unlike Read, it is found when the latest version was deleted.
+// - An error if the metadata cannot be read. IsNotFound reports whether the secret never existed.
+//
+// Example usage:
+// metadata, err := client.ReadMetadata(ctx, "secret/api")
+func (c *Client) ReadMetadata(ctx context.Context, fullPath string) (*Metadata, error) {
+	mount, path, err := SplitPath(fullPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data struct {
+			CurrentVersion int `json:"current_version"`
+		} `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, mount+"/metadata/"+path, nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &Metadata{CurrentVersion: response.Data.CurrentVersion}, nil
+}
+
+// Write writes a new version of a secret.
+//
+// Parameters:
+// - ctx: Context for the request.
+// - fullPath: Path of the secret, starting with the mount of the secrets engine, e.g. secret/api.
+// - data: The values of the new version.
+// - cas: With check-and-set, the write only succeeds if the current version
+// of the secret is cas, 0 meaning that it must not exist. Nil writes unconditionally.
+//
+// Returns:
+// - The number of the new version.
+// - An error if the secret cannot be written.
+//
+// Example usage:
+// version, err := client.Write(ctx, "secret/api", map[string]string{"API_TOKEN": "abc"}, nil)
+func (c *Client) Write(ctx context.Context, fullPath string, data map[string]string, cas *int) (int, error) {
+	mount, path, err := SplitPath(fullPath)
+	if err != nil {
+		return 0, err
+	}
+
+	body := map[string]any{"data": data}
+	if cas != nil {
+		body["options"] = map[string]int{"cas": *cas}
+	}
+
+	var response struct {
+		Data struct {
+			Version int `json:"version"`
+		} `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPost, mount+"/data/"+path, nil, body, &response); err != nil {
+		return 0, err
+	}
+	return response.Data.Version, nil
+}
+
+// do sends a request to the Vault API and decodes the response into result.
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values, body any, result any) error {
+	endpoint := c.address + "/v1/" + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(content)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", c.token)
+	if len(c.namespace) > 0 {
+		req.Header.Set("X-Vault-Namespace", c.namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		var response struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(content, &response) == nil {
+			apiErr.Errors = response.Errors
+		}
+		return apiErr
+	}
+
+	if result == nil || len(content) == 0 {
+		return nil
+	}
+	return json.Unmarshal(content, result)
+}
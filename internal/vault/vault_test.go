@@ -0,0 +1,213 @@
+package vault_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "s.test-token"
+
+// kvServer is an in-memory stand-in for the KV version 2 secrets engine
+// mounted at secret/.
+type kvServer struct {
+	secrets   map[string][]map[string]any
+	deleted   map[string]map[int]bool // Soft-deleted versions by path.
+	namespace string
+	mu        sync.Mutex
+}
+
+func newKVServer(t *testing.T) (*kvServer, *httptest.Server) {
+	kv := &kvServer{secrets: make(map[string][]map[string]any), deleted: make(map[string]map[int]bool)}
+	server := httptest.NewServer(kv)
+	t.Cleanup(server.Close)
+	return kv, server
+}
+
+func (kv *kvServer) fail(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string][]string{"errors": {message}})
+}
+
+func (kv *kvServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	if r.Header.Get("X-Vault-Token") != testToken {
+		kv.fail(w, http.StatusForbidden, "permission denied")
+		return
+	}
+	kv.namespace = r.Header.Get("X-Vault-Namespace")
+	if path, ok := strings.CutPrefix(r.URL.Path, "/v1/secret/metadata/"); ok {
+		if len(kv.secrets[path]) == 0 {
+			kv.fail(w, http.StatusNotFound, "")
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"current_version": len(kv.secrets[path])}})
+		return
+	}
+	path, ok := strings.CutPrefix(r.URL.Path, "/v1/secret/data/")
+	if !ok {
+		kv.fail(w, http.StatusNotFound, "no handler for route")
+		return
+	}
+	versions := kv.secrets[path]
+
+	switch r.Method {
+	case http.MethodGet:
+		version := len(versions)
+		if v := r.URL.Query().Get("version"); len(v) > 0 {
+			version, _ = strconv.Atoi(v)
+		}
+		if version < 1 || version > len(versions) || kv.deleted[path][version] {
+			kv.fail(w, http.StatusNotFound, "")
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{
+			"data":     versions[version-1],
+			"metadata": map[string]any{"created_time": "2024-05-01T10:00:00Z", "version": version},
+		}})
+	case http.MethodPost:
+		var body struct {
+			Options map[string]int `json:"options"`
+			Data    map[string]any `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			kv.fail(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if cas, ok := body.Options["cas"]; ok && cas != len(versions) {
+			kv.fail(w, http.StatusBadRequest, "check-and-set parameter did not match the current version")
+			return
+		}
+		kv.secrets[path] = append(versions, body.Data)
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"version": len(versions) + 1}})
+	default:
+		kv.fail(w, http.StatusMethodNotAllowed, "unsupported operation")
+	}
+}
+
+func TestClientReadWrite(t *testing.T) {
+	ctx := context.Background()
+	kv, server := newKVServer(t)
+	client := vault.NewClient(server.Client(), server.URL+"/", testToken).WithNamespace("team-a")
+
+	_, err := client.Read(ctx, "secret/api", 0)
+	assert.True(t, vault.IsNotFound(err))
+
+	version, err := client.Write(ctx, "secret/api", map[string]string{"API_TOKEN": "one"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+	assert.Equal(t, "team-a", kv.namespace)
+
+	cas := 1
+	version, err = client.Write(ctx, "secret/api", map[string]string{"API_TOKEN": "two"}, &cas)
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	_, err = client.Write(ctx, "secret/api", map[string]string{"API_TOKEN": "three"}, &cas)
+	assert.ErrorContains(t, err, "vault: status 400: check-and-set parameter did not match the current version")
+
+	secret, err := client.Read(ctx, "secret/api", 0)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_TOKEN": "two"}, secret.Data)
+	assert.Equal(t, 2, secret.Version)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), secret.CreatedTime)
+
+	secret, err = client.Read(ctx, "secret/api", 1)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_TOKEN": "one"}, secret.Data)
+	assert.Equal(t, 1, secret.Version)
+}
+
+func TestClientReadMetadata(t *testing.T) {
+	ctx := context.Background()
+	kv, server := newKVServer(t)
+	client := vault.NewClient(server.Client(), server.URL, testToken)
+
+	_, err := client.ReadMetadata(ctx, "secret/api")
+	assert.True(t, vault.IsNotFound(err))
+
+	kv.secrets["api"] = []map[string]any{{"API_TOKEN": "one"}, {"API_TOKEN": "two"}}
+	kv.deleted["api"] = map[int]bool{2: true}
+
+	// The data of a deleted latest version is not found, its metadata is.
+	_, err = client.Read(ctx, "secret/api", 0)
+	assert.True(t, vault.IsNotFound(err))
+	metadata, err := client.ReadMetadata(ctx, "secret/api")
+	require.NoError(t, err)
+	assert.Equal(t, 2, metadata.CurrentVersion)
+}
+
+func TestClientReadNonStringValues(t *testing.T) {
+	kv, server := newKVServer(t)
+	kv.secrets["api"] = []map[string]any{{
+		"PORT":  8080.0,
+		"ID":    json.Number("12345678901234567890"),
+		"DEBUG": true,
+		"TAGS":  []any{"a", "b"},
+		"EMPTY": nil,
+	}}
+
+	secret, err := vault.NewClient(server.Client(), server.URL, testToken).Read(context.Background(), "secret/api", 0)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"PORT":  "8080",
+		"ID":    "12345678901234567890",
+		"DEBUG": "true",
+		"TAGS":  `["a","b"]`,
+		"EMPTY": "null",
+	}, secret.Data)
+}
+
+func TestClientErrors(t *testing.T) {
+	ctx := context.Background()
+	_, server := newKVServer(t)
+
+	_, err := vault.NewClient(server.Client(), server.URL, "wrong").Read(ctx, "secret/api", 0)
+	var apiErr *vault.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	assert.EqualError(t, err, "vault: status 403: permission denied")
+	assert.False(t, vault.IsNotFound(err))
+
+	_, err = vault.NewClient(server.Client(), server.URL, testToken).Read(ctx, "api", 0)
+	assert.ErrorContains(t, err, "must be MOUNT/PATH")
+}
+
+func TestSplitPath(t *testing.T) {
+	mount, path, err := vault.SplitPath("/secret/api/prod/")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", mount)
+	assert.Equal(t, "api/prod", path)
+
+	_, _, err = vault.SplitPath("secret")
+	assert.Error(t, err)
+}
+
+func TestNewClientFromEnv(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("VAULT_ADDR", "")
+	t.Setenv("VAULT_TOKEN", "")
+	_, err := vault.NewClientFromEnv()
+	assert.ErrorContains(t, err, "VAULT_ADDR is not set")
+
+	t.Setenv("VAULT_ADDR", "https://vault.example.com:8200")
+	_, err = vault.NewClientFromEnv()
+	assert.ErrorContains(t, err, "VAULT_TOKEN is not set")
+
+	t.Setenv("VAULT_TOKEN", testToken)
+	client, err := vault.NewClientFromEnv()
+	require.NoError(t, err)
+	assert.NotNil(t, client)
+}
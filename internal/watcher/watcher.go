@@ -0,0 +1,94 @@
+// Package watcher provides utilities for reacting to changes in local files.
+//
+// This package watches a set of files for writes, renames and removals and
+// invokes a callback once a burst of changes has settled. It uses fsnotify
+// (inotify on Linux) and watches the parent directories of the files, so
+// editors that replace files on save are handled too.
+package watcher
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce is the default time to wait for a burst of changes to settle.
+const DefaultDebounce = 500 * time.Millisecond
+
+// Watch watches the given files and calls onChange after they change.
+//
+// Changes happening within the debounce interval of each other are coalesced
+// into a single call. Errors returned by onChange, as well as errors reported
+// by the file watcher, are passed to onError and do not stop the watch. Watch
+// blocks until the context is cancelled.
+//
+// Parameters:
+// - ctx: Context controlling how long to watch.
+// - paths: Files to watch.
+// - debounce: Time to wait after the last change before calling onChange.
+// - onChange: Function called after the files changed.
+// - onError: Function called with non-fatal errors.
+//
+// Returns:
+// - An error if the files cannot be watched, otherwise nil once the context is cancelled.
+//
+// Example usage:
+// ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+// defer stop()
+// err := watcher.Watch(ctx, []string{".env"}, watcher.DefaultDebounce, reload, logError)
+func Watch(ctx context.Context, paths []string, debounce time.Duration, onChange func() error, onError func(error)) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	watched := make(map[string]struct{}, len(paths))
+	dirs := make(map[string]struct{}, len(paths))
+	for _, path := range paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		watched[absPath] = struct{}{}
+
+		dir := filepath.Dir(absPath)
+		if _, found := dirs[dir]; found {
+			continue
+		}
+		if err := w.Add(dir); err != nil {
+			return err
+		}
+		dirs[dir] = struct{}{}
+	}
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if _, found := watched[filepath.Clean(event.Name)]; !found || event.Op == fsnotify.Chmod {
+				continue
+			}
+			timer.Reset(debounce)
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			onError(err)
+		case <-timer.C:
+			if err := onChange(); err != nil {
+				onError(err)
+			}
+		}
+	}
+}
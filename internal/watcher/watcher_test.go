@@ -0,0 +1,52 @@
+package watcher_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/watcher"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+	otherFile := filepath.Join(dir, ".env.unrelated")
+	if err := os.WriteFile(envFile, []byte("KEY=VALUE"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls atomic.Int32
+	done := make(chan error)
+	go func() {
+		done <- watcher.Watch(ctx, []string{envFile}, 100*time.Millisecond, func() error {
+			calls.Add(1)
+			return nil
+		}, func(err error) {
+			t.Errorf("unexpected error: %v", err)
+		})
+	}()
+
+	// Give the watcher time to register the directory.
+	time.Sleep(100 * time.Millisecond)
+
+	os.WriteFile(otherFile, []byte("KEY=OTHER"), 0644)
+	for i := 0; i < 5; i++ {
+		os.WriteFile(envFile, []byte("KEY=VALUE"), 0644)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.Eventually(t, func() bool { return calls.Load() == 1 }, 2*time.Second, 20*time.Millisecond)
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, int32(1), calls.Load(), "a burst of writes should trigger a single call")
+
+	cancel()
+	assert.Nil(t, <-done)
+}
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/ogticrd/kubectl-envsecret/cmd"
 	"github.com/spf13/cobra"
@@ -16,7 +20,15 @@ func main() {
 	// rootCmd is the base command for the kubectl-envsecret plugin.
 	var rootCmd *cobra.Command = cmd.NewCmdEnvSecret(genericiooptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr})
 
-	err := rootCmd.Execute()
+	// Cancel in-flight requests when the user presses Ctrl-C or the process is terminated.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	var exitErr *cmd.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.Code)
+	}
 	if err != nil {
 		os.Exit(1)
 	}
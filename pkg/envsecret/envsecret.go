@@ -0,0 +1,555 @@
+// Package envsecret is the public Go API of kubectl-envsecret.
+//
+// It lets Go programs, such as deploy tools, do what the create command does
+// without shelling out to the plugin: Load reads and checks the values of a
+// secret from .env files and other sources, Build turns them into a Secret
+// object and Apply creates or updates it in a cluster through any
+// kubernetes.Interface, recording its history.
+//
+// The API is stable: fields may be added to Options, Values and Result, but
+// existing ones keep their meaning.
+package envsecret
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/diff"
+	"github.com/ogticrd/kubectl-envsecret/internal/k8sapi"
+	"github.com/ogticrd/kubectl-envsecret/internal/output"
+	"github.com/ogticrd/kubectl-envsecret/internal/parser"
+	"github.com/ogticrd/kubectl-envsecret/internal/render"
+	"github.com/ogticrd/kubectl-envsecret/internal/scan"
+	"github.com/ogticrd/kubectl-envsecret/internal/schema"
+	"github.com/ogticrd/kubectl-envsecret/internal/utils"
+	"github.com/ogticrd/kubectl-envsecret/internal/vault"
+	v1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultHistoryLimit is the number of revisions the create command keeps for each secret.
+const DefaultHistoryLimit = k8sapi.DefaultHistoryLimit
+
+// Actions reported in a Result.
+const (
+	ActionCreated   = output.ActionCreated
+	ActionUpdated   = output.ActionUpdated
+	ActionUnchanged = output.ActionUnchanged
+)
+
+// ValidationError is returned by Load when the values do not match the schema.
+type ValidationError = schema.ValidationError
+
+// WarningsError is returned by Load when warnings are found and
+// Options.FailOnWarnings is set.
+type WarningsError = scan.WarningsError
+
+// Errors returned by Options.Validate, and so by Load and Apply, for options
+// that cannot be used together.
+var (
+	ErrValuesWithoutTemplate  = errors.New("values files require templates")
+	ErrTemplateSources        = errors.New("templates only support .env files, not other sources")
+	ErrControllerWithoutOwner = errors.New("an owner is required to mark it as the controller")
+	ErrNegativeHistoryLimit   = errors.New("the history limit must be greater than or equal to 0")
+)
+
+// DirectivesError is returned by Load when values are generate directives
+// and Options.GenerateMissing is not set.
+type DirectivesError struct {
+	Keys []string // Keys whose values are directives, sorted.
+}
+
+// Error implements the error interface.
+func (e *DirectivesError) Error() string {
+	return fmt.Sprintf("keys %s use %s directives, set Options.GenerateMissing to fill them", strings.Join(e.Keys, ", "), parser.DirectivePrefix)
+}
+
+// Source is an origin of values, such as a .env file or a Vault secret.
+type Source interface {
+	// Load reads the values of the source. Sources reading them over the
+	// network or from other programs stop when ctx is cancelled.
+	Load(ctx context.Context) (map[string]string, error)
+	// File returns the local file the values are read from, or an empty
+	// string if they do not come from a file.
+	File() string
+}
+
+// Options describes how to load a secret and where to write it. The zero
+// value reads nothing, so at least Files or Sources must be set.
+type Options struct {
+	// Warnings receives warnings about suspicious values and notices about
+	// generated values, one per line. They are discarded when nil.
+	Warnings io.Writer
+	// Name of the secret.
+	Name string
+	// Namespace of the secret, "default" when empty.
+	Namespace string
+	// Overlay also loads the .local, .OVERLAY and .OVERLAY.local variants of
+	// the .env files in Files that exist, following the dotenv-flow convention.
+	Overlay string
+	// Schema is the path of a schema file the values are validated against.
+	Schema string
+	// Owner is a workload owning the secret in its namespace, as KIND/NAME,
+	// e.g. deployment/api. The secret is deleted along with it.
+	Owner string
+	// Files are the .env files to read, or the URIs of other sources as
+	// accepted by NewSource. Later files take precedence.
+	Files []string
+	// Sources are read after Files, taking precedence over them.
+	Sources []Source
+	// ValuesFiles are YAML files with values available to templates as .Values.
+	ValuesFiles []string
+	// CompareFiles are .env files whose sensitive values must not be reused.
+	CompareFiles []string
+	// HistoryLimit is the number of previous versions of the secret to keep,
+	// 0 keeping all of them. See DefaultHistoryLimit.
+	HistoryLimit int
+	// Retries is the number of attempts of writes failing with a conflict or
+	// a transient error. A default number of attempts is made when 0.
+	Retries int
+	// Template renders the .env files as Go templates before parsing them.
+	Template bool
+	// GenerateMissing fills !generate: directives with random values.
+	GenerateMissing bool
+	// FailOnWarnings makes Load fail with a WarningsError when warnings are found.
+	FailOnWarnings bool
+	// Overwrite updates the secret if it already exists.
+	Overwrite bool
+	// OwnerController marks the Owner as the managing controller of the secret.
+	OwnerController bool
+}
+
+// Validate checks that the options can be used together, without reading
+// any file. Load and Apply call it.
+//
+// Returns:
+// - One of the Err errors of the package, or an error if a URI in Files is not valid.
+//
+// Example usage:
+// if err := opts.Validate(); errors.Is(err, envsecret.ErrValuesWithoutTemplate) {
+func (opts Options) Validate() error {
+	if len(opts.ValuesFiles) > 0 && !opts.Template {
+		return ErrValuesWithoutTemplate
+	}
+	if opts.Template {
+		sources, err := parser.NewSources(opts.Files)
+		if err != nil {
+			return err
+		}
+		if len(opts.Sources) > 0 || len(plainFiles(sources)) < len(sources) {
+			return ErrTemplateSources
+		}
+	}
+	if opts.OwnerController && len(opts.Owner) == 0 {
+		return ErrControllerWithoutOwner
+	}
+	if opts.HistoryLimit < 0 {
+		return ErrNegativeHistoryLimit
+	}
+	return nil
+}
+
+// Values are the values of a secret, as loaded by Load.
+type Values struct {
+	// Data holds the values by key.
+	Data map[string]string
+	// Generated are the keys whose values were generated. Apply keeps the
+	// values the secret already has for them.
+	Generated []string
+	// Files are the local files the values were read from.
+	Files []string
+}
+
+// Result describes what Apply did.
+type Result struct {
+	// Secret is the secret as returned by the API server.
+	Secret *v1.Secret
+	// Action is one of ActionCreated, ActionUpdated or ActionUnchanged.
+	Action string
+	// Changes summarizes the added (+), changed (~) and removed (-) keys of
+	// an update, without their values.
+	Changes string
+}
+
+// NewSource returns the source a URI refers to, selected by its scheme:
+// file://PATH or a plain path for a .env file, env://PREFIX for the variables
+// of the current process starting with PREFIX, sops://PATH for a file
+// encrypted with SOPS, onepassword://PATH for a 1Password export and
+// bitwarden://PATH for a Bitwarden JSON export.
+//
+// Example usage:
+// source, err := envsecret.NewSource("env://APP_")
+func NewSource(uri string) (Source, error) {
+	return parser.NewSource(uri)
+}
+
+// NewVaultSource returns a source reading a HashiCorp Vault KV v2 secret,
+// configured from the VAULT_ADDR, VAULT_TOKEN and VAULT_NAMESPACE environment
+// variables as the vault CLI is.
+//
+// Parameters:
+// - path: Path of the secret, starting with the mount of the secrets engine, e.g. secret/api.
+// - version: Version to read, or 0 for the latest one.
+//
+// Example usage:
+// source, err := envsecret.NewVaultSource("secret/api", 0)
+func NewVaultSource(path string, version int) (Source, error) {
+	if _, _, err := vault.SplitPath(path); err != nil {
+		return nil, err
+	}
+	client, err := vault.NewClientFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return &vault.Source{Client: client, Path: path, Version: version}, nil
+}
+
+// Load reads the values of a secret from the files and sources of the
+// options, fills generate directives, validates them against the schema and
+// writes warnings about suspicious values and files.
+//
+// Parameters:
+// - ctx: Context for sources reading values over the network or from other programs, e.g. Vault.
+// - opts: The options. Only the fields about reading values are used.
+//
+// Returns:
+// - The values.
+// - An error if a source cannot be read, values are generate directives without
+// GenerateMissing (a DirectivesError), the values do not match the schema (a
+// ValidationError) or warnings were found with FailOnWarnings (a WarningsError).
+//
+// Example usage:
+// values, err := envsecret.Load(ctx, envsecret.Options{Files: []string{".env"}, Warnings: os.Stderr})
+func Load(ctx context.Context, opts Options) (*Values, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	paths := utils.RemoveDuplicatedStringE(opts.Files)
+	if len(opts.Overlay) > 0 {
+		paths = parser.Overlay(paths, opts.Overlay)
+	}
+	fileSources, err := parser.NewSources(paths)
+	if err != nil {
+		return nil, err
+	}
+	sources := make([]parser.Source, 0, len(fileSources)+len(opts.Sources))
+	sources = append(sources, fileSources...)
+	for _, source := range opts.Sources {
+		sources = append(sources, source)
+	}
+	plain := plainFiles(sources)
+
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no files or sources to read values from")
+	}
+	if err := utils.ValidatePaths(parser.SourceFiles(sources)); err != nil {
+		return nil, err
+	}
+	if err := utils.ValidatePaths(opts.CompareFiles); err != nil {
+		return nil, err
+	}
+	if err := utils.ValidatePaths(opts.ValuesFiles); err != nil {
+		return nil, err
+	}
+
+	var data map[string]string
+	if opts.Template {
+		data, err = renderFiles(plain, opts.ValuesFiles)
+	} else {
+		data, err = parser.LoadSources(ctx, sources...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	generated, err := generate(data, opts.GenerateMissing)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opts.Schema) > 0 {
+		s, err := schema.Load(opts.Schema)
+		if err != nil {
+			return nil, err
+		}
+		data, err = s.Validate(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := check(data, plain, opts); err != nil {
+		return nil, err
+	}
+
+	return &Values{
+		Data:      data,
+		Generated: generated,
+		Files:     parser.SourceFiles(sources),
+	}, nil
+}
+
+// Build returns the secret holding the values, as Apply writes it. The
+// labels and annotations kubectl-envsecret records are added when it is
+// written.
+//
+// Example usage:
+// secret := envsecret.Build(opts, values)
+func Build(opts Options, values *Values) *v1.Secret {
+	return &v1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      opts.Name,
+			Namespace: namespace(opts),
+		},
+		Type: v1.SecretTypeOpaque,
+		Data: utils.MapStringToBytes(values.Data),
+	}
+}
+
+// Expected returns the data Apply leaves in a secret written with the values:
+// generated values the live secret already has are kept, and so are the
+// previous values of the keys being rotated.
+//
+// Parameters:
+// - values: The values, as returned by Load.
+// - live: The secret as currently stored, or nil if it does not exist.
+//
+// Returns:
+// - The data of the secret once written.
+//
+// Example usage:
+// values, err := envsecret.Load(ctx, opts)
+// changes := diff.Compare(envsecret.Expected(values, live), live.Data)
+func Expected(values *Values, live *v1.Secret) map[string][]byte {
+	data := utils.MapStringToBytes(values.Data)
+	if live == nil {
+		return data
+	}
+	for _, key := range values.Generated {
+		if value, ok := live.Data[key]; ok {
+			data[key] = value
+		}
+	}
+	k8sapi.KeepPreviousValues(live, data)
+	return data
+}
+
+// Apply creates the secret with the values, or updates it when it already
+// exists and Options.Overwrite is set, recording the previous version in its
+// history. Generated values the secret already has are kept.
+//
+// Parameters:
+// - ctx: Context for the API requests.
+// - client: Kubernetes client, e.g. created with kubernetes.NewForConfig.
+// - opts: The options. Only the fields about writing the secret are used.
+// - values: The values, as returned by Load.
+//
+// Returns:
+// - What was done.
+// - An error if the secret cannot be written.
+//
+// Example usage:
+// clientset, err := kubernetes.NewForConfig(config)
+// result, err := envsecret.Apply(ctx, clientset, opts, values)
+func Apply(ctx context.Context, client kubernetes.Interface, opts Options, values *Values) (*Result, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if len(opts.Name) == 0 {
+		return nil, fmt.Errorf("the name of the secret is required")
+	}
+	if len(values.Data) == 0 {
+		return nil, fmt.Errorf("no secrets provided")
+	}
+
+	k := k8sapi.NewK8sClient(client, namespace(opts)).WithSourceFiles(values.Files...)
+	if opts.Retries > 0 {
+		k.WithRetryAttempts(opts.Retries)
+	}
+
+	var owner *metav1.OwnerReference
+	if len(opts.Owner) > 0 {
+		var err error
+		owner, err = k.ResolveOwner(ctx, opts.Owner, opts.OwnerController)
+		if err != nil {
+			return nil, err
+		}
+		k.WithOwner(owner)
+	}
+
+	data := make(map[string]string, len(values.Data))
+	for key, value := range values.Data {
+		data[key] = value
+	}
+	if len(values.Generated) > 0 {
+		var live map[string][]byte
+		existing, err := k.GetSecret(ctx, opts.Name)
+		if err == nil {
+			live = existing.Data
+		} else if !kerr.IsNotFound(err) {
+			return nil, err
+		}
+		for _, key := range values.Generated {
+			if value, ok := live[key]; ok {
+				data[key] = string(value)
+				continue
+			}
+			warn(opts, "Generated a new value for %s", key)
+		}
+	}
+
+	secret, err := k.CreateSecretFromObject(ctx, Build(opts, &Values{Data: data}))
+	if err == nil {
+		if _, err := k.RecordRevision(ctx, secret, opts.HistoryLimit); err != nil {
+			return nil, err
+		}
+		return &Result{Secret: secret, Action: ActionCreated}, nil
+	}
+	if !kerr.IsAlreadyExists(err) || !opts.Overwrite {
+		return nil, err
+	}
+
+	existing, err := k.GetSecret(ctx, opts.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Previous values of keys being rotated are kept by UpdateSecret.
+	desired := Expected(&Values{Data: data}, existing)
+	changes := diff.Compare(existing.Data, desired)
+	// A secret with the same data still needs updating to gain a new owner.
+	if changes.Empty() && k8sapi.HasOwner(existing, owner) {
+		return &Result{Secret: existing, Action: ActionUnchanged}, nil
+	}
+
+	secret, err = k.UpdateSecret(ctx, opts.Name, data)
+	if err != nil {
+		return nil, err
+	}
+	// Only changed data is worth a revision to roll back to.
+	if !changes.Empty() {
+		if _, err := k.RecordRevision(ctx, secret, opts.HistoryLimit); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Result{Secret: secret, Action: ActionUpdated, Changes: changes.String()}, nil
+}
+
+// namespace returns the namespace of the secret.
+func namespace(opts Options) string {
+	if len(opts.Namespace) == 0 {
+		return "default"
+	}
+	return opts.Namespace
+}
+
+// warn writes a warning or notice, if warnings are not discarded.
+func warn(opts Options, format string, args ...any) {
+	if opts.Warnings != nil {
+		fmt.Fprintf(opts.Warnings, format+"\n", args...)
+	}
+}
+
+// plainFiles returns the paths of the sources that are plain .env files.
+func plainFiles(sources []parser.Source) []string {
+	var paths []string
+	for _, source := range sources {
+		if file, ok := source.(*parser.FileSource); ok {
+			paths = append(paths, file.Path)
+		}
+	}
+	return paths
+}
+
+// renderFiles renders every env file as a template and parses the result.
+// Later files take precedence, as with parser.Load.
+func renderFiles(paths []string, valuesPaths []string) (map[string]string, error) {
+	data, err := render.NewData(valuesPaths...)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	for _, path := range paths {
+		content, err := render.Render(path, data)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := parser.Parse(content)
+		if err != nil {
+			return nil, fmt.Errorf("error loading rendered template %s: %w", path, err)
+		}
+		for key, value := range parsed {
+			values[key] = value
+		}
+	}
+
+	return values, nil
+}
+
+// generate replaces generate directives with random values and returns their
+// keys, in order.
+func generate(values map[string]string, generateMissing bool) ([]string, error) {
+	directives, err := parser.Directives(values)
+	if err != nil {
+		return nil, err
+	}
+
+	generated := make([]string, 0, len(directives))
+	for key := range directives {
+		generated = append(generated, key)
+	}
+	sort.Strings(generated)
+
+	if len(generated) > 0 && !generateMissing {
+		return nil, &DirectivesError{Keys: generated}
+	}
+
+	for _, key := range generated {
+		values[key], err = directives[key].Generate()
+		if err != nil {
+			return nil, fmt.Errorf("error generating a value for %s: %w", key, err)
+		}
+	}
+	return generated, nil
+}
+
+// check writes warnings about placeholder values, values shared with the
+// compared env files and env files tracked by git. With FailOnWarnings any
+// warning makes it fail.
+func check(values map[string]string, plain []string, opts Options) error {
+	findings := scan.Placeholders(values)
+
+	for _, path := range opts.CompareFiles {
+		other, err := parser.Load(path)
+		if err != nil {
+			return err
+		}
+		findings = append(findings, scan.SharedValues(values, other, path)...)
+	}
+
+	// Encrypted files and exports are not leaks when tracked by git.
+	tracked, err := scan.TrackedFiles(plain)
+	if err != nil {
+		return err
+	}
+	findings = append(findings, tracked...)
+
+	for _, finding := range findings {
+		warn(opts, "Warning: %s", finding)
+	}
+
+	if opts.FailOnWarnings && len(findings) > 0 {
+		return &scan.WarningsError{Findings: findings}
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package envsecret_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ogticrd/kubectl-envsecret/internal/parser"
+	"github.com/ogticrd/kubectl-envsecret/pkg/envsecret"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	base := writeFile(t, ".env", "# comment\nDB_HOST=localhost\nDB_USER='app'\n")
+	override := writeFile(t, ".env.prod", "DB_HOST=db.internal\n")
+
+	values, err := envsecret.Load(context.Background(), envsecret.Options{Files: []string{base, override, base}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"DB_HOST": "db.internal", "DB_USER": "app"}, values.Data)
+	assert.Equal(t, []string{base, override}, values.Files)
+	assert.Empty(t, values.Generated)
+
+	_, err = envsecret.Load(context.Background(), envsecret.Options{})
+	assert.EqualError(t, err, "no files or sources to read values from")
+
+	_, err = envsecret.Load(context.Background(), envsecret.Options{Files: []string{base}, ValuesFiles: []string{override}})
+	assert.EqualError(t, err, "values files require templates")
+}
+
+func TestLoadSources(t *testing.T) {
+	path := writeFile(t, ".env", "API_URL=https://api.example.com\nLOG_LEVEL=info\n")
+	t.Setenv("APP_LOG_LEVEL", "debug")
+	source, err := envsecret.NewSource("env://APP_")
+	require.NoError(t, err)
+
+	values, err := envsecret.Load(context.Background(), envsecret.Options{Files: []string{path}, Sources: []envsecret.Source{source}})
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com", values.Data["API_URL"])
+	assert.Equal(t, "debug", values.Data["APP_LOG_LEVEL"])
+	assert.Equal(t, []string{path}, values.Files)
+
+	_, err = envsecret.Load(context.Background(), envsecret.Options{Files: []string{path}, Sources: []envsecret.Source{source}, Template: true})
+	assert.EqualError(t, err, "templates only support .env files, not other sources")
+}
+
+func TestLoadGenerate(t *testing.T) {
+	path := writeFile(t, ".env", "SESSION_KEY=!generate:16:hex\n")
+
+	_, err := envsecret.Load(context.Background(), envsecret.Options{Files: []string{path}})
+	var directivesErr *envsecret.DirectivesError
+	require.ErrorAs(t, err, &directivesErr)
+	assert.Equal(t, []string{"SESSION_KEY"}, directivesErr.Keys)
+	assert.EqualError(t, err, "keys SESSION_KEY use !generate: directives, set Options.GenerateMissing to fill them")
+
+	values, err := envsecret.Load(context.Background(), envsecret.Options{Files: []string{path}, GenerateMissing: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SESSION_KEY"}, values.Generated)
+	assert.Len(t, values.Data["SESSION_KEY"], 16)
+}
+
+func TestLoadWarnings(t *testing.T) {
+	path := writeFile(t, ".env", "API_TOKEN=changeme\n")
+	var warnings bytes.Buffer
+
+	_, err := envsecret.Load(context.Background(), envsecret.Options{Files: []string{path}, Warnings: &warnings})
+	require.NoError(t, err)
+	assert.Contains(t, warnings.String(), "Warning: ")
+	assert.Contains(t, warnings.String(), "API_TOKEN")
+
+	_, err = envsecret.Load(context.Background(), envsecret.Options{Files: []string{path}, FailOnWarnings: true})
+	var warningsErr *envsecret.WarningsError
+	assert.ErrorAs(t, err, &warningsErr)
+}
+
+func TestLoadSchema(t *testing.T) {
+	path := writeFile(t, ".env", "PORT=http\n")
+	schemaPath := writeFile(t, "schema.yaml", "properties:\n  PORT:\n    type: int\n")
+
+	_, err := envsecret.Load(context.Background(), envsecret.Options{Files: []string{path}, Schema: schemaPath})
+	var validationErr *envsecret.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+}
+
+func TestOptionsValidate(t *testing.T) {
+	vault := &parser.FileSource{Path: ".env.vault"}
+
+	tests := []struct {
+		err  error
+		name string
+		opts envsecret.Options
+	}{
+		{name: "Defaults"},
+		{name: "Template", opts: envsecret.Options{Files: []string{".env"}, ValuesFiles: []string{"values.yaml"}, Template: true}},
+		{name: "Values without template", opts: envsecret.Options{ValuesFiles: []string{"values.yaml"}}, err: envsecret.ErrValuesWithoutTemplate},
+		{name: "Template with env source", opts: envsecret.Options{Files: []string{".env", "env://APP_"}, Template: true}, err: envsecret.ErrTemplateSources},
+		{name: "Template with sources", opts: envsecret.Options{Files: []string{".env"}, Sources: []envsecret.Source{vault}, Template: true}, err: envsecret.ErrTemplateSources},
+		{name: "Controller without owner", opts: envsecret.Options{OwnerController: true}, err: envsecret.ErrControllerWithoutOwner},
+		{name: "Negative history limit", opts: envsecret.Options{HistoryLimit: -1}, err: envsecret.ErrNegativeHistoryLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if tt.err == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	secret := envsecret.Build(envsecret.Options{Name: "api", Namespace: "production"}, &envsecret.Values{Data: map[string]string{"PORT": "8080"}})
+	assert.Equal(t, "Secret", secret.Kind)
+	assert.Equal(t, "v1", secret.APIVersion)
+	assert.Equal(t, "api", secret.Name)
+	assert.Equal(t, "production", secret.Namespace)
+	assert.Equal(t, v1.SecretTypeOpaque, secret.Type)
+	assert.Equal(t, map[string][]byte{"PORT": []byte("8080")}, secret.Data)
+}
+
+func TestExpected(t *testing.T) {
+	values := &envsecret.Values{
+		Data:      map[string]string{"API_TOKEN": "new", "SESSION_KEY": "random"},
+		Generated: []string{"SESSION_KEY"},
+	}
+	assert.Equal(t, map[string][]byte{"API_TOKEN": []byte("new"), "SESSION_KEY": []byte("random")}, envsecret.Expected(values, nil))
+
+	live := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"envsecret.ogticrd.io/rotating-keys": "API_TOKEN"}},
+		Data:       map[string][]byte{"API_TOKEN": []byte("new"), "API_TOKEN_PREVIOUS": []byte("old"), "SESSION_KEY": []byte("live")},
+	}
+	assert.Equal(t, live.Data, envsecret.Expected(values, live))
+}
+
+func TestApply(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	opts := envsecret.Options{Name: "api", HistoryLimit: envsecret.DefaultHistoryLimit}
+
+	result, err := envsecret.Apply(ctx, client, opts, &envsecret.Values{Data: map[string]string{"PORT": "8080"}})
+	require.NoError(t, err)
+	assert.Equal(t, envsecret.ActionCreated, result.Action)
+	assert.Equal(t, "kubectl-envsecret", result.Secret.Labels["app.kubernetes.io/managed-by"])
+
+	_, err = envsecret.Apply(ctx, client, opts, &envsecret.Values{Data: map[string]string{"PORT": "9090"}})
+	assert.True(t, kerr.IsAlreadyExists(err))
+
+	opts.Overwrite = true
+	result, err = envsecret.Apply(ctx, client, opts, &envsecret.Values{Data: map[string]string{"PORT": "9090"}})
+	require.NoError(t, err)
+	assert.Equal(t, envsecret.ActionUpdated, result.Action)
+	assert.Equal(t, "~PORT", result.Changes)
+
+	result, err = envsecret.Apply(ctx, client, opts, &envsecret.Values{Data: map[string]string{"PORT": "9090"}})
+	require.NoError(t, err)
+	assert.Equal(t, envsecret.ActionUnchanged, result.Action)
+
+	secret, err := client.CoreV1().Secrets("default").Get(ctx, "api", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("9090"), secret.Data["PORT"])
+}
+
+func TestApplyKeepsGeneratedValues(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+		Data:       map[string][]byte{"SESSION_KEY": []byte("live")},
+	})
+	var warnings bytes.Buffer
+	opts := envsecret.Options{Name: "api", Overwrite: true, Warnings: &warnings}
+
+	result, err := envsecret.Apply(ctx, client, opts, &envsecret.Values{
+		Data:      map[string]string{"SESSION_KEY": "new", "CSRF_KEY": "new", "PORT": "8080"},
+		Generated: []string{"CSRF_KEY", "SESSION_KEY"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, envsecret.ActionUpdated, result.Action)
+	assert.Equal(t, []byte("live"), result.Secret.Data["SESSION_KEY"])
+	assert.Equal(t, []byte("new"), result.Secret.Data["CSRF_KEY"])
+	assert.Equal(t, "Generated a new value for CSRF_KEY\n", warnings.String())
+}
+
+func TestApplyAddsOwnerToUnchangedSecret(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+			Data:       map[string][]byte{"PORT": []byte("8080")},
+		},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default", UID: "1234"}},
+	)
+	opts := envsecret.Options{Name: "api", Overwrite: true, Owner: "deployment/api"}
+	values := &envsecret.Values{Data: map[string]string{"PORT": "8080"}}
+
+	result, err := envsecret.Apply(ctx, client, opts, values)
+	require.NoError(t, err)
+	assert.Equal(t, envsecret.ActionUpdated, result.Action)
+	assert.Empty(t, result.Changes)
+	require.Len(t, result.Secret.OwnerReferences, 1)
+	assert.Equal(t, "Deployment", result.Secret.OwnerReferences[0].Kind)
+	assert.Equal(t, "1234", string(result.Secret.OwnerReferences[0].UID))
+
+	result, err = envsecret.Apply(ctx, client, opts, values)
+	require.NoError(t, err)
+	assert.Equal(t, envsecret.ActionUnchanged, result.Action)
+
+	// Becoming the controller changes the reference too.
+	opts.OwnerController = true
+	result, err = envsecret.Apply(ctx, client, opts, values)
+	require.NoError(t, err)
+	assert.Equal(t, envsecret.ActionUpdated, result.Action)
+	assert.True(t, *result.Secret.OwnerReferences[0].Controller)
+}
+
+func TestApplyErrors(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	values := &envsecret.Values{Data: map[string]string{"PORT": "8080"}}
+
+	_, err := envsecret.Apply(ctx, client, envsecret.Options{}, values)
+	assert.EqualError(t, err, "the name of the secret is required")
+
+	_, err = envsecret.Apply(ctx, client, envsecret.Options{Name: "api", OwnerController: true}, values)
+	assert.EqualError(t, err, "an owner is required to mark it as the controller")
+
+	_, err = envsecret.Apply(ctx, client, envsecret.Options{Name: "api"}, &envsecret.Values{})
+	assert.EqualError(t, err, "no secrets provided")
+
+	_, err = envsecret.Apply(ctx, client, envsecret.Options{Name: "api", Owner: "deployment/api"}, values)
+	assert.ErrorContains(t, err, "owner deployment/api not found")
+}
+
+func TestApplyDuringRotation(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "api",
+			Namespace:   "default",
+			Annotations: map[string]string{"envsecret.ogticrd.io/rotating-keys": "API_TOKEN"},
+		},
+		Data: map[string][]byte{"API_TOKEN": []byte("new"), "API_TOKEN_PREVIOUS": []byte("old")},
+	})
+	opts := envsecret.Options{Name: "api", Overwrite: true}
+
+	result, err := envsecret.Apply(ctx, client, opts, &envsecret.Values{Data: map[string]string{"API_TOKEN": "new"}})
+	require.NoError(t, err)
+	assert.Equal(t, envsecret.ActionUnchanged, result.Action)
+
+	result, err = envsecret.Apply(ctx, client, opts, &envsecret.Values{Data: map[string]string{"API_TOKEN": "new", "PORT": "8080"}})
+	require.NoError(t, err)
+	assert.Equal(t, envsecret.ActionUpdated, result.Action)
+	assert.Equal(t, "+PORT", result.Changes)
+	assert.Equal(t, []byte("old"), result.Secret.Data["API_TOKEN_PREVIOUS"])
+	assert.Equal(t, "API_TOKEN", result.Secret.Annotations["envsecret.ogticrd.io/rotating-keys"])
+}
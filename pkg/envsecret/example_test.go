@@ -0,0 +1,106 @@
+package envsecret_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ogticrd/kubectl-envsecret/pkg/envsecret"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// writeEnvFile writes a .env file to a temporary directory and returns its path.
+func writeEnvFile(content string) string {
+	dir, err := os.MkdirTemp("", "envsecret-example")
+	if err != nil {
+		log.Fatal(err)
+	}
+	path := filepath.Join(dir, ".env")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		log.Fatal(err)
+	}
+	return path
+}
+
+func Example() {
+	path := writeEnvFile("DB_HOST=db.internal\nTLS_KEY=\"-----BEGIN KEY-----\nMIIE\n-----END KEY-----\"\n")
+	defer os.RemoveAll(filepath.Dir(path))
+
+	opts := envsecret.Options{
+		Name:         "api",
+		Namespace:    "production",
+		Files:        []string{path},
+		HistoryLimit: envsecret.DefaultHistoryLimit,
+	}
+
+	values, err := envsecret.Load(context.Background(), opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Any kubernetes.Interface works, e.g. one created with kubernetes.NewForConfig.
+	client := fake.NewSimpleClientset()
+	result, err := envsecret.Apply(context.Background(), client, opts, values)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(result.Action, result.Secret.Namespace+"/"+result.Secret.Name)
+	fmt.Printf("%q\n", result.Secret.Data["TLS_KEY"])
+	// Output:
+	// created production/api
+	// "-----BEGIN KEY-----\nMIIE\n-----END KEY-----"
+}
+
+func ExampleBuild() {
+	path := writeEnvFile("LOG_LEVEL=debug\nPORT=8080\n")
+	defer os.RemoveAll(filepath.Dir(path))
+
+	opts := envsecret.Options{Name: "api", Files: []string{path}}
+	values, err := envsecret.Load(context.Background(), opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	secret := envsecret.Build(opts, values)
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Println(secret.Namespace, secret.Name, secret.Type, keys)
+	// Output: default api Opaque [LOG_LEVEL PORT]
+}
+
+func ExampleApply_overwrite() {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+
+	path := writeEnvFile("LOG_LEVEL=info\n")
+	defer os.RemoveAll(filepath.Dir(path))
+	opts := envsecret.Options{Name: "api", Files: []string{path}, Overwrite: true}
+
+	for _, content := range []string{"LOG_LEVEL=info\n", "LOG_LEVEL=debug\nPORT=8080\n", "LOG_LEVEL=debug\nPORT=8080\n"} {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			log.Fatal(err)
+		}
+		values, err := envsecret.Load(context.Background(), opts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		result, err := envsecret.Apply(ctx, client, opts, values)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(strings.TrimSpace(result.Action + " " + result.Changes))
+	}
+	// Output:
+	// created
+	// updated +PORT ~LOG_LEVEL
+	// unchanged
+}